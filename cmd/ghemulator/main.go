@@ -0,0 +1,31 @@
+// Command ghemulator serves a local GitHub REST API stand-in seeded from a JSON file,
+// so the action binary can be run end to end with INPUT_GITHUB_API_URL pointing at it.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"release-candidate/internal/ghemulator"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	seed := flag.String("seed", "", "path to a JSON seed file describing the organization")
+	flag.Parse()
+
+	state := &ghemulator.State{}
+	if *seed != "" {
+		var err error
+		state, err = ghemulator.LoadState(*seed)
+		if err != nil {
+			log.Fatalf("Error loading seed: %v", err)
+		}
+	}
+
+	log.Printf("GitHub emulator for %q listening on http://%s/", state.Owner, *addr)
+	if err := http.ListenAndServe(*addr, ghemulator.New(state)); err != nil {
+		log.Fatalf("Error serving emulator: %v", err)
+	}
+}
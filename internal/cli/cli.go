@@ -0,0 +1,190 @@
+// Package cli runs the use cases outside GitHub Actions, from a laptop or another CI system.
+// Every action input is also a flag (rc_version becomes --rc-version) and an environment
+// variable (RELEASE_WAVE_RC_VERSION). Flags take precedence over environment variables.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"release-candidate/internal/configs"
+	"sort"
+	"strings"
+)
+
+// EnvPrefix prefixes the environment variable of every input
+const EnvPrefix = "RELEASE_WAVE_"
+
+// Commands maps the subcommands to the use cases they run
+var Commands = map[string]string{
+	"release-candidate":  "Release-Candidate",
+	"production-release": "Production-Release",
+	"main-to-epic-sync":  "Main-To-Epic-Sync",
+	"github-release":     "GitHub-Release",
+	"changelog":          "Changelog",
+	"hotfix":             "Hotfix",
+	"rollback":           "Rollback",
+}
+
+type input struct {
+	name   string
+	usage  string
+	isBool bool
+}
+
+// inputs mirrors the inputs of action.yml, except use_case which is the subcommand
+var inputs = []input{
+	{name: "rc_version", usage: "release version, e.g. v1.4.0, or auto to compute the next one (required)"},
+	{name: "version_bump", usage: "bump of rc_version auto: auto, major, minor or patch (default auto)"},
+	{name: "allow_calver", usage: "accept calendar versions such as 2026.10.1", isBool: true},
+	{name: "version_source_repo", usage: "repository whose releases give the latest version for rc_version auto"},
+	{name: "manifest_path", usage: "path to a YAML or JSON release manifest"},
+	{name: "owner", usage: "organization owning the repositories (required unless set in the manifest)"},
+	{name: "production_branch", usage: "production branch (required unless set in the manifest)"},
+	{name: "development_branch", usage: "branch the release candidate is cut from (default development)"},
+	{name: "pr_title", usage: "release candidate pull request title"},
+	{name: "pr_body", usage: "release candidate pull request body"},
+	{name: "github_token", usage: "GitHub token (also read from GITHUB_TOKEN)"},
+	{name: "github_api_url", usage: "GitHub REST API base URL"},
+	{name: "github_max_retries", usage: "retries for rate limited or transiently failed GitHub calls (default 5)"},
+	{name: "app_id", usage: "GitHub App ID"},
+	{name: "private_key", usage: "GitHub App private key"},
+	{name: "installation_id", usage: "GitHub App installation ID"},
+	{name: "include_repositories", usage: "comma-separated repositories to include (names, globs, re: regexes)"},
+	{name: "exclude_repositories", usage: "comma-separated repositories to exclude"},
+	{name: "exclude_prod_release_repositories", usage: "comma-separated repositories to exclude from production-release"},
+	{name: "repository_topics", usage: "comma-separated topics selecting repositories"},
+	{name: "repository_properties", usage: "comma-separated name=value custom properties selecting repositories"},
+	{name: "environment", usage: "production environment"},
+	{name: "enable_main_to_epic_sync", usage: "run main-to-epic-sync after production-release", isBool: true},
+	{name: "slack_channel", usage: "Slack channel added to the Slack payloads"},
+	{name: "hydra_webhook_url", usage: "Hydra webhook URL"},
+	{name: "hydra_webhook_secret", usage: "Hydra webhook secret"},
+	{name: "concurrency", usage: "repositories processed at the same time (default 4)"},
+	{name: "dry_run", usage: "only record the mutating GitHub calls as a plan", isBool: true},
+	{name: "wait_for_workflows", usage: "wait for the dispatched production runs", isBool: true},
+	{name: "workflow_wait_timeout", usage: "how long to wait for the dispatched runs (default 30m)"},
+	{name: "workflow_poll_interval", usage: "how often the dispatched runs are polled (default 20s)"},
+	{name: "prod_workflow_filter", usage: "regex matched against the production workflow paths (default prod-release.*)"},
+	{name: "production_ref", usage: "dispatch on the production branch or a release tag: branch or tag (default branch)"},
+	{name: "create_releases", usage: "create GitHub releases after production-release", isBool: true},
+	{name: "release_draft", usage: "create the GitHub releases as drafts", isBool: true},
+	{name: "release_prerelease", usage: "mark the GitHub releases as pre-releases", isBool: true},
+	{name: "hotfix_stage", usage: "hotfix stage to run: branch, pull-requests or release (default branch)"},
+	{name: "rollback_version", usage: "release to roll back to instead of the one before rc_version"},
+	{name: "lock_repository", usage: "control repository holding the release lock of the organization"},
+	{name: "lock_branch", usage: "branch of the release lock file (default branch of lock_repository)"},
+	{name: "lock_ttl", usage: "how long the release lock stays valid without a renewal before it can be taken over (default 2h)"},
+	{name: "freeze_calendar", usage: "YAML freeze calendar no production workflow is dispatched during"},
+	{name: "freeze_calendar_path", usage: "path to a YAML or JSON freeze calendar file"},
+	{name: "freeze_override", usage: "release during a freeze window, with freeze_override_reason", isBool: true},
+	{name: "freeze_override_reason", usage: "why the freeze is overridden"},
+	{name: "readiness_checks", usage: "checks to pass before production-release dispatches: all, or pull-requests, ci, reviews"},
+	{name: "readiness_required_approvals", usage: "approvals the reviews readiness check requires (default 1)"},
+	{name: "changelog_group_by", usage: "group the changelog by conventional commit type or by label: type or label (default type)"},
+	{name: "workflow_inputs", usage: "YAML map of templated workflow_dispatch inputs"},
+	{name: "waves", usage: "production rollout waves, one \"name: filters\" per line"},
+	{name: "log_level", usage: "debug, info, warn or error (default info)"},
+}
+
+func flagName(inputName string) string {
+	return strings.ReplaceAll(inputName, "_", "-")
+}
+
+func envName(inputName string) string {
+	return EnvPrefix + strings.ToUpper(inputName)
+}
+
+// Config builds the configuration of a CLI run from its arguments (subcommand then flags) and getenv.
+// It returns flag.ErrHelp after printing the usage when help was asked for.
+func Config(args []string, getenv func(string) string, stderr io.Writer) (*configs.Config, error) {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		Usage(stderr)
+		return nil, flag.ErrHelp
+	}
+	command := args[0]
+	useCase, ok := Commands[command]
+	if !ok {
+		Usage(stderr)
+		return nil, fmt.Errorf("unknown command %q", command)
+	}
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	values := make(map[string]*string)
+	bools := make(map[string]*bool)
+	for _, in := range inputs {
+		usage := fmt.Sprintf("%s [$%s]", in.usage, envName(in.name))
+		if in.isBool {
+			bools[in.name] = fs.Bool(flagName(in.name), false, usage)
+		} else {
+			values[in.name] = fs.String(flagName(in.name), "", usage)
+		}
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: release-wave %s [flags]\n\nFlags:\n", command)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	getInput := func(name string) string {
+		if name == "use_case" {
+			return useCase
+		}
+		if set[flagName(name)] {
+			if value, ok := bools[name]; ok {
+				return fmt.Sprintf("%t", *value)
+			}
+			return *values[name]
+		}
+		if value := getenv(envName(name)); value != "" {
+			return value
+		}
+		if name == "github_token" {
+			return getenv("GITHUB_TOKEN")
+		}
+		return ""
+	}
+
+	cfg, err := configs.Load(getInput)
+	if err != nil {
+		return nil, err
+	}
+	cfg.CLI = true
+	return cfg, nil
+}
+
+// Usage lists the commands
+func Usage(w io.Writer) {
+	commands := make([]string, 0, len(Commands))
+	for command := range Commands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	fmt.Fprintf(w, "Usage: release-wave <command> [flags]\n\nCommands:\n")
+	for _, command := range commands {
+		fmt.Fprintf(w, "  %-20s runs the %s use case\n", command, Commands[command])
+	}
+	fmt.Fprintf(w, "\nRun release-wave <command> -h for its flags. Every flag can also be set with a %s* environment variable.\n", EnvPrefix)
+}
+
+// IsCommand reports whether arg selects a CLI run: a command, or a request for the usage
+func IsCommand(arg string) bool {
+	_, ok := Commands[arg]
+	return ok || arg == "help" || arg == "-h" || arg == "--help"
+}
+
+// IsHelp reports whether err only means the usage was printed
+func IsHelp(err error) bool {
+	return errors.Is(err, flag.ErrHelp)
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"release-candidate/internal/configs"
+)
+
+func TestConfig(t *testing.T) {
+	required := []string{"--owner", "o", "--rc-version", "v1.1.0", "--production-branch", "main"}
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		check   func(t *testing.T, cfg *configs.Config)
+		wantErr string
+		// wantHelp expects the usage to be printed instead of a configuration
+		wantHelp bool
+	}{
+		{
+			name: "use case from the subcommand",
+			args: append([]string{"production-release"}, required...),
+			check: func(t *testing.T, cfg *configs.Config) {
+				if cfg.UseCase != "Production-Release" || !cfg.CLI {
+					t.Errorf("use case, CLI = %s, %v, want Production-Release, true", cfg.UseCase, cfg.CLI)
+				}
+			},
+		},
+		{
+			name: "environment variables",
+			args: []string{"release-candidate"},
+			env: map[string]string{
+				"RELEASE_WAVE_OWNER":             "acme",
+				"RELEASE_WAVE_RC_VERSION":        "v2.0.0",
+				"RELEASE_WAVE_PRODUCTION_BRANCH": "release",
+				"RELEASE_WAVE_CONCURRENCY":       "2",
+			},
+			check: func(t *testing.T, cfg *configs.Config) {
+				if cfg.Owner != "acme" || cfg.RCVersion != "v2.0.0" || cfg.ProductionBranch != "release" || cfg.Concurrency != 2 {
+					t.Errorf("owner, version, branch, concurrency = %s, %s, %s, %d, want the environment", cfg.Owner, cfg.RCVersion, cfg.ProductionBranch, cfg.Concurrency)
+				}
+			},
+		},
+		{
+			name: "flags take precedence over environment variables",
+			args: append([]string{"release-candidate", "--concurrency", "8"}, required...),
+			env:  map[string]string{"RELEASE_WAVE_OWNER": "acme", "RELEASE_WAVE_CONCURRENCY": "2"},
+			check: func(t *testing.T, cfg *configs.Config) {
+				if cfg.Owner != "o" || cfg.Concurrency != 8 {
+					t.Errorf("owner, concurrency = %s, %d, want o, 8", cfg.Owner, cfg.Concurrency)
+				}
+			},
+		},
+		{
+			name: "GITHUB_TOKEN fallback",
+			args: append([]string{"release-candidate"}, required...),
+			env:  map[string]string{"GITHUB_TOKEN": "fallback"},
+			check: func(t *testing.T, cfg *configs.Config) {
+				if cfg.Token != "fallback" {
+					t.Errorf("token = %q, want the GITHUB_TOKEN value", cfg.Token)
+				}
+			},
+		},
+		{
+			name: "prefixed token before GITHUB_TOKEN",
+			args: append([]string{"release-candidate"}, required...),
+			env:  map[string]string{"GITHUB_TOKEN": "fallback", "RELEASE_WAVE_GITHUB_TOKEN": "prefixed"},
+			check: func(t *testing.T, cfg *configs.Config) {
+				if cfg.Token != "prefixed" {
+					t.Errorf("token = %q, want the RELEASE_WAVE_GITHUB_TOKEN value", cfg.Token)
+				}
+			},
+		},
+		{
+			name: "bool flags",
+			args: append([]string{"production-release", "--dry-run", "--wait-for-workflows"}, required...),
+			check: func(t *testing.T, cfg *configs.Config) {
+				if !cfg.DryRun || !cfg.WaitForWorkflows || cfg.EnableMainToEpicSync {
+					t.Errorf("dry run, wait, sync = %v, %v, %v, want true, true, false", cfg.DryRun, cfg.WaitForWorkflows, cfg.EnableMainToEpicSync)
+				}
+			},
+		},
+		{
+			name: "bool flag set to false overrides the environment",
+			args: append([]string{"production-release", "--dry-run=false"}, required...),
+			env:  map[string]string{"RELEASE_WAVE_DRY_RUN": "true"},
+			check: func(t *testing.T, cfg *configs.Config) {
+				if cfg.DryRun {
+					t.Error("dry run = true, want the flag value")
+				}
+			},
+		},
+		{name: "no command", wantHelp: true},
+		{name: "help command", args: []string{"help"}, wantHelp: true},
+		{name: "command help", args: []string{"release-candidate", "-h"}, wantHelp: true},
+		{name: "unknown command", args: []string{"deploy"}, wantErr: `unknown command "deploy"`},
+		{name: "unexpected argument", args: append([]string{"release-candidate", "extra"}, required...), wantErr: `unexpected argument "extra"`},
+		{name: "unknown flag", args: []string{"release-candidate", "--branch", "main"}, wantErr: "flag provided but not defined: -branch"},
+		{name: "missing required input", args: []string{"release-candidate", "--owner", "o"}, wantErr: "rc_version is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(name string) string { return tt.env[name] }
+			var stderr bytes.Buffer
+
+			cfg, err := Config(tt.args, getenv, &stderr)
+			if tt.wantHelp {
+				if !IsHelp(err) {
+					t.Fatalf("Config() error = %v, want the help error", err)
+				}
+				if !strings.Contains(stderr.String(), "Usage: release-wave") {
+					t.Errorf("usage = %q, want the usage", stderr.String())
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Config() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if IsHelp(err) {
+					t.Errorf("IsHelp(%v) = true", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Config() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var usage bytes.Buffer
+	Usage(&usage)
+	for command, useCase := range Commands {
+		if !strings.Contains(usage.String(), command) || !strings.Contains(usage.String(), useCase) {
+			t.Errorf("Usage() = %q, want it to list %s (%s)", usage.String(), command, useCase)
+		}
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	tests := map[string]bool{
+		"production-release": true,
+		"help":               true,
+		"--help":             true,
+		"-test.run=^$":       false,
+		"Production-Release": false,
+	}
+	for arg, want := range tests {
+		if got := IsCommand(arg); got != want {
+			t.Errorf("IsCommand(%q) = %v, want %v", arg, got, want)
+		}
+	}
+}
@@ -1,7 +1,56 @@
 package configs
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
 	"github.com/sethvargo/go-githubactions"
+	"gopkg.in/yaml.v3"
+)
+
+// What production workflows are dispatched on: the production branch, or an immutable tag named
+// after the release version that is created from the head of the production branch
+const (
+	ProductionRefBranch = "branch"
+	ProductionRefTag    = "tag"
+)
+
+// How the changelog groups the changes of a repo: by conventional commit type, or by pull request label
+const (
+	ChangelogGroupByType  = "type"
+	ChangelogGroupByLabel = "label"
+)
+
+// The stages of a hotfix, run one after the other: cut the hotfix branches from the production branch, open the
+// pull requests of the fixed repos back into the production and development branches, and release them once merged
+const (
+	HotfixStageBranch       = "branch"
+	HotfixStagePullRequests = "pull-requests"
+	HotfixStageRelease      = "release"
+)
+
+// The checks of the readiness gate Production-Release runs before dispatching: no release candidate pull request
+// left open, passing checks on the head of the production branch, and approved release candidate pull requests
+const (
+	ReadinessCheckPullRequests = "pull-requests"
+	ReadinessCheckCI           = "ci"
+	ReadinessCheckReviews      = "reviews"
+)
+
+// RCVersionAuto as rc_version computes the release version from the latest release and what changed since
+const RCVersionAuto = "auto"
+
+// How an automatic release version is bumped from the latest release. Auto picks the bump from the conventional
+// commit types of the pull requests merged since: major for breaking changes, minor for features, patch otherwise.
+const (
+	VersionBumpAuto  = "auto"
+	VersionBumpMajor = "major"
+	VersionBumpMinor = "minor"
+	VersionBumpPatch = "patch"
 )
 
 type Config struct {
@@ -10,94 +59,505 @@ type Config struct {
 	Environment                    string
 	Owner                          string
 	Token                          string
+	GitHubAPIURL                   string
 	AppID                          string
 	InstallationID                 string
 	PrivateKey                     string
 	RCVersion                      string
 	ProductionBranch               string
+	DevelopmentBranch              string
+	PRTitle                        string
+	PRBody                         string
 	IncludeRepositories            string
 	ExcludeRepositories            string
 	ExcludeProdReleaseRepositories string
+	RepositoryTopics               string
+	RepositoryProperties           string
 	RCBranch                       string
+	HotfixBranch                   string
 	HydraWebhookURL                string
 	HydraWebhookSecret             string
 	EnableMainToEpicSync           bool
+	DryRun                         bool
+	Concurrency                    int
+	MaxRetries                     int
+	WaitForWorkflows               bool
+	WorkflowWaitTimeout            time.Duration
+	WorkflowPollInterval           time.Duration
+	Waves                          []Wave
+	ProdWorkflowFilter             string
+	ProductionRef                  string
+	CreateReleases                 bool
+	ReleaseDraft                   bool
+	ReleasePrerelease              bool
+	ChangelogGroupBy               string
+	VersionBump                    string
+	AllowCalVer                    bool
+	HotfixStage                    string
+	// ReadinessChecks are the readiness checks to pass before Production-Release dispatches, none by default
+	ReadinessChecks []string
+	// ReadinessRequiredApprovals is how many approvals the reviews readiness check requires
+	ReadinessRequiredApprovals int
+	// LockRepository is the control repo, repo or owner/repo, holding the release lock of Owner. No lock is taken without one.
+	LockRepository string
+	// LockBranch is the branch of the lock file, the default branch of LockRepository when empty
+	LockBranch string
+	// LockTTL is how long a lock is held before another run may take it over
+	LockTTL time.Duration
+	// FreezeCalendar holds the windows Production-Release doesn't dispatch in, nil without one
+	FreezeCalendar *FreezeCalendar
+	// FreezeOverride releases during a freeze window, for FreezeOverrideReason
+	FreezeOverride       bool
+	FreezeOverrideReason string
+	// RollbackVersion is the release Rollback redeploys in every repo, instead of the one before RCVersion
+	RollbackVersion string
+	// VersionSourceRepo is the repo whose releases give the latest version, instead of all selected repos
+	VersionSourceRepo string
+	// WorkflowInputs maps the workflow_dispatch inputs sent to production workflows to text/template values
+	WorkflowInputs map[string]string
+	SlackChannel   string
+	ManifestPath   string
+	// RepoOverrides holds the per-repo manifest overrides keyed by lowercased repository name
+	RepoOverrides map[string]RepoOverride
+	// CLI is set when running from the command line, where outputs are printed to stdout
+	CLI bool
 }
 
+// WorkflowFilterFor returns the production workflow file filter of a repo
+func (c *Config) WorkflowFilterFor(repo string) string {
+	if override, ok := c.RepoOverrides[strings.ToLower(repo)]; ok && override.WorkflowFilter != "" {
+		return override.WorkflowFilter
+	}
+	return c.ProdWorkflowFilter
+}
+
+// ReadinessCheck reports whether the readiness check is enabled
+func (c *Config) ReadinessCheck(check string) bool {
+	for _, enabled := range c.ReadinessChecks {
+		if enabled == check {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkflowInputData is what workflow input templates can reference
+type WorkflowInputData struct {
+	// RCVersion is the release version as given, e.g. v1.4.0, and Version the same without the v
+	RCVersion        string
+	Version          string
+	Environment      string
+	Repo             string
+	Owner            string
+	ProductionBranch string
+}
+
+// WorkflowInputsFor renders the workflow_dispatch inputs of a repo released at version, RCVersion unless it is rolled
+// back. Its overrides replace global inputs of the same name.
+func (c *Config) WorkflowInputsFor(repo string, version string) (map[string]interface{}, error) {
+	templates := make(map[string]string, len(c.WorkflowInputs))
+	for name, value := range c.WorkflowInputs {
+		templates[name] = value
+	}
+	if override, ok := c.RepoOverrides[strings.ToLower(repo)]; ok {
+		for name, value := range override.Inputs {
+			templates[name] = value
+		}
+	}
+
+	data := WorkflowInputData{
+		RCVersion:        version,
+		Version:          strings.TrimPrefix(version, "v"),
+		Environment:      c.EnvironmentFor(repo),
+		Repo:             repo,
+		Owner:            c.Owner,
+		ProductionBranch: c.ProductionBranch,
+	}
+	inputs := make(map[string]interface{}, len(templates))
+	for name, value := range templates {
+		rendered, err := renderInputTemplate(name, value, data)
+		if err != nil {
+			return nil, err
+		}
+		inputs[name] = rendered
+	}
+	return inputs, nil
+}
+
+func renderInputTemplate(name string, value string, data WorkflowInputData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("input %s is not a valid template: %v", name, err)
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("error rendering input %s: %v", name, err)
+	}
+	return rendered.String(), nil
+}
+
+// EnvironmentFor returns the environment a repo is released to
+func (c *Config) EnvironmentFor(repo string) string {
+	if override, ok := c.RepoOverrides[strings.ToLower(repo)]; ok && override.Environment != "" {
+		return override.Environment
+	}
+	return c.Environment
+}
+
+// InputFunc returns the value of an input by its action input name, or "" when it isn't set
+type InputFunc func(name string) string
+
+// or returns an input, or the manifest value when the input isn't set
+func (getInput InputFunc) or(name string, manifestValue string) string {
+	if value := getInput(name); value != "" {
+		return value
+	}
+	return manifestValue
+}
+
+// boolOr returns a boolean input, or the manifest value when the input isn't set
+func (getInput InputFunc) boolOr(name string, manifestValue *bool) bool {
+	if value := getInput(name); value != "" {
+		return value == "true"
+	}
+	return manifestValue != nil && *manifestValue
+}
+
+// Variables reads the configuration from the GitHub Actions inputs and fails the step if it is invalid
 func Variables() (*Config, error) {
+	cfg, err := Load(githubactions.GetInput)
+	if err != nil {
+		githubactions.Fatalf("%v", err)
+	}
+	for _, secret := range []string{cfg.Token, cfg.AppID, cfg.InstallationID, cfg.PrivateKey, cfg.HydraWebhookSecret} {
+		if secret != "" {
+			githubactions.AddMask(secret)
+		}
+	}
+	return cfg, nil
+}
 
-	logLevel := githubactions.GetInput("log_level")
+// Load builds the configuration from getInput, merged over the manifest when manifest_path is set
+func Load(getInput InputFunc) (*Config, error) {
+	logLevel := getInput("log_level")
 	if logLevel == "" {
 		logLevel = "info"
 	}
 
-	owner := githubactions.GetInput("owner")
+	manifest := &Manifest{}
+	manifestPath := getInput("manifest_path")
+	if manifestPath != "" {
+		var err error
+		manifest, err = LoadManifest(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	owner := getInput.or("owner", manifest.Owner)
 	if owner == "" {
-		githubactions.Fatalf("owner is required")
+		return nil, fmt.Errorf("owner is required")
 	}
 
-	token := githubactions.GetInput("github_token")
-	if token != "" {
-		githubactions.AddMask(token)
+	token := getInput("github_token")
+	githubAPIURL := getInput("github_api_url")
+	appID := getInput("app_id")
+	installationId := getInput("installation_id")
+	privateKey := getInput("private_key")
+
+	rcVersion := getInput("rc_version")
+	if rcVersion == "" {
+		return nil, fmt.Errorf("rc_version is required")
 	}
 
-	appID := githubactions.GetInput("app_id")
-	if appID != "" {
-		githubactions.AddMask(appID)
+	productionBranch := getInput.or("production_branch", manifest.Branches.Production)
+	if productionBranch == "" {
+		return nil, fmt.Errorf("production_branch is required, as an input or as branches.production in the manifest")
 	}
 
-	installationId := githubactions.GetInput("installation_id")
-	if installationId != "" {
-		githubactions.AddMask(installationId)
+	developmentBranch := getInput.or("development_branch", manifest.Branches.Development)
+	if developmentBranch == "" {
+		developmentBranch = "development"
 	}
 
-	privateKey := githubactions.GetInput("private_key")
-	if privateKey != "" {
+	prTitle := getInput.or("pr_title", manifest.PullRequest.Title)
+	prBody := getInput.or("pr_body", manifest.PullRequest.Body)
 
-		githubactions.AddMask(privateKey)
+	usecase := getInput("use_case")
+	environment := getInput.or("environment", manifest.Environment)
+
+	excludeRepositories := getInput.or("exclude_repositories", manifest.Repositories.Exclude.String())
+	includeRepositories := getInput.or("include_repositories", manifest.Repositories.Include.String())
+	excludeProdReleaseRepostories := getInput.or("exclude_prod_release_repositories", manifest.Repositories.ExcludeProdRelease.String())
+	repositoryTopics := getInput.or("repository_topics", manifest.Repositories.Topics.String())
+	repositoryProperties := getInput.or("repository_properties", manifest.propertiesInput())
+
+	// Skipped repos are excluded on top of whichever exclude list applies
+	repoOverrides := make(map[string]RepoOverride)
+	for repo, override := range manifest.Overrides {
+		if override.WorkflowFilter != "" {
+			if _, err := regexp.Compile(override.WorkflowFilter); err != nil {
+				return nil, fmt.Errorf("overrides.%s.workflow_filter is not a valid regular expression: %v", repo, err)
+			}
+		}
+		if err := validateInputTemplates("overrides."+repo+".inputs", override.Inputs); err != nil {
+			return nil, err
+		}
+		repoOverrides[strings.ToLower(repo)] = override
+		if override.Skip {
+			excludeRepositories = strings.TrimPrefix(excludeRepositories+", "+repo, ", ")
+		}
 	}
 
-	rcVersion := githubactions.GetInput("rc_version")
-	if rcVersion == "" {
-		githubactions.Fatalf("rc_version is required")
+	enableMainToEpicSyncBool := getInput.boolOr("enable_main_to_epic_sync", manifest.Epics.EnableMainToEpicSync)
+
+	dryRun := getInput("dry_run") == "true"
+
+	concurrency := 4
+	if manifest.Concurrency > 0 {
+		concurrency = manifest.Concurrency
+	}
+	if concurrencyString := getInput("concurrency"); concurrencyString != "" {
+		var err error
+		concurrency, err = strconv.Atoi(concurrencyString)
+		if err != nil || concurrency < 1 {
+			return nil, fmt.Errorf("concurrency should be a positive integer")
+		}
 	}
 
-	productionBranch := githubactions.GetInput("production_branch")
-	if productionBranch == "" {
-		githubactions.Fatalf("production_branch is required")
+	maxRetries := 5
+	if maxRetriesString := getInput("github_max_retries"); maxRetriesString != "" {
+		var err error
+		maxRetries, err = strconv.Atoi(maxRetriesString)
+		if err != nil || maxRetries < 0 {
+			return nil, fmt.Errorf("github_max_retries should be a non-negative integer")
+		}
+	}
+
+	waitForWorkflows := getInput.boolOr("wait_for_workflows", manifest.Workflows.Wait)
+
+	workflowWaitTimeout := 30 * time.Minute
+	if timeoutString := getInput.or("workflow_wait_timeout", manifest.Workflows.WaitTimeout); timeoutString != "" {
+		var err error
+		workflowWaitTimeout, err = time.ParseDuration(timeoutString)
+		if err != nil || workflowWaitTimeout <= 0 {
+			return nil, fmt.Errorf("workflow_wait_timeout should be a positive duration such as 30m")
+		}
+	}
+
+	workflowPollInterval := 20 * time.Second
+	if intervalString := getInput.or("workflow_poll_interval", manifest.Workflows.PollInterval); intervalString != "" {
+		var err error
+		workflowPollInterval, err = time.ParseDuration(intervalString)
+		if err != nil || workflowPollInterval <= 0 {
+			return nil, fmt.Errorf("workflow_poll_interval should be a positive duration such as 20s")
+		}
+	}
+
+	// A bad wave definition fails the run before anything is changed, not halfway through a release
+	waves, err := manifest.waves()
+	if err != nil {
+		return nil, err
+	}
+	if wavesString := getInput("waves"); wavesString != "" {
+		if waves, err = ParseWaves(wavesString); err != nil {
+			return nil, fmt.Errorf("waves: %v", err)
+		}
+	}
+
+	prodWorkflowFilter := getInput.or("prod_workflow_filter", manifest.Workflows.ProdFilter)
+	if prodWorkflowFilter == "" {
+		prodWorkflowFilter = "prod-release.*"
+	}
+	if _, err := regexp.Compile(prodWorkflowFilter); err != nil {
+		return nil, fmt.Errorf("prod_workflow_filter is not a valid regular expression: %v", err)
+	}
+
+	// The inputs sent before they were configurable remain the default
+	workflowInputs := map[string]string{
+		"environment":     "{{.Environment}}",
+		"release_version": "{{.RCVersion}}",
+	}
+	if workflowInputsString := getInput("workflow_inputs"); workflowInputsString != "" {
+		workflowInputs = make(map[string]string)
+		if err := yaml.Unmarshal([]byte(workflowInputsString), &workflowInputs); err != nil {
+			return nil, fmt.Errorf("workflow_inputs should be name: value lines: %v", err)
+		}
+	} else if manifest.Workflows.Inputs != nil {
+		workflowInputs = manifest.Workflows.Inputs
+	}
+	if err := validateInputTemplates("workflow_inputs", workflowInputs); err != nil {
+		return nil, err
+	}
+
+	productionRef := getInput.or("production_ref", manifest.Workflows.ProductionRef)
+	if productionRef == "" {
+		productionRef = ProductionRefBranch
 	}
+	if productionRef != ProductionRefBranch && productionRef != ProductionRefTag {
+		return nil, fmt.Errorf("production_ref should be %s or %s", ProductionRefBranch, ProductionRefTag)
+	}
+
+	createReleases := getInput.boolOr("create_releases", manifest.Releases.Create)
+	releaseDraft := getInput.boolOr("release_draft", manifest.Releases.Draft)
+	releasePrerelease := getInput.boolOr("release_prerelease", manifest.Releases.Prerelease)
+
+	changelogGroupBy := getInput.or("changelog_group_by", manifest.Changelog.GroupBy)
+	if changelogGroupBy == "" {
+		changelogGroupBy = ChangelogGroupByType
+	}
+	if changelogGroupBy != ChangelogGroupByType && changelogGroupBy != ChangelogGroupByLabel {
+		return nil, fmt.Errorf("changelog_group_by should be %s or %s", ChangelogGroupByType, ChangelogGroupByLabel)
+	}
+
+	versionBump := getInput.or("version_bump", manifest.Version.Bump)
+	if versionBump == "" {
+		versionBump = VersionBumpAuto
+	}
+	switch versionBump {
+	case VersionBumpAuto, VersionBumpMajor, VersionBumpMinor, VersionBumpPatch:
+	default:
+		return nil, fmt.Errorf("version_bump should be %s, %s, %s or %s", VersionBumpAuto, VersionBumpMajor, VersionBumpMinor, VersionBumpPatch)
+	}
+	versionSourceRepo := getInput.or("version_source_repo", manifest.Version.SourceRepo)
+	allowCalVer := getInput.boolOr("allow_calver", manifest.Version.AllowCalVer)
+
+	hotfixStage := getInput("hotfix_stage")
+	if hotfixStage == "" {
+		hotfixStage = HotfixStageBranch
+	}
+	if hotfixStage != HotfixStageBranch && hotfixStage != HotfixStagePullRequests && hotfixStage != HotfixStageRelease {
+		return nil, fmt.Errorf("hotfix_stage should be %s, %s or %s", HotfixStageBranch, HotfixStagePullRequests, HotfixStageRelease)
+	}
+
+	rollbackVersion := getInput("rollback_version")
 
-	usecase := githubactions.GetInput("use_case")
-	environment := githubactions.GetInput("environment")
+	var readinessChecks []string
+	for _, check := range strings.Split(getInput.or("readiness_checks", manifest.Readiness.Checks.String()), ",") {
+		switch check = strings.TrimSpace(check); check {
+		case "":
+		case "all":
+			readinessChecks = []string{ReadinessCheckPullRequests, ReadinessCheckCI, ReadinessCheckReviews}
+		case ReadinessCheckPullRequests, ReadinessCheckCI, ReadinessCheckReviews:
+			readinessChecks = append(readinessChecks, check)
+		default:
+			return nil, fmt.Errorf("readiness_checks should be all or a comma-separated list of %s, %s and %s, got %q", ReadinessCheckPullRequests, ReadinessCheckCI, ReadinessCheckReviews, check)
+		}
+	}
 
-	excludeRepositories := githubactions.GetInput("exclude_repositories")
-	includeRepositories := githubactions.GetInput("include_repositories")
-	excludeProdReleaseRepostories := githubactions.GetInput("exclude_prod_release_repositories")
+	readinessRequiredApprovals := 1
+	if manifest.Readiness.RequiredApprovals != nil {
+		readinessRequiredApprovals = *manifest.Readiness.RequiredApprovals
+	}
+	if approvalsString := getInput("readiness_required_approvals"); approvalsString != "" {
+		var err error
+		readinessRequiredApprovals, err = strconv.Atoi(approvalsString)
+		if err != nil || readinessRequiredApprovals < 0 {
+			return nil, fmt.Errorf("readiness_required_approvals should be a non-negative integer")
+		}
+	}
 
-	enableMainToEpicSyncString := githubactions.GetInput("enable_main_to_epic_sync")
-	enableMainToEpicSyncBool := (enableMainToEpicSyncString == "true")
+	lockRepository := getInput.or("lock_repository", manifest.Lock.Repository)
+	lockBranch := getInput.or("lock_branch", manifest.Lock.Branch)
+	lockTTL := 2 * time.Hour
+	if ttlString := getInput.or("lock_ttl", manifest.Lock.TTL); ttlString != "" {
+		var err error
+		lockTTL, err = time.ParseDuration(ttlString)
+		if err != nil || lockTTL <= 0 {
+			return nil, fmt.Errorf("lock_ttl should be a positive duration such as 2h")
+		}
+	}
 
-    hydraWebhookURL := githubactions.GetInput("hydra_webhook_url")
-	hydraWebhookSecret := githubactions.GetInput("hydra_webhook_secret")
-	if hydraWebhookSecret != "" {
-		githubactions.AddMask(hydraWebhookSecret)
+	freezeCalendar := manifest.Freeze
+	if freezeCalendar != nil {
+		if err := freezeCalendar.compile(); err != nil {
+			return nil, fmt.Errorf("freeze: %v", err)
+		}
+	}
+	if freezeCalendarPath := getInput("freeze_calendar_path"); freezeCalendarPath != "" {
+		var err error
+		if freezeCalendar, err = LoadFreezeCalendar(freezeCalendarPath); err != nil {
+			return nil, err
+		}
+	}
+	if freezeCalendarString := getInput("freeze_calendar"); freezeCalendarString != "" {
+		var err error
+		if freezeCalendar, err = ParseFreezeCalendar(freezeCalendarString); err != nil {
+			return nil, fmt.Errorf("freeze_calendar: %v", err)
+		}
 	}
+	freezeOverride := getInput("freeze_override") == "true"
+	freezeOverrideReason := strings.TrimSpace(getInput("freeze_override_reason"))
+	if freezeOverride && freezeOverrideReason == "" {
+		return nil, fmt.Errorf("freeze_override requires freeze_override_reason")
+	}
+
+	slackChannel := getInput.or("slack_channel", manifest.Notifications.SlackChannel)
+
+	hydraWebhookURL := getInput.or("hydra_webhook_url", manifest.Epics.HydraWebhookURL)
+	hydraWebhookSecret := getInput("hydra_webhook_secret")
 	return &Config{
 		LogLevel:                       logLevel,
 		UseCase:                        usecase,
 		Owner:                          owner,
 		Token:                          token,
+		GitHubAPIURL:                   githubAPIURL,
 		AppID:                          appID,
 		PrivateKey:                     privateKey,
 		InstallationID:                 installationId,
 		RCVersion:                      rcVersion,
 		ProductionBranch:               productionBranch,
+		DevelopmentBranch:              developmentBranch,
+		PRTitle:                        prTitle,
+		PRBody:                         prBody,
 		Environment:                    environment,
 		IncludeRepositories:            includeRepositories,
 		ExcludeRepositories:            excludeRepositories,
 		ExcludeProdReleaseRepositories: excludeProdReleaseRepostories,
+		RepositoryTopics:               repositoryTopics,
+		RepositoryProperties:           repositoryProperties,
 		HydraWebhookURL:                hydraWebhookURL,
 		HydraWebhookSecret:             hydraWebhookSecret,
-		EnableMainToEpicSync:			enableMainToEpicSyncBool,
+		EnableMainToEpicSync:           enableMainToEpicSyncBool,
+		DryRun:                         dryRun,
+		Concurrency:                    concurrency,
+		MaxRetries:                     maxRetries,
+		WaitForWorkflows:               waitForWorkflows,
+		WorkflowWaitTimeout:            workflowWaitTimeout,
+		WorkflowPollInterval:           workflowPollInterval,
+		Waves:                          waves,
+		ProdWorkflowFilter:             prodWorkflowFilter,
+		ProductionRef:                  productionRef,
+		CreateReleases:                 createReleases,
+		ReleaseDraft:                   releaseDraft,
+		ReleasePrerelease:              releasePrerelease,
+		ChangelogGroupBy:               changelogGroupBy,
+		VersionBump:                    versionBump,
+		AllowCalVer:                    allowCalVer,
+		HotfixStage:                    hotfixStage,
+		RollbackVersion:                rollbackVersion,
+		ReadinessChecks:                readinessChecks,
+		ReadinessRequiredApprovals:     readinessRequiredApprovals,
+		LockRepository:                 lockRepository,
+		LockBranch:                     lockBranch,
+		LockTTL:                        lockTTL,
+		FreezeCalendar:                 freezeCalendar,
+		FreezeOverride:                 freezeOverride,
+		FreezeOverrideReason:           freezeOverrideReason,
+		VersionSourceRepo:              versionSourceRepo,
+		WorkflowInputs:                 workflowInputs,
+		SlackChannel:                   slackChannel,
+		ManifestPath:                   manifestPath,
+		RepoOverrides:                  repoOverrides,
 	}, nil
 }
+
+// validateInputTemplates renders every workflow input once so unknown fields are reported before anything is dispatched
+func validateInputTemplates(key string, inputs map[string]string) error {
+	for name, value := range inputs {
+		if _, err := renderInputTemplate(name, value, WorkflowInputData{}); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return nil
+}
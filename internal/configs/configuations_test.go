@@ -0,0 +1,92 @@
+package configs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  map[string]string
+		wantErr string
+	}{
+		{name: "missing owner", inputs: map[string]string{"owner": ""}, wantErr: "owner is required"},
+		{name: "missing rc_version", inputs: map[string]string{"rc_version": ""}, wantErr: "rc_version is required"},
+		{name: "missing production_branch", inputs: map[string]string{"production_branch": ""}, wantErr: "production_branch is required"},
+		{name: "invalid waves", inputs: map[string]string{"waves": "canary"}, wantErr: "waves:"},
+		{name: "invalid workflow filter", inputs: map[string]string{"prod_workflow_filter": "prod-(release"}, wantErr: "prod_workflow_filter is not a valid regular expression"},
+		{name: "invalid workflow inputs", inputs: map[string]string{"workflow_inputs": "[environment]"}, wantErr: "workflow_inputs should be name: value lines"},
+		{name: "unknown template field", inputs: map[string]string{"workflow_inputs": "version: '{{.Tag}}'"}, wantErr: "workflow_inputs: error rendering input version"},
+		{name: "freeze override without a reason", inputs: map[string]string{"freeze_override": "true"}, wantErr: "freeze_override requires freeze_override_reason"},
+		{name: "invalid freeze calendar", inputs: map[string]string{"freeze_calendar": "timezone: UTC"}, wantErr: "freeze_calendar: "},
+		{name: "missing manifest", inputs: map[string]string{"manifest_path": "/does/not/exist.yml"}, wantErr: "exist.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := map[string]string{"owner": "o", "rc_version": "v1.0.0", "production_branch": "main"}
+			for name, value := range tt.inputs {
+				inputs[name] = value
+			}
+
+			_, err := Load(func(name string) string { return inputs[name] })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflowInputsFor(t *testing.T) {
+	cfg := &Config{
+		Owner:            "o",
+		RCVersion:        "v1.4.0",
+		Environment:      "production",
+		ProductionBranch: "main",
+		WorkflowInputs: map[string]string{
+			"environment": "{{.Environment}}",
+			"version":     "{{.Version}}",
+			"target":      "{{.Owner}}/{{.Repo}}@{{.ProductionBranch}}",
+		},
+		RepoOverrides: map[string]RepoOverride{
+			"billing": {Environment: "production-eu", Inputs: map[string]string{"version": "{{.RCVersion}}", "region": "eu"}},
+		},
+	}
+	tests := []struct {
+		name    string
+		repo    string
+		version string
+		want    map[string]interface{}
+	}{
+		{
+			name:    "global inputs",
+			repo:    "api",
+			version: "v1.4.0",
+			want:    map[string]interface{}{"environment": "production", "version": "1.4.0", "target": "o/api@main"},
+		},
+		{
+			name:    "overrides",
+			repo:    "Billing",
+			version: "v1.4.0",
+			want:    map[string]interface{}{"environment": "production-eu", "version": "v1.4.0", "target": "o/Billing@main", "region": "eu"},
+		},
+		{
+			name:    "rolled back version",
+			repo:    "api",
+			version: "v1.3.2",
+			want:    map[string]interface{}{"environment": "production", "version": "1.3.2", "target": "o/api@main"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.WorkflowInputsFor(tt.repo, tt.version)
+			if err != nil {
+				t.Fatalf("WorkflowInputsFor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WorkflowInputsFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package configs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RepoFilter matches repository names against a comma-separated list of entries.
+// Each entry is an exact name (api-gateway), a glob (svc-*) or a regular expression
+// prefixed with "re:" (re:^legacy-). Exact names and globs are case insensitive like GitHub repository names.
+type RepoFilter struct {
+	exact    []string
+	globs    []string
+	patterns []*regexp.Regexp
+}
+
+func ParseRepoFilter(list string) (RepoFilter, error) {
+	return NewRepoFilter(strings.Split(list, ","))
+}
+
+// NewRepoFilter builds a filter from entries that are already split, so their regexes may contain commas
+func NewRepoFilter(entries []string) (RepoFilter, error) {
+	var filter RepoFilter
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(entry, "re:"):
+			re, err := regexp.Compile(strings.TrimPrefix(entry, "re:"))
+			if err != nil {
+				return RepoFilter{}, fmt.Errorf("invalid repository regex %q: %v", entry, err)
+			}
+			filter.patterns = append(filter.patterns, re)
+		case strings.ContainsAny(entry, "*?["):
+			if _, err := path.Match(entry, ""); err != nil {
+				return RepoFilter{}, fmt.Errorf("invalid repository glob %q: %v", entry, err)
+			}
+			filter.globs = append(filter.globs, strings.ToLower(entry))
+		default:
+			filter.exact = append(filter.exact, entry)
+		}
+	}
+	return filter, nil
+}
+
+func (f RepoFilter) Empty() bool {
+	return len(f.exact) == 0 && len(f.globs) == 0 && len(f.patterns) == 0
+}
+
+// ExactOnly reports whether the filter lists only exact names, so repositories can be fetched by name
+func (f RepoFilter) ExactOnly() bool {
+	return len(f.globs) == 0 && len(f.patterns) == 0
+}
+
+// ExactNames returns the exact names in the order they were listed
+func (f RepoFilter) ExactNames() []string {
+	return f.exact
+}
+
+func (f RepoFilter) Match(repoName string) bool {
+	for _, name := range f.exact {
+		if strings.EqualFold(name, repoName) {
+			return true
+		}
+	}
+	for _, glob := range f.globs {
+		if matched, _ := path.Match(glob, strings.ToLower(repoName)); matched {
+			return true
+		}
+	}
+	for _, re := range f.patterns {
+		if re.MatchString(repoName) {
+			return true
+		}
+	}
+	return false
+}
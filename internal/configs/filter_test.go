@@ -0,0 +1,55 @@
+package configs
+
+import "testing"
+
+func TestParseRepoFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		list          string
+		wantErr       bool
+		wantExactOnly bool
+		match         []string
+		noMatch       []string
+	}{
+		{name: "empty", list: " , ", wantExactOnly: true, noMatch: []string{"api"}},
+		{name: "exact names ignore case", list: "api, Web", wantExactOnly: true, match: []string{"API", "web"}, noMatch: []string{"api-v2"}},
+		{name: "glob", list: "svc-*", match: []string{"svc-a", "SVC-B"}, noMatch: []string{"my-svc-a"}},
+		{name: "regex", list: "re:^legacy-", match: []string{"legacy-api"}, noMatch: []string{"api-legacy-"}},
+		{name: "invalid regex", list: "re:(", wantErr: true},
+		{name: "invalid glob", list: "svc-[", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseRepoFilter(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRepoFilter(%q) error = %v, wantErr %v", tt.list, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if filter.ExactOnly() != tt.wantExactOnly {
+				t.Errorf("ExactOnly() = %v, want %v", filter.ExactOnly(), tt.wantExactOnly)
+			}
+			for _, repo := range tt.match {
+				if !filter.Match(repo) {
+					t.Errorf("Match(%q) = false, want true", repo)
+				}
+			}
+			for _, repo := range tt.noMatch {
+				if filter.Match(repo) {
+					t.Errorf("Match(%q) = true, want false", repo)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRepoFilterKeepsCommasInRegexes(t *testing.T) {
+	filter, err := NewRepoFilter([]string{"re:^api-v{1,2}$"})
+	if err != nil {
+		t.Fatalf("NewRepoFilter() error = %v", err)
+	}
+	if !filter.Match("api-vv") || filter.Match("api-vvv") {
+		t.Error("the regex was not kept whole")
+	}
+}
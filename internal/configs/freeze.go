@@ -0,0 +1,245 @@
+package configs
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	// The action runs from a scratch image, which has no time zone database
+	_ "time/tzdata"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FreezeCalendar lists the periods production releases are frozen in, from freeze_calendar,
+// freeze_calendar_path or the freeze section of the manifest
+type FreezeCalendar struct {
+	// Timezone applies to the windows without one of their own, UTC by default
+	Timezone string         `yaml:"timezone"`
+	Windows  []FreezeWindow `yaml:"windows"`
+}
+
+// FreezeWindow is a freeze period, of one of three kinds:
+//   - weekly, on Days (mon to sun), all day or from Start to End (HH:MM, up to 24:00, wrapping past midnight
+//     when End is before Start)
+//   - yearly, From To as MM-DD, both days included, such as 12-24 to 01-02
+//   - once, From To as YYYY-MM-DD, both days included, or as YYYY-MM-DDTHH:MM
+type FreezeWindow struct {
+	Name     string     `yaml:"name"`
+	Timezone string     `yaml:"timezone"`
+	Days     StringList `yaml:"days"`
+	Start    string     `yaml:"start"`
+	End      string     `yaml:"end"`
+	From     string     `yaml:"from"`
+	To       string     `yaml:"to"`
+
+	location *time.Location
+	weekdays map[time.Weekday]bool
+	// start and end are minutes since midnight of a weekly window with hours
+	start, end int
+	// fromDay and toDay are the month*100+day of a yearly window
+	fromDay, toDay int
+	// from and to bound a window that happens once, to excluded
+	from, to time.Time
+}
+
+// LoadFreezeCalendar reads a freeze calendar from a YAML or JSON file
+func LoadFreezeCalendar(path string) (*FreezeCalendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading freeze calendar %s: %v", path, err)
+	}
+	calendar, err := ParseFreezeCalendar(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("freeze calendar %s: %v", path, err)
+	}
+	return calendar, nil
+}
+
+// ParseFreezeCalendar reads a freeze calendar from YAML or JSON and validates its windows
+func ParseFreezeCalendar(definition string) (*FreezeCalendar, error) {
+	var calendar FreezeCalendar
+	decoder := yaml.NewDecoder(strings.NewReader(definition))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&calendar); err != nil {
+		return nil, fmt.Errorf("error parsing freeze calendar: %v", err)
+	}
+	if err := calendar.compile(); err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// compile validates the windows and parses their days and times
+func (c *FreezeCalendar) compile() error {
+	if len(c.Windows) == 0 {
+		return fmt.Errorf("freeze calendar has no windows")
+	}
+	for i := range c.Windows {
+		window := &c.Windows[i]
+		if window.Name == "" {
+			return fmt.Errorf("windows[%d] has no name", i)
+		}
+		timezone := window.Timezone
+		if timezone == "" {
+			timezone = c.Timezone
+		}
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return fmt.Errorf("window %q: unknown timezone %q", window.Name, timezone)
+		}
+		window.location = location
+		if err := window.compile(); err != nil {
+			return fmt.Errorf("window %q: %v", window.Name, err)
+		}
+	}
+	return nil
+}
+
+func (w *FreezeWindow) compile() error {
+	switch {
+	case len(w.Days) > 0:
+		if w.From != "" || w.To != "" {
+			return fmt.Errorf("days can't be combined with from and to")
+		}
+		w.weekdays = make(map[time.Weekday]bool)
+		for _, day := range w.Days {
+			weekday, err := parseWeekday(day)
+			if err != nil {
+				return err
+			}
+			w.weekdays[weekday] = true
+		}
+		if w.Start == "" && w.End == "" {
+			return nil
+		}
+		if w.Start == "" || w.End == "" {
+			return fmt.Errorf("start and end should be set together")
+		}
+		var err error
+		if w.start, err = parseClock(w.Start); err != nil {
+			return fmt.Errorf("start: %v", err)
+		}
+		if w.end, err = parseClock(w.End); err != nil {
+			return fmt.Errorf("end: %v", err)
+		}
+		if w.start == w.end {
+			return fmt.Errorf("start and end should differ")
+		}
+	case w.From == "" || w.To == "":
+		return fmt.Errorf("should have days, or from and to")
+	case w.Start != "" || w.End != "":
+		return fmt.Errorf("start and end only apply to days")
+	case len(w.From) == len("01-02"):
+		from, fromErr := time.Parse("01-02", w.From)
+		to, toErr := time.Parse("01-02", w.To)
+		if fromErr != nil || toErr != nil {
+			return fmt.Errorf("from and to should both be MM-DD, YYYY-MM-DD or YYYY-MM-DDTHH:MM")
+		}
+		w.fromDay, w.toDay = int(from.Month())*100+from.Day(), int(to.Month())*100+to.Day()
+	default:
+		var err error
+		if w.from, err = parseFreezeTime(w.From, w.location, false); err != nil {
+			return fmt.Errorf("from: %v", err)
+		}
+		if w.to, err = parseFreezeTime(w.To, w.location, true); err != nil {
+			return fmt.Errorf("to: %v", err)
+		}
+		if !w.from.Before(w.to) {
+			return fmt.Errorf("to should be after from")
+		}
+	}
+	return nil
+}
+
+func parseWeekday(day string) (time.Weekday, error) {
+	name := strings.ToLower(strings.TrimSpace(day))
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if full := strings.ToLower(weekday.String()); name == full || name == full[:3] {
+			return weekday, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q, days are mon to sun", day)
+}
+
+// parseClock returns the minutes since midnight of HH:MM, accepting 24:00 as the end of the day
+func parseClock(clock string) (int, error) {
+	if clock == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%q should be HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseFreezeTime parses a date or date and time. A date that ends a window includes the whole day.
+func parseFreezeTime(value string, location *time.Location, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, location); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q should be MM-DD, YYYY-MM-DD or YYYY-MM-DDTHH:MM", value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ActiveWindow returns the first window now falls in and when it ends, or nil if releases aren't frozen.
+// A weekly window covering every day never ends and returns a zero time.
+func (c *FreezeCalendar) ActiveWindow(now time.Time) (*FreezeWindow, time.Time) {
+	for i := range c.Windows {
+		if until, ok := c.Windows[i].activeUntil(now); ok {
+			return &c.Windows[i], until
+		}
+	}
+	return nil, time.Time{}
+}
+
+func (w *FreezeWindow) activeUntil(now time.Time) (time.Time, bool) {
+	now = now.In(w.location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, w.location)
+	switch {
+	case w.weekdays != nil && w.Start == "":
+		if !w.weekdays[now.Weekday()] {
+			return time.Time{}, false
+		}
+		for days := 1; days < 7; days++ {
+			if next := midnight.AddDate(0, 0, days); !w.weekdays[next.Weekday()] {
+				return next, true
+			}
+		}
+		return time.Time{}, true
+	case w.weekdays != nil:
+		minute := now.Hour()*60 + now.Minute()
+		// The wall clock time of a day, so a DST change earlier that day doesn't shift it
+		at := func(day time.Time, minutes int) time.Time {
+			return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, w.location)
+		}
+		if w.start < w.end {
+			return at(midnight, w.end), w.weekdays[now.Weekday()] && minute >= w.start && minute < w.end
+		}
+		// The window wraps past midnight into the next day
+		if w.weekdays[now.Weekday()] && minute >= w.start {
+			return at(midnight.AddDate(0, 0, 1), w.end), true
+		}
+		yesterday := midnight.AddDate(0, 0, -1)
+		return at(midnight, w.end), w.weekdays[yesterday.Weekday()] && minute < w.end
+	case w.fromDay != 0:
+		day := int(now.Month())*100 + now.Day()
+		endYear := now.Year()
+		active := w.fromDay <= day && day <= w.toDay
+		if w.fromDay > w.toDay {
+			active = day >= w.fromDay || day <= w.toDay
+			if day >= w.fromDay {
+				endYear++
+			}
+		}
+		return time.Date(endYear, time.Month(w.toDay/100), w.toDay%100+1, 0, 0, 0, 0, w.location), active
+	}
+	return w.to, !now.Before(w.from) && now.Before(w.to)
+}
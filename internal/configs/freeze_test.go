@@ -0,0 +1,118 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFreezeCalendarErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		wantErr    string
+	}{
+		{name: "no windows", definition: "timezone: UTC\n", wantErr: "no windows"},
+		{name: "unknown field", definition: "windows:\n  - name: w\n    days: [sat]\n    hours: 1\n", wantErr: "error parsing"},
+		{name: "no name", definition: "windows:\n  - days: [sat]\n", wantErr: "has no name"},
+		{name: "unknown timezone", definition: "windows:\n  - name: w\n    timezone: Mars/Base\n    days: [sat]\n", wantErr: "unknown timezone"},
+		{name: "unknown day", definition: "windows:\n  - name: w\n    days: [someday]\n", wantErr: "unknown day"},
+		{name: "start without end", definition: "windows:\n  - name: w\n    days: [fri]\n    start: \"18:00\"\n", wantErr: "set together"},
+		{name: "invalid clock", definition: "windows:\n  - name: w\n    days: [fri]\n    start: \"6pm\"\n    end: \"23:00\"\n", wantErr: "HH:MM"},
+		{name: "days and dates", definition: "windows:\n  - name: w\n    days: [fri]\n    from: 12-24\n    to: 01-02\n", wantErr: "can't be combined"},
+		{name: "from without to", definition: "windows:\n  - name: w\n    from: 12-24\n", wantErr: "should have days"},
+		{name: "mixed date formats", definition: "windows:\n  - name: w\n    from: 12-24\n    to: 2025-01-02\n", wantErr: "should both be"},
+		{name: "to before from", definition: "windows:\n  - name: w\n    from: 2025-01-02\n    to: 2025-01-01\n", wantErr: "after from"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFreezeCalendar(tt.definition)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFreezeCalendar() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestActiveWindow(t *testing.T) {
+	calendar, err := ParseFreezeCalendar(`
+timezone: UTC
+windows:
+  - name: weekend
+    days: [sat, sun]
+  - name: friday-evening
+    days: [fri]
+    start: "18:00"
+    end: "02:00"
+  - name: holidays
+    from: 12-24
+    to: 01-02
+  - name: migration
+    timezone: Europe/Paris
+    from: 2024-03-11T09:00
+    to: 2024-03-12
+`)
+	if err != nil {
+		t.Fatalf("ParseFreezeCalendar() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		now        string
+		wantWindow string
+		wantUntil  string
+	}{
+		{name: "weekday", now: "2024-01-03T12:00:00Z"},
+		{name: "saturday until monday", now: "2024-01-06T10:00:00Z", wantWindow: "weekend", wantUntil: "2024-01-08T00:00:00Z"},
+		{name: "friday before the evening", now: "2024-01-05T17:59:00Z"},
+		{name: "friday evening until saturday night", now: "2024-01-05T20:00:00Z", wantWindow: "friday-evening", wantUntil: "2024-01-06T02:00:00Z"},
+		{name: "holidays until the new year", now: "2024-12-30T12:00:00Z", wantWindow: "holidays", wantUntil: "2025-01-03T00:00:00Z"},
+		{name: "holidays after the new year", now: "2025-01-02T12:00:00Z", wantWindow: "holidays", wantUntil: "2025-01-03T00:00:00Z"},
+		{name: "before the migration in its timezone", now: "2024-03-11T07:59:00Z"},
+		{name: "migration until its last day ends", now: "2024-03-11T08:00:00Z", wantWindow: "migration", wantUntil: "2024-03-12T23:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			window, until := calendar.ActiveWindow(now)
+			if tt.wantWindow == "" {
+				if window != nil {
+					t.Errorf("ActiveWindow() = %s, want none", window.Name)
+				}
+				return
+			}
+			if window == nil || window.Name != tt.wantWindow {
+				t.Fatalf("ActiveWindow() = %v, want %s", window, tt.wantWindow)
+			}
+			if got := until.UTC().Format(time.RFC3339); got != tt.wantUntil {
+				t.Errorf("until = %s, want %s", got, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestActiveWindowOnDSTChange(t *testing.T) {
+	calendar, err := ParseFreezeCalendar(`
+windows:
+  - name: maintenance
+    timezone: Europe/Paris
+    days: [sun]
+    start: "01:00"
+    end: "05:00"
+`)
+	if err != nil {
+		t.Fatalf("ParseFreezeCalendar() error = %v", err)
+	}
+
+	// Clocks went forward from 02:00 to 03:00 that morning, so the window ends at 05:00 summer time
+	window, until := calendar.ActiveWindow(time.Date(2024, 3, 31, 1, 30, 0, 0, time.UTC))
+	if window == nil || window.Name != "maintenance" {
+		t.Fatalf("ActiveWindow() = %v, want maintenance", window)
+	}
+	if got := until.UTC().Format(time.RFC3339); got != "2024-03-31T03:00:00Z" {
+		t.Errorf("until = %s, want 2024-03-31T03:00:00Z", got)
+	}
+}
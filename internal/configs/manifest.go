@@ -0,0 +1,226 @@
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the declarative release configuration read from manifest_path. It is YAML, or JSON
+// since JSON is valid YAML. Action inputs that are set take precedence over the manifest values.
+type Manifest struct {
+	Owner       string `yaml:"owner"`
+	Environment string `yaml:"environment"`
+	Concurrency int    `yaml:"concurrency"`
+	Branches    struct {
+		Production  string `yaml:"production"`
+		Development string `yaml:"development"`
+	} `yaml:"branches"`
+	PullRequest struct {
+		Title string `yaml:"title"`
+		Body  string `yaml:"body"`
+	} `yaml:"pull_request"`
+	Repositories struct {
+		Include            StringList            `yaml:"include"`
+		Exclude            StringList            `yaml:"exclude"`
+		ExcludeProdRelease StringList            `yaml:"exclude_prod_release"`
+		Topics             StringList            `yaml:"topics"`
+		Properties         map[string]StringList `yaml:"properties"`
+	} `yaml:"repositories"`
+	Waves     []ManifestWave `yaml:"waves"`
+	Workflows struct {
+		ProdFilter string `yaml:"prod_filter"`
+		// ProductionRef is branch or tag, see the production_ref input
+		ProductionRef string `yaml:"production_ref"`
+		// Inputs are the workflow_dispatch inputs as text/template values, e.g. version: "{{.RCVersion}}"
+		Inputs       map[string]string `yaml:"inputs"`
+		Wait         *bool             `yaml:"wait"`
+		WaitTimeout  string            `yaml:"wait_timeout"`
+		PollInterval string            `yaml:"poll_interval"`
+	} `yaml:"workflows"`
+	Releases struct {
+		Create     *bool `yaml:"create"`
+		Draft      *bool `yaml:"draft"`
+		Prerelease *bool `yaml:"prerelease"`
+	} `yaml:"releases"`
+	Changelog struct {
+		GroupBy string `yaml:"group_by"`
+	} `yaml:"changelog"`
+	// Version configures rc_version auto, see the version_bump and version_source_repo inputs, and calendar versions
+	Version struct {
+		Bump        string `yaml:"bump"`
+		SourceRepo  string `yaml:"source_repo"`
+		AllowCalVer *bool  `yaml:"allow_calver"`
+	} `yaml:"version"`
+	// Readiness configures the gate run before Production-Release dispatches, see the readiness_checks input
+	Readiness struct {
+		Checks            StringList `yaml:"checks"`
+		RequiredApprovals *int       `yaml:"required_approvals"`
+	} `yaml:"readiness"`
+	// Lock configures the release lock, see the lock_repository input
+	Lock struct {
+		Repository string `yaml:"repository"`
+		Branch     string `yaml:"branch"`
+		TTL        string `yaml:"ttl"`
+	} `yaml:"lock"`
+	// Freeze is the freeze calendar, see the freeze_calendar input
+	Freeze *FreezeCalendar `yaml:"freeze"`
+	Epics  struct {
+		EnableMainToEpicSync *bool  `yaml:"enable_main_to_epic_sync"`
+		HydraWebhookURL      string `yaml:"hydra_webhook_url"`
+	} `yaml:"epics"`
+	Notifications struct {
+		SlackChannel string `yaml:"slack_channel"`
+	} `yaml:"notifications"`
+	// Overrides adjusts individual repositories, keyed by repository name
+	Overrides map[string]RepoOverride `yaml:"overrides"`
+}
+
+type ManifestWave struct {
+	Name         string     `yaml:"name"`
+	Repositories StringList `yaml:"repositories"`
+}
+
+// RepoOverride replaces the global settings for one repository
+type RepoOverride struct {
+	WorkflowFilter string `yaml:"workflow_filter"`
+	Environment    string `yaml:"environment"`
+	// Inputs are added to the global workflow inputs, replacing those of the same name
+	Inputs map[string]string `yaml:"inputs"`
+	// Skip leaves the repository out of every use case
+	Skip bool `yaml:"skip"`
+}
+
+// StringList accepts a YAML list or a comma-separated string
+type StringList []string
+
+func (s *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = nil
+		for _, item := range strings.Split(value.Value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*s = append(*s, item)
+			}
+		}
+		return nil
+	}
+	var items []string
+	if err := value.Decode(&items); err != nil {
+		return err
+	}
+	*s = items
+	return nil
+}
+
+func (s StringList) String() string {
+	return strings.Join(s, ", ")
+}
+
+// LoadManifest reads and validates a manifest. Unknown keys are rejected so typos don't go unnoticed.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %v", err)
+	}
+
+	var manifest Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s: %v", path, err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+	return &manifest, nil
+}
+
+// Validate checks the values the action can't check later on, reporting every problem at once
+func (m *Manifest) Validate() error {
+	var errs []error
+	if m.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("concurrency should be a positive integer"))
+	}
+	durations := []struct{ key, value string }{
+		{"workflows.wait_timeout", m.Workflows.WaitTimeout},
+		{"workflows.poll_interval", m.Workflows.PollInterval},
+		{"lock.ttl", m.Lock.TTL},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(d.value); err != nil || duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s should be a positive duration such as 30m, got %q", d.key, d.value))
+		}
+	}
+	if m.Readiness.RequiredApprovals != nil && *m.Readiness.RequiredApprovals < 0 {
+		errs = append(errs, fmt.Errorf("readiness.required_approvals should be a non-negative integer"))
+	}
+	for _, name := range m.propertyNames() {
+		if len(m.Repositories.Properties[name]) == 0 {
+			errs = append(errs, fmt.Errorf("repositories.properties.%s has no values", name))
+		}
+	}
+	seen := make(map[string]bool)
+	for i, wave := range m.Waves {
+		switch {
+		case wave.Name == "":
+			errs = append(errs, fmt.Errorf("waves[%d] has no name", i))
+		case strings.ContainsAny(wave.Name, ":\n"):
+			errs = append(errs, fmt.Errorf("waves[%d] name %q should not contain ':' or newlines", i, wave.Name))
+		case seen[wave.Name]:
+			errs = append(errs, fmt.Errorf("waves[%d] name %q is used twice", i, wave.Name))
+		}
+		seen[wave.Name] = true
+		if len(wave.Repositories) == 0 {
+			errs = append(errs, fmt.Errorf("waves[%d] (%s) has no repositories", i, wave.Name))
+		}
+	}
+	for repo := range m.Overrides {
+		if strings.TrimSpace(repo) == "" {
+			errs = append(errs, fmt.Errorf("overrides has an empty repository name"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waves builds the waves from their repository lists directly, as joining them into the waves input would split
+// regexes containing commas such as re:^svc-{1,3}
+func (m *Manifest) waves() ([]Wave, error) {
+	var waves []Wave
+	for i, wave := range m.Waves {
+		filter, err := NewRepoFilter(wave.Repositories)
+		if err != nil {
+			return nil, fmt.Errorf("waves[%d] (%s): %v", i, wave.Name, err)
+		}
+		waves = append(waves, Wave{Name: wave.Name, Filter: filter})
+	}
+	return waves, nil
+}
+
+// propertiesInput renders the properties in the name=value format of the repository_properties input
+func (m *Manifest) propertiesInput() string {
+	var pairs []string
+	for _, name := range m.propertyNames() {
+		for _, value := range m.Repositories.Properties[name] {
+			pairs = append(pairs, name+"="+value)
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (m *Manifest) propertyNames() []string {
+	names := make([]string, 0, len(m.Repositories.Properties))
+	for name := range m.Repositories.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
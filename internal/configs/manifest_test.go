@@ -0,0 +1,191 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeManifest writes content to a manifest file in a temporary directory and returns its path
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "release.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStringList(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want StringList
+	}{
+		{name: "list", yaml: "[svc-*, api]", want: StringList{"svc-*", "api"}},
+		{name: "comma-separated", yaml: "svc-*, api , ,", want: StringList{"svc-*", "api"}},
+		{name: "single", yaml: "api", want: StringList{"api"}},
+		{name: "empty", yaml: `""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StringList
+			if err := yaml.Unmarshal([]byte(tt.yaml), &got); err != nil {
+				t.Fatalf("Unmarshal(%q) error = %v", tt.yaml, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal(%q) = %#v, want %#v", tt.yaml, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		// wantErrs are all reported at once
+		wantErrs []string
+	}{
+		{
+			name:     "valid",
+			manifest: "owner: o\nconcurrency: 2\nworkflows:\n  wait_timeout: 45m\nwaves:\n  - name: canary\n    repositories: api\noverrides:\n  billing:\n    skip: true\n",
+		},
+		{
+			name:     "JSON",
+			manifest: `{"owner": "o", "repositories": {"include": ["svc-*"]}}`,
+		},
+		{
+			name:     "unknown key",
+			manifest: "owner: o\nbranchs:\n  production: main\n",
+			wantErrs: []string{"field branchs not found"},
+		},
+		{
+			name:     "every invalid value",
+			manifest: "concurrency: -1\nworkflows:\n  poll_interval: soon\nrepositories:\n  properties:\n    release_train: []\nwaves:\n  - name: canary\n    repositories: api\n  - name: canary\n  - repositories: web\n",
+			wantErrs: []string{
+				"concurrency should be a positive integer",
+				`workflows.poll_interval should be a positive duration such as 30m, got "soon"`,
+				"repositories.properties.release_train has no values",
+				`waves[1] name "canary" is used twice`,
+				"waves[1] (canary) has no repositories",
+				"waves[2] has no name",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := LoadManifest(writeManifest(t, tt.manifest))
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("LoadManifest() error = %v", err)
+				}
+				if manifest.Owner != "o" {
+					t.Errorf("owner = %q, want o", manifest.Owner)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("LoadManifest() error = nil")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadManifest() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// setInputs sets the action inputs Variables reads, as the runner does
+func setInputs(t *testing.T, inputs map[string]string) {
+	t.Helper()
+	for name, value := range inputs {
+		t.Setenv("INPUT_"+strings.ToUpper(name), value)
+	}
+}
+
+func TestVariablesManifest(t *testing.T) {
+	manifestPath := writeManifest(t, `owner: acme
+environment: staging
+concurrency: 8
+branches:
+  production: release
+repositories:
+  include: [svc-*, api]
+  properties:
+    release_train: [payments, core]
+workflows:
+  wait: true
+  wait_timeout: 45m
+overrides:
+  Billing:
+    environment: production-eu
+  sandbox:
+    skip: true
+`)
+
+	tests := []struct {
+		name   string
+		inputs map[string]string
+		check  func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:   "manifest values",
+			inputs: map[string]string{},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Owner != "acme" || cfg.ProductionBranch != "release" || cfg.DevelopmentBranch != "development" || cfg.Concurrency != 8 {
+					t.Errorf("owner, branches, concurrency = %s, %s, %s, %d, want acme, release, development, 8", cfg.Owner, cfg.ProductionBranch, cfg.DevelopmentBranch, cfg.Concurrency)
+				}
+				if cfg.IncludeRepositories != "svc-*, api" || cfg.RepositoryProperties != "release_train=payments, release_train=core" {
+					t.Errorf("include, properties = %q, %q", cfg.IncludeRepositories, cfg.RepositoryProperties)
+				}
+				if !cfg.WaitForWorkflows || cfg.WorkflowWaitTimeout.String() != "45m0s" {
+					t.Errorf("wait, timeout = %v, %s, want true, 45m", cfg.WaitForWorkflows, cfg.WorkflowWaitTimeout)
+				}
+				if cfg.ExcludeRepositories != "sandbox" {
+					t.Errorf("exclude = %q, want the skipped sandbox", cfg.ExcludeRepositories)
+				}
+				if cfg.EnvironmentFor("billing") != "production-eu" || cfg.EnvironmentFor("api") != "staging" {
+					t.Errorf("environments = %s, %s, want production-eu for billing and staging otherwise", cfg.EnvironmentFor("billing"), cfg.EnvironmentFor("api"))
+				}
+			},
+		},
+		{
+			name: "inputs take precedence",
+			inputs: map[string]string{
+				"owner":                "o",
+				"production_branch":    "main",
+				"concurrency":          "2",
+				"include_repositories": "web",
+				"exclude_repositories": "legacy",
+				"wait_for_workflows":   "false",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Owner != "o" || cfg.ProductionBranch != "main" || cfg.Concurrency != 2 || cfg.IncludeRepositories != "web" || cfg.WaitForWorkflows {
+					t.Errorf("owner, branch, concurrency, include, wait = %s, %s, %d, %s, %v, want the inputs", cfg.Owner, cfg.ProductionBranch, cfg.Concurrency, cfg.IncludeRepositories, cfg.WaitForWorkflows)
+				}
+				if cfg.ExcludeRepositories != "legacy, sandbox" {
+					t.Errorf("exclude = %q, want the input plus the skipped sandbox", cfg.ExcludeRepositories)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.inputs["manifest_path"] = manifestPath
+			tt.inputs["rc_version"] = "v1.0.0"
+			setInputs(t, tt.inputs)
+
+			cfg, err := Variables()
+			if err != nil {
+				t.Fatalf("Variables() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Wave is a production rollout wave: the repositories its filter selects are dispatched together
+type Wave struct {
+	Name   string
+	Filter RepoFilter
+}
+
+// ParseWaves reads one wave per line in the format "name: filters", in rollout order.
+// Filters use the repository filter syntax (exact names, globs, re: regexes). Blank lines and # comments are ignored.
+func ParseWaves(definition string) ([]Wave, error) {
+	var waves []Wave
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(definition, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, filters, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("wave %q should be in the format name: filters", line)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("wave %q is defined twice", name)
+		}
+		seen[name] = true
+		filter, err := ParseRepoFilter(filters)
+		if err != nil {
+			return nil, fmt.Errorf("wave %q: %v", name, err)
+		}
+		if filter.Empty() {
+			return nil, fmt.Errorf("wave %q selects no repositories", name)
+		}
+		waves = append(waves, Wave{Name: name, Filter: filter})
+	}
+	return waves, nil
+}
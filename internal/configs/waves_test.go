@@ -0,0 +1,100 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWaves(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		wantNames  []string
+		wantErr    string
+	}{
+		{
+			name:       "waves in rollout order",
+			definition: "# canaries first\ncanary: api\n\nrest: svc-*, re:^web-\n",
+			wantNames:  []string{"canary", "rest"},
+		},
+		{name: "empty", definition: "\n# nothing\n"},
+		{name: "missing filters separator", definition: "canary api", wantErr: "format name: filters"},
+		{name: "missing name", definition: ": api", wantErr: "format name: filters"},
+		{name: "duplicate name", definition: "canary: api\ncanary: web", wantErr: "defined twice"},
+		{name: "no repositories", definition: "canary: ,", wantErr: "selects no repositories"},
+		{name: "invalid filter", definition: "canary: re:(", wantErr: "invalid repository regex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waves, err := ParseWaves(tt.definition)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseWaves() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWaves() error = %v", err)
+			}
+			if len(waves) != len(tt.wantNames) {
+				t.Fatalf("got %d waves, want %d", len(waves), len(tt.wantNames))
+			}
+			for i, wave := range waves {
+				if wave.Name != tt.wantNames[i] {
+					t.Errorf("waves[%d].Name = %q, want %q", i, wave.Name, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVariablesWaves(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		input    string
+		// matches maps repos to the wave selecting them
+		matches map[string]string
+	}{
+		{
+			name:     "manifest waves keep regexes with commas",
+			manifest: "waves:\n  - name: canary\n    repositories: [\"re:^api-v{1,2}$\"]\n  - name: rest\n    repositories: [svc-*]\n",
+			matches:  map[string]string{"api-vv": "canary", "svc-a": "rest"},
+		},
+		{
+			name:     "the waves input replaces the manifest waves",
+			manifest: "waves:\n  - name: canary\n    repositories: [api]\n",
+			input:    "first: web",
+			matches:  map[string]string{"web": "first", "api": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setInputs(t, map[string]string{"owner": "o", "rc_version": "v1.0.0", "production_branch": "main", "manifest_path": writeManifest(t, tt.manifest), "waves": tt.input})
+
+			cfg, err := Variables()
+			if err != nil {
+				t.Fatalf("Variables() error = %v", err)
+			}
+			for repo, want := range tt.matches {
+				got := ""
+				for _, wave := range cfg.Waves {
+					if wave.Filter.Match(repo) {
+						got = wave.Name
+						break
+					}
+				}
+				if got != want {
+					t.Errorf("repo %s is in wave %q, want %q", repo, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestManifestWavesInvalid(t *testing.T) {
+	manifest := &Manifest{Waves: []ManifestWave{{Name: "canary", Repositories: StringList{"re:("}}}}
+	if _, err := manifest.waves(); err == nil || !strings.Contains(err.Error(), "waves[0] (canary)") {
+		t.Errorf("waves() error = %v, want it to name waves[0] (canary)", err)
+	}
+}
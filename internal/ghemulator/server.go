@@ -0,0 +1,1102 @@
+// Package ghemulator is a local stand-in for the parts of the GitHub REST API this action uses.
+// Point CreateGitHubClient at it through Config.GitHubAPIURL to run the use cases end to end.
+package ghemulator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Emulator serves the GitHub REST endpoints go-github calls from this repository
+type Emulator struct {
+	mu    sync.Mutex
+	state *State
+	mux   *http.ServeMux
+	// Requests records "METHOD /path" for every request served
+	Requests []string
+}
+
+func New(state *State) *Emulator {
+	e := &Emulator{state: state, mux: http.NewServeMux()}
+
+	e.mux.HandleFunc("GET /orgs/{owner}/repos", e.listOrgRepos)
+	e.mux.HandleFunc("GET /orgs/{owner}/properties/values", e.listCustomPropertyValues)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}", e.getRepo)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/git/ref/{ref...}", e.getRef)
+	e.mux.HandleFunc("POST /repos/{owner}/{repo}/git/refs", e.createRef)
+	e.mux.HandleFunc("DELETE /repos/{owner}/{repo}/git/refs/{ref...}", e.deleteRef)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/branches", e.listBranches)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/contents/{path...}", e.getContents)
+	e.mux.HandleFunc("PUT /repos/{owner}/{repo}/contents/{path...}", e.putContents)
+	e.mux.HandleFunc("DELETE /repos/{owner}/{repo}/contents/{path...}", e.deleteContents)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/pulls", e.listPulls)
+	e.mux.HandleFunc("POST /repos/{owner}/{repo}/pulls", e.createPull)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}", e.getPull)
+	e.mux.HandleFunc("PATCH /repos/{owner}/{repo}/pulls/{number}", e.editPull)
+	e.mux.HandleFunc("POST /repos/{owner}/{repo}/issues/{number}/comments", e.createComment)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/actions/workflows", e.listWorkflows)
+	e.mux.HandleFunc("POST /repos/{owner}/{repo}/actions/workflows/{workflow}/dispatches", e.dispatchWorkflow)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/actions/workflows/{workflow}/runs", e.listWorkflowRuns)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/actions/runs/{run_id}", e.getWorkflowRun)
+	e.mux.HandleFunc("POST /repos/{owner}/{repo}/dispatches", e.repositoryDispatch)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/releases/tags/{tag}", e.getReleaseByTag)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/releases/latest", e.getLatestRelease)
+	e.mux.HandleFunc("POST /repos/{owner}/{repo}/releases/generate-notes", e.generateReleaseNotes)
+	e.mux.HandleFunc("POST /repos/{owner}/{repo}/releases", e.createRelease)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/releases", e.listReleases)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/compare/{basehead...}", e.compareCommits)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{ref}/status", e.getCombinedStatus)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/commits/{ref}/check-runs", e.listCheckRuns)
+	e.mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}/reviews", e.listReviews)
+	e.mux.HandleFunc("POST /app/installations/{id}/access_tokens", e.createInstallationToken)
+	// Not part of GitHub: stands in for the Hydra active epics webhook so Main-To-Epic-Sync can run locally
+	e.mux.HandleFunc("POST /epics/hydra-active", e.hydraActiveEpics)
+
+	return e
+}
+
+// Start serves the emulator on a local httptest server. The caller must Close it.
+func (e *Emulator) Start() *httptest.Server {
+	return httptest.NewServer(e)
+}
+
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	e.Requests = append(e.Requests, r.Method+" "+r.URL.Path)
+	fault := e.nextFault(r)
+	e.mu.Unlock()
+	if fault != nil {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfter))
+		}
+		message := fault.Message
+		if message == "" {
+			message = http.StatusText(fault.Status)
+		}
+		writeJSON(w, fault.Status, map[string]string{"message": message})
+		return
+	}
+	e.mux.ServeHTTP(w, r)
+}
+
+func (e *Emulator) nextFault(r *http.Request) *Fault {
+	for _, fault := range e.state.Faults {
+		if fault.Count > 0 && (fault.Method == "" || fault.Method == r.Method) && strings.HasPrefix(r.URL.Path, fault.PathPrefix) {
+			fault.Count--
+			return fault
+		}
+	}
+	return nil
+}
+
+// State returns the emulated organization. Hold no references across concurrent requests.
+func (e *Emulator) State() *State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.state
+}
+
+// Inspect runs fn on the emulated organization under the lock every request takes first, so that the requests
+// served after it returns don't race with it
+func (e *Emulator) Inspect(fn func(state *State)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn(e.state)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func notFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{
+		"message":           "Not Found",
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
+
+// validationFailed writes a 422 body shaped like GitHub's, which CreatePullRequest parses for the first error message
+func validationFailed(w http.ResponseWriter, resource string, message string) {
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"message": "Validation Failed",
+		"errors": []interface{}{
+			map[string]string{"resource": resource, "code": "custom", "message": message},
+		},
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
+
+// paginate returns the [start, end) window for the requested page and sets the Link header.
+// Like GitHub, per_page defaults to 30 and is capped at 100.
+func paginate(w http.ResponseWriter, r *http.Request, total int) (int, int) {
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	if end < total {
+		lastPage := (total + perPage - 1) / perPage
+		link := func(p int, rel string) string {
+			u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+			q := r.URL.Query()
+			q.Set("page", strconv.Itoa(p))
+			q.Set("per_page", strconv.Itoa(perPage))
+			u.RawQuery = q.Encode()
+			return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+		}
+		w.Header().Set("Link", link(page+1, "next")+", "+link(lastPage, "last"))
+	}
+	return start, end
+}
+
+// lookupRepo resolves the {owner}/{repo} path values, writing a 404 when they don't exist
+func (e *Emulator) lookupRepo(w http.ResponseWriter, r *http.Request) *Repo {
+	if e.state.Owner != "" && !strings.EqualFold(r.PathValue("owner"), e.state.Owner) {
+		notFound(w)
+		return nil
+	}
+	repo := e.state.repo(r.PathValue("repo"))
+	if repo == nil {
+		notFound(w)
+	}
+	return repo
+}
+
+func (e *Emulator) repoJSON(repo *Repo) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      repo.Name,
+		"full_name": e.state.Owner + "/" + repo.Name,
+		"archived":  repo.Archived,
+		"topics":    repo.Topics,
+		"owner":     map[string]string{"login": e.state.Owner},
+	}
+}
+
+func (e *Emulator) pullJSON(r *http.Request, repo *Repo, pull *Pull) map[string]interface{} {
+	labels := make([]interface{}, 0, len(pull.Labels))
+	for _, label := range pull.Labels {
+		labels = append(labels, map[string]string{"name": label})
+	}
+	body := map[string]interface{}{
+		"number":    pull.Number,
+		"state":     pull.State,
+		"title":     pull.Title,
+		"body":      pull.Body,
+		"html_url":  fmt.Sprintf("http://%s/%s/%s/pull/%d", r.Host, e.state.Owner, repo.Name, pull.Number),
+		"mergeable": !repo.hasConflicts(pull.Head, pull.Base),
+		"head":      map[string]string{"ref": pull.Head, "label": e.state.Owner + ":" + pull.Head},
+		"base":      map[string]string{"ref": pull.Base, "label": e.state.Owner + ":" + pull.Base},
+		"user":      map[string]string{"login": pull.Author},
+		"labels":    labels,
+	}
+	if pull.Merged {
+		body["merged_at"] = time.Now().UTC().Format(time.RFC3339)
+		body["merge_commit_sha"] = pull.MergeCommitSHA
+	}
+	return body
+}
+
+func (e *Emulator) listOrgRepos(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state.Owner != "" && !strings.EqualFold(r.PathValue("owner"), e.state.Owner) {
+		notFound(w)
+		return
+	}
+	start, end := paginate(w, r, len(e.state.Repos))
+	repos := make([]interface{}, 0, end-start)
+	for _, repo := range e.state.Repos[start:end] {
+		repos = append(repos, e.repoJSON(repo))
+	}
+	writeJSON(w, http.StatusOK, repos)
+}
+
+func (e *Emulator) listCustomPropertyValues(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state.Owner != "" && !strings.EqualFold(r.PathValue("owner"), e.state.Owner) {
+		notFound(w)
+		return
+	}
+	start, end := paginate(w, r, len(e.state.Repos))
+	body := make([]interface{}, 0, end-start)
+	for _, repo := range e.state.Repos[start:end] {
+		names := make([]string, 0, len(repo.CustomProperties))
+		for name := range repo.CustomProperties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		properties := make([]interface{}, 0, len(names))
+		for _, name := range names {
+			properties = append(properties, map[string]interface{}{"property_name": name, "value": repo.CustomProperties[name]})
+		}
+		body = append(body, map[string]interface{}{
+			"repository_name":      repo.Name,
+			"repository_full_name": e.state.Owner + "/" + repo.Name,
+			"properties":           properties,
+		})
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (e *Emulator) getRepo(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if repo := e.lookupRepo(w, r); repo != nil {
+		writeJSON(w, http.StatusOK, e.repoJSON(repo))
+	}
+}
+
+func refJSON(ref string, sha string) map[string]interface{} {
+	return map[string]interface{}{
+		"ref":    ref,
+		"object": map[string]string{"type": "commit", "sha": sha},
+	}
+}
+
+func (e *Emulator) getRef(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	if name, ok := strings.CutPrefix(r.PathValue("ref"), "tags/"); ok {
+		if tag := repo.tag(name); tag != nil {
+			writeJSON(w, http.StatusOK, refJSON("refs/tags/"+tag.Name, tag.SHA))
+			return
+		}
+	}
+	name, ok := strings.CutPrefix(r.PathValue("ref"), "heads/")
+	branch := repo.branch(name)
+	if !ok || branch == nil {
+		notFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, refJSON("refs/heads/"+branch.Name, branch.SHA))
+}
+
+func (e *Emulator) createRef(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	var body struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	if name, ok := strings.CutPrefix(body.Ref, "refs/tags/"); ok && body.SHA != "" {
+		if repo.tag(name) != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference already exists"})
+			return
+		}
+		repo.Tags = append(repo.Tags, &Tag{Name: name, SHA: body.SHA})
+		writeJSON(w, http.StatusCreated, refJSON(body.Ref, body.SHA))
+		return
+	}
+	name, ok := strings.CutPrefix(body.Ref, "refs/heads/")
+	if !ok || body.SHA == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference name must start with 'refs/heads/' or 'refs/tags/'"})
+		return
+	}
+	if repo.branch(name) != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference already exists"})
+		return
+	}
+	repo.Branches = append(repo.Branches, &Branch{Name: name, SHA: body.SHA})
+	writeJSON(w, http.StatusCreated, refJSON(body.Ref, body.SHA))
+}
+
+func (e *Emulator) deleteRef(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	name, _ := strings.CutPrefix(r.PathValue("ref"), "heads/")
+	for i, branch := range repo.Branches {
+		if branch.Name == name {
+			repo.Branches = append(repo.Branches[:i], repo.Branches[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Reference does not exist"})
+}
+
+func (e *Emulator) listBranches(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	protectedOnly := r.URL.Query().Get("protected") == "true"
+	var branches []*Branch
+	for _, branch := range repo.Branches {
+		if !protectedOnly || branch.Protected {
+			branches = append(branches, branch)
+		}
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+
+	start, end := paginate(w, r, len(branches))
+	body := make([]interface{}, 0, end-start)
+	for _, branch := range branches[start:end] {
+		body = append(body, map[string]interface{}{
+			"name":      branch.Name,
+			"protected": branch.Protected,
+			"commit":    map[string]string{"sha": branch.SHA},
+		})
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (e *Emulator) getContents(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	filePath := r.PathValue("path")
+	content, ok := repo.file(filePath)
+	if !ok {
+		notFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"type":     "file",
+		"encoding": "base64",
+		"name":     path.Base(filePath),
+		"path":     filePath,
+		"size":     len(content),
+		"sha":      blobSHA(content),
+		"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+	})
+}
+
+// putContents creates or updates a file. Like GitHub, updating requires the SHA of the current version, and
+// creating a file that exists is refused.
+func (e *Emulator) putContents(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	var body struct {
+		Message string `json:"message"`
+		Content string `json:"content"`
+		SHA     string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	content, err := base64.StdEncoding.DecodeString(body.Content)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "content is not valid Base64"})
+		return
+	}
+	filePath := r.PathValue("path")
+	current, exists := repo.Files[filePath]
+	switch {
+	case exists && body.SHA == "":
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Invalid request.\n\n\"sha\" wasn't supplied."})
+		return
+	case body.SHA != "" && (!exists || blobSHA(current) != body.SHA):
+		writeJSON(w, http.StatusConflict, map[string]string{"message": fmt.Sprintf("%s does not match %s", filePath, body.SHA)})
+		return
+	}
+	if repo.Files == nil {
+		repo.Files = make(map[string]string)
+	}
+	repo.Files[filePath] = string(content)
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"content": map[string]interface{}{"name": path.Base(filePath), "path": filePath, "sha": blobSHA(string(content))},
+		"commit":  map[string]interface{}{"message": body.Message},
+	})
+}
+
+func (e *Emulator) deleteContents(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	var body struct {
+		Message string `json:"message"`
+		SHA     string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	filePath := r.PathValue("path")
+	current, exists := repo.Files[filePath]
+	if !exists {
+		notFound(w)
+		return
+	}
+	if blobSHA(current) != body.SHA {
+		writeJSON(w, http.StatusConflict, map[string]string{"message": fmt.Sprintf("%s does not match %s", filePath, body.SHA)})
+		return
+	}
+	delete(repo.Files, filePath)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"content": nil, "commit": map[string]interface{}{"message": body.Message}})
+}
+
+func (e *Emulator) listPulls(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	query := r.URL.Query()
+	state := query.Get("state")
+	if state == "" {
+		state = "open"
+	}
+	var pulls []*Pull
+	for _, pull := range repo.Pulls {
+		if state != "all" && pull.State != state {
+			continue
+		}
+		if base := query.Get("base"); base != "" && pull.Base != base {
+			continue
+		}
+		if head := query.Get("head"); head != "" && head != e.state.Owner+":"+pull.Head {
+			continue
+		}
+		pulls = append(pulls, pull)
+	}
+
+	start, end := paginate(w, r, len(pulls))
+	body := make([]interface{}, 0, end-start)
+	for _, pull := range pulls[start:end] {
+		body = append(body, e.pullJSON(r, repo, pull))
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (e *Emulator) createPull(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	var body struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+		Head  string `json:"head"`
+		Base  string `json:"base"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+
+	head := repo.branch(body.Head)
+	base := repo.branch(body.Base)
+	switch {
+	case head == nil:
+		validationFailed(w, "PullRequest", fmt.Sprintf("head %s does not exist", body.Head))
+		return
+	case base == nil:
+		validationFailed(w, "PullRequest", fmt.Sprintf("base %s does not exist", body.Base))
+		return
+	}
+	for _, pull := range repo.Pulls {
+		if pull.State == "open" && pull.Head == body.Head && pull.Base == body.Base {
+			validationFailed(w, "PullRequest", fmt.Sprintf("A pull request already exists for %s:%s.", e.state.Owner, body.Head))
+			return
+		}
+	}
+	if head.SHA == base.SHA {
+		validationFailed(w, "PullRequest", fmt.Sprintf("No commits between %s and %s", body.Base, body.Head))
+		return
+	}
+
+	pull := &Pull{
+		Number: repo.nextPullNumber(),
+		Title:  body.Title,
+		Body:   body.Body,
+		Head:   body.Head,
+		Base:   body.Base,
+		State:  "open",
+	}
+	repo.Pulls = append(repo.Pulls, pull)
+	writeJSON(w, http.StatusCreated, e.pullJSON(r, repo, pull))
+}
+
+func (e *Emulator) lookupPull(w http.ResponseWriter, r *http.Request, repo *Repo) *Pull {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		notFound(w)
+		return nil
+	}
+	pull := repo.pull(number)
+	if pull == nil {
+		notFound(w)
+	}
+	return pull
+}
+
+func (e *Emulator) getPull(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	if pull := e.lookupPull(w, r, repo); pull != nil {
+		writeJSON(w, http.StatusOK, e.pullJSON(r, repo, pull))
+	}
+}
+
+func (e *Emulator) editPull(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	pull := e.lookupPull(w, r, repo)
+	if pull == nil {
+		return
+	}
+	var body struct {
+		State *string `json:"state"`
+		Title *string `json:"title"`
+		Body  *string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	if body.State != nil {
+		pull.State = *body.State
+	}
+	if body.Title != nil {
+		pull.Title = *body.Title
+	}
+	if body.Body != nil {
+		pull.Body = *body.Body
+	}
+	writeJSON(w, http.StatusOK, e.pullJSON(r, repo, pull))
+}
+
+func (e *Emulator) createComment(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	pull := e.lookupPull(w, r, repo)
+	if pull == nil {
+		return
+	}
+	var body struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	pull.Comments = append(pull.Comments, body.Body)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"id": len(pull.Comments), "body": body.Body})
+}
+
+func (e *Emulator) listWorkflows(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	start, end := paginate(w, r, len(repo.Workflows))
+	workflows := make([]interface{}, 0, end-start)
+	for _, workflow := range repo.Workflows[start:end] {
+		workflows = append(workflows, map[string]interface{}{
+			"id":    workflow.ID,
+			"name":  workflow.Name,
+			"path":  workflow.Path,
+			"state": "active",
+			"url":   fmt.Sprintf("http://%s/repos/%s/%s/actions/workflows/%d", r.Host, e.state.Owner, repo.Name, workflow.ID),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_count": len(repo.Workflows),
+		"workflows":   workflows,
+	})
+}
+
+func (e *Emulator) dispatchWorkflow(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	workflow := lookupWorkflow(repo, r.PathValue("workflow"))
+	if workflow == nil {
+		notFound(w)
+		return
+	}
+	var body struct {
+		Ref    string                 `json:"ref"`
+		Inputs map[string]interface{} `json:"inputs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	// Runs of tag dispatches report the tag as their head branch, like on GitHub
+	var branch, sha string
+	if name, ok := strings.CutPrefix(body.Ref, "refs/tags/"); ok {
+		if tag := repo.tag(name); tag != nil {
+			branch, sha = tag.Name, tag.SHA
+		}
+	} else {
+		name, _ := strings.CutPrefix(body.Ref, "refs/heads/")
+		if head := repo.branch(name); head != nil {
+			branch, sha = head.Name, head.SHA
+		}
+	}
+	if branch == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": fmt.Sprintf("No ref found for: %s", body.Ref)})
+		return
+	}
+	repo.Dispatches = append(repo.Dispatches, &Dispatch{WorkflowID: workflow.ID, Ref: body.Ref, Inputs: body.Inputs})
+
+	// Runs finish as soon as they start, except pending ones which stay in progress
+	run := &Run{
+		ID:         e.nextRunID(),
+		WorkflowID: workflow.ID,
+		Branch:     branch,
+		SHA:        sha,
+		Status:     "completed",
+		Conclusion: workflow.Conclusion,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	switch run.Conclusion {
+	case "":
+		run.Conclusion = "success"
+	case "pending":
+		run.Status, run.Conclusion = "in_progress", ""
+	}
+	repo.Runs = append(repo.Runs, run)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func lookupWorkflow(repo *Repo, idOrFile string) *Workflow {
+	for _, workflow := range repo.Workflows {
+		if strconv.FormatInt(workflow.ID, 10) == idOrFile || strings.HasSuffix(workflow.Path, "/"+idOrFile) {
+			return workflow
+		}
+	}
+	return nil
+}
+
+func (e *Emulator) nextRunID() int64 {
+	next := int64(1)
+	for _, repo := range e.state.Repos {
+		for _, run := range repo.Runs {
+			if run.ID >= next {
+				next = run.ID + 1
+			}
+		}
+	}
+	return next
+}
+
+func (e *Emulator) runJSON(r *http.Request, repo *Repo, run *Run) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          run.ID,
+		"workflow_id": run.WorkflowID,
+		"head_branch": run.Branch,
+		"head_sha":    run.SHA,
+		"event":       "workflow_dispatch",
+		"status":      run.Status,
+		"conclusion":  run.Conclusion,
+		"created_at":  run.CreatedAt.Format(time.RFC3339),
+		"html_url":    fmt.Sprintf("http://%s/%s/%s/actions/runs/%d", r.Host, e.state.Owner, repo.Name, run.ID),
+	}
+}
+
+// listWorkflowRuns supports the branch, event and created>=timestamp filters, newest first like GitHub
+func (e *Emulator) listWorkflowRuns(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	workflow := lookupWorkflow(repo, r.PathValue("workflow"))
+	if workflow == nil {
+		notFound(w)
+		return
+	}
+	query := r.URL.Query()
+	var createdAfter time.Time
+	if created, ok := strings.CutPrefix(query.Get("created"), ">="); ok {
+		createdAfter, _ = time.Parse(time.RFC3339, created)
+	}
+	var runs []*Run
+	for i := len(repo.Runs) - 1; i >= 0; i-- {
+		run := repo.Runs[i]
+		if run.WorkflowID != workflow.ID || run.CreatedAt.Before(createdAfter) {
+			continue
+		}
+		if branch := query.Get("branch"); branch != "" && run.Branch != branch {
+			continue
+		}
+		if event := query.Get("event"); event != "" && event != "workflow_dispatch" {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	start, end := paginate(w, r, len(runs))
+	workflowRuns := make([]interface{}, 0, end-start)
+	for _, run := range runs[start:end] {
+		workflowRuns = append(workflowRuns, e.runJSON(r, repo, run))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"total_count":   len(runs),
+		"workflow_runs": workflowRuns,
+	})
+}
+
+func (e *Emulator) getWorkflowRun(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("run_id"), 10, 64)
+	if err != nil {
+		notFound(w)
+		return
+	}
+	run := repo.run(id)
+	if run == nil {
+		notFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, e.runJSON(r, repo, run))
+}
+
+func (e *Emulator) repositoryDispatch(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	var event Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	repo.Events = append(repo.Events, &event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *Emulator) releaseJSON(r *http.Request, repo *Repo, release *Release) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         release.ID,
+		"tag_name":   release.TagName,
+		"name":       release.Name,
+		"body":       release.Body,
+		"draft":      release.Draft,
+		"prerelease": release.Prerelease,
+		"html_url":   fmt.Sprintf("http://%s/%s/%s/releases/tag/%s", r.Host, e.state.Owner, repo.Name, release.TagName),
+	}
+}
+
+func (e *Emulator) getReleaseByTag(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	release := repo.release(r.PathValue("tag"))
+	if release == nil {
+		notFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, e.releaseJSON(r, repo, release))
+}
+
+// getLatestRelease returns the last published release that isn't a pre-release
+func (e *Emulator) getLatestRelease(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	for i := len(repo.Releases) - 1; i >= 0; i-- {
+		if release := repo.Releases[i]; !release.Draft && !release.Prerelease {
+			writeJSON(w, http.StatusOK, e.releaseJSON(r, repo, release))
+			return
+		}
+	}
+	notFound(w)
+}
+
+// generateReleaseNotes only produces the Full Changelog link GitHub ends its notes with
+func (e *Emulator) generateReleaseNotes(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	var body struct {
+		TagName         string `json:"tag_name"`
+		PreviousTagName string `json:"previous_tag_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TagName == "" {
+		validationFailed(w, "Release", "tag_name is missing")
+		return
+	}
+	changelog := fmt.Sprintf("http://%s/%s/%s/commits/%s", r.Host, e.state.Owner, repo.Name, body.TagName)
+	if body.PreviousTagName != "" {
+		changelog = fmt.Sprintf("http://%s/%s/%s/compare/%s...%s", r.Host, e.state.Owner, repo.Name, body.PreviousTagName, body.TagName)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"name": body.TagName,
+		"body": "**Full Changelog**: " + changelog,
+	})
+}
+
+func (e *Emulator) createRelease(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	var release Release
+	if err := json.NewDecoder(r.Body).Decode(&release); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "Problems parsing JSON"})
+		return
+	}
+	if repo.release(release.TagName) != nil {
+		validationFailed(w, "Release", "already_exists")
+		return
+	}
+	release.ID = 1
+	for _, repo := range e.state.Repos {
+		for _, existing := range repo.Releases {
+			if existing.ID >= release.ID {
+				release.ID = existing.ID + 1
+			}
+		}
+	}
+	repo.Releases = append(repo.Releases, &release)
+	writeJSON(w, http.StatusCreated, e.releaseJSON(r, repo, &release))
+}
+
+func (e *Emulator) createInstallationToken(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"token":      "ghs_emulated_installation_token",
+		"expires_at": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *Emulator) hydraActiveEpics(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	epics := e.state.ActiveEpics
+	if epics == nil {
+		epics = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"epic_names": epics})
+}
+
+// listReleases returns the releases newest first
+func (e *Emulator) listReleases(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	start, end := paginate(w, r, len(repo.Releases))
+	body := make([]interface{}, 0, end-start)
+	for i := start; i < end; i++ {
+		body = append(body, e.releaseJSON(r, repo, repo.Releases[len(repo.Releases)-1-i]))
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// compareCommits returns the commits of the linear history after base up to head
+func (e *Emulator) compareCommits(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	base, head, ok := strings.Cut(r.PathValue("basehead"), "...")
+	from, to := repo.commitIndex(base), repo.commitIndex(head)
+	if !ok || from < 0 || to < 0 {
+		notFound(w)
+		return
+	}
+	var commits []*Commit
+	if to > from {
+		commits = repo.Commits[from+1 : to+1]
+	}
+
+	start, end := paginate(w, r, len(commits))
+	body := make([]interface{}, 0, end-start)
+	for i, commit := range commits[start:end] {
+		parentSHAs := commit.Parents
+		if parentSHAs == nil {
+			// commits starts after base, so every commit in it has a previous one
+			parentSHAs = []string{repo.Commits[from+start+i].SHA}
+		}
+		parents := make([]interface{}, 0, len(parentSHAs))
+		for _, sha := range parentSHAs {
+			parents = append(parents, map[string]string{"sha": sha})
+		}
+		body = append(body, map[string]interface{}{
+			"sha":      commit.SHA,
+			"html_url": fmt.Sprintf("http://%s/%s/%s/commit/%s", r.Host, e.state.Owner, repo.Name, commit.SHA),
+			"commit":   map[string]interface{}{"message": commit.Message, "author": map[string]string{"name": commit.Author}},
+			"author":   map[string]string{"login": commit.Author},
+			"parents":  parents,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"html_url":      fmt.Sprintf("http://%s/%s/%s/compare/%s...%s", r.Host, e.state.Owner, repo.Name, base, head),
+		"total_commits": len(commits),
+		"commits":       body,
+	})
+}
+
+func (e *Emulator) listReviews(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	pull := e.lookupPull(w, r, repo)
+	if pull == nil {
+		return
+	}
+	body := []interface{}{}
+	for i, review := range pull.Reviews {
+		body = append(body, map[string]interface{}{
+			"id":    i + 1,
+			"user":  map[string]interface{}{"login": review.User},
+			"state": review.State,
+		})
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// getCombinedStatus reports the commit statuses of a ref. Like GitHub, a ref without statuses is pending.
+func (e *Emulator) getCombinedStatus(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	state := "success"
+	statuses := []interface{}{}
+	for _, check := range repo.checks(r.PathValue("ref"), "status") {
+		checkState := check.Conclusion
+		if check.Status != "" && check.Status != "completed" {
+			checkState = "pending"
+		}
+		if checkState != "success" && state != "failure" {
+			state = checkState
+		}
+		statuses = append(statuses, map[string]interface{}{"context": check.Name, "state": checkState})
+	}
+	if len(statuses) == 0 {
+		state = "pending"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"state":       state,
+		"sha":         repo.resolveSHA(r.PathValue("ref")),
+		"total_count": len(statuses),
+		"statuses":    statuses,
+	})
+}
+
+func (e *Emulator) listCheckRuns(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	repo := e.lookupRepo(w, r)
+	if repo == nil {
+		return
+	}
+	runs := []interface{}{}
+	for i, check := range repo.checks(r.PathValue("ref"), "check_run") {
+		status := check.Status
+		if status == "" {
+			status = "completed"
+		}
+		runs = append(runs, map[string]interface{}{
+			"id":         i + 1,
+			"name":       check.Name,
+			"head_sha":   check.SHA,
+			"status":     status,
+			"conclusion": check.Conclusion,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"total_count": len(runs), "check_runs": runs})
+}
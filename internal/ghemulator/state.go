@@ -0,0 +1,301 @@
+package ghemulator
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// State is the organization the emulator serves. It can be built in Go or loaded from a JSON seed file.
+type State struct {
+	Owner       string   `json:"owner"`
+	Repos       []*Repo  `json:"repos"`
+	ActiveEpics []string `json:"active_epics"`
+	Faults      []*Fault `json:"faults"`
+}
+
+// Fault makes the next Count requests matching Method and PathPrefix fail with Status,
+// to exercise retries on rate limits and transient server errors
+type Fault struct {
+	Method     string `json:"method"`
+	PathPrefix string `json:"path_prefix"`
+	Status     int    `json:"status"`
+	RetryAfter int    `json:"retry_after"`
+	Message    string `json:"message"`
+	Count      int    `json:"count"`
+}
+
+type Repo struct {
+	Name     string   `json:"name"`
+	Archived bool     `json:"archived"`
+	Topics   []string `json:"topics"`
+	// CustomProperties maps organization custom property names to a string or a list of strings
+	CustomProperties map[string]interface{} `json:"custom_properties"`
+	Branches         []*Branch              `json:"branches"`
+	Tags             []*Tag                 `json:"tags"`
+	Workflows        []*Workflow            `json:"workflows"`
+	// Conflicts lists "head->base" pairs whose PRs are reported as not mergeable
+	Conflicts  []string    `json:"conflicts"`
+	Pulls      []*Pull     `json:"pulls"`
+	Dispatches []*Dispatch `json:"dispatches"`
+	Runs       []*Run      `json:"runs"`
+	Events     []*Event    `json:"events"`
+	Releases   []*Release  `json:"releases"`
+	// Commits is the history of the repo, oldest first. Branches and tags point into it by SHA.
+	Commits []*Commit `json:"commits"`
+	// Files maps file paths to their content on every ref. Workflow files that aren't listed
+	// get defaultWorkflowFile.
+	Files map[string]string `json:"files"`
+	// Checks are the check runs and commit statuses of the commits
+	Checks []*Check `json:"checks"`
+}
+
+type Branch struct {
+	Name      string `json:"name"`
+	SHA       string `json:"sha"`
+	Protected bool   `json:"protected"`
+}
+
+// Tag is a lightweight tag
+type Tag struct {
+	Name string `json:"name"`
+	SHA  string `json:"sha"`
+}
+
+type Workflow struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Conclusion is given to the runs dispatches start: success (the default), failure, cancelled,
+	// or pending to leave them in progress
+	Conclusion string `json:"conclusion"`
+}
+
+type Pull struct {
+	Number   int      `json:"number"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Head     string   `json:"head"`
+	Base     string   `json:"base"`
+	State    string   `json:"state"`
+	Comments []string `json:"comments"`
+	Author   string   `json:"author"`
+	Labels   []string `json:"labels"`
+	Merged   bool     `json:"merged"`
+	// MergeCommitSHA is the commit merging the pull request created
+	MergeCommitSHA string    `json:"merge_commit_sha"`
+	Reviews        []*Review `json:"reviews"`
+}
+
+type Review struct {
+	User string `json:"user"`
+	// State is APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	State string `json:"state"`
+}
+
+// Check is a check run of a commit, or a commit status with status
+type Check struct {
+	SHA  string `json:"sha"`
+	Name string `json:"name"`
+	// Type is check_run (the default) or status
+	Type string `json:"type"`
+	// Status is queued, in_progress or completed, which is the default
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+}
+
+type Commit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	// Parents are the SHAs of the parent commits. Left out, the parent is the previous commit of the history.
+	Parents []string `json:"parents"`
+}
+
+// Dispatch is a recorded workflow_dispatch call
+type Dispatch struct {
+	WorkflowID int64                  `json:"workflow_id"`
+	Ref        string                 `json:"ref"`
+	Inputs     map[string]interface{} `json:"inputs"`
+}
+
+// Run is a workflow run started by a dispatch
+type Run struct {
+	ID         int64     `json:"id"`
+	WorkflowID int64     `json:"workflow_id"`
+	Branch     string    `json:"branch"`
+	SHA        string    `json:"sha"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Release is a GitHub Release, in creation order
+type Release struct {
+	ID         int64  `json:"id"`
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// Event is a recorded repository_dispatch call
+type Event struct {
+	EventType     string          `json:"event_type"`
+	ClientPayload json.RawMessage `json:"client_payload"`
+}
+
+// LoadState reads a JSON seed file
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading seed file: %v", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing seed file: %v", err)
+	}
+	return &state, nil
+}
+
+// defaultWorkflowFile declares the workflow_dispatch inputs the action sends by default
+const defaultWorkflowFile = `on:
+  workflow_dispatch:
+    inputs:
+      environment:
+        required: true
+      release_version:
+        required: true
+`
+
+func (s *State) repo(name string) *Repo {
+	for _, repo := range s.Repos {
+		if repo.Name == name {
+			return repo
+		}
+	}
+	return nil
+}
+
+func (r *Repo) branch(name string) *Branch {
+	for _, branch := range r.Branches {
+		if branch.Name == name {
+			return branch
+		}
+	}
+	return nil
+}
+
+func (r *Repo) tag(name string) *Tag {
+	for _, tag := range r.Tags {
+		if tag.Name == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+func (r *Repo) pull(number int) *Pull {
+	for _, pull := range r.Pulls {
+		if pull.Number == number {
+			return pull
+		}
+	}
+	return nil
+}
+
+// blobSHA is the git blob SHA of content, which changes with every version of a file
+func blobSHA(content string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(content), content))))
+}
+
+func (r *Repo) hasConflicts(head string, base string) bool {
+	for _, pair := range r.Conflicts {
+		if pair == head+"->"+base {
+			return true
+		}
+	}
+	return false
+}
+
+// file returns the content of a file, and whether it exists
+func (r *Repo) file(path string) (string, bool) {
+	if content, ok := r.Files[path]; ok {
+		return content, true
+	}
+	for _, workflow := range r.Workflows {
+		if workflow.Path == path {
+			return defaultWorkflowFile, true
+		}
+	}
+	return "", false
+}
+
+func (r *Repo) run(id int64) *Run {
+	for _, run := range r.Runs {
+		if run.ID == id {
+			return run
+		}
+	}
+	return nil
+}
+
+func (r *Repo) release(tag string) *Release {
+	for _, release := range r.Releases {
+		if release.TagName == tag {
+			return release
+		}
+	}
+	return nil
+}
+
+// commitIndex returns the position in Commits of a branch, tag or SHA, or -1
+func (r *Repo) commitIndex(ref string) int {
+	sha := r.resolveSHA(ref)
+	for i, commit := range r.Commits {
+		if commit.SHA == sha {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveSHA returns the commit a branch or tag points at, or ref itself
+func (r *Repo) resolveSHA(ref string) string {
+	if branch := r.branch(ref); branch != nil {
+		return branch.SHA
+	}
+	if tag := r.tag(ref); tag != nil {
+		return tag.SHA
+	}
+	return ref
+}
+
+// checks returns the checks of type checkType on the commit ref resolves to
+func (r *Repo) checks(ref string, checkType string) []*Check {
+	sha := r.resolveSHA(ref)
+	var checks []*Check
+	for _, check := range r.Checks {
+		kind := check.Type
+		if kind == "" {
+			kind = "check_run"
+		}
+		if check.SHA == sha && kind == checkType {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+func (r *Repo) nextPullNumber() int {
+	next := 1
+	for _, pull := range r.Pulls {
+		if pull.Number >= next {
+			next = pull.Number + 1
+		}
+	}
+	return next
+}
@@ -0,0 +1,441 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"sort"
+	"strings"
+)
+
+// ChangelogEntry is a merged pull request, or a commit pushed without one
+type ChangelogEntry struct {
+	// Type and Scope are parsed from conventional commit titles such as "feat(api)!: add search"
+	Type     string   `json:"type,omitempty"`
+	Scope    string   `json:"scope,omitempty"`
+	Breaking bool     `json:"breaking,omitempty"`
+	Title    string   `json:"title"`
+	Number   int      `json:"number,omitempty"`
+	SHA      string   `json:"sha,omitempty"`
+	URL      string   `json:"url"`
+	Author   string   `json:"author,omitempty"`
+	Labels   []string `json:"labels,omitempty"`
+}
+
+type ChangelogGroup struct {
+	Name    string           `json:"name"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// RepoChangelog is what changed in one repo between Base and Head
+type RepoChangelog struct {
+	Repo       string           `json:"repo"`
+	Base       string           `json:"base,omitempty"`
+	Head       string           `json:"head,omitempty"`
+	CompareURL string           `json:"compare_url,omitempty"`
+	Commits    int              `json:"commits"`
+	Groups     []ChangelogGroup `json:"groups,omitempty"`
+	// Skipped explains why a repo has nothing to compare, e.g. its first release
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+const (
+	changelogBreakingGroup = "Breaking Changes"
+	changelogOtherGroup    = "Other Changes"
+)
+
+// changelogTypes are the conventional commit types in the order their groups are listed
+var changelogTypes = []struct{ name, group string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build"},
+	{"ci", "CI"},
+	{"style", "Style"},
+	{"chore", "Chores"},
+}
+
+// changelogLabelTypes gives a type to titles that aren't conventional commits from GitHub's default labels
+var changelogLabelTypes = map[string]string{
+	"bug":           "fix",
+	"enhancement":   "feat",
+	"feature":       "feat",
+	"documentation": "docs",
+}
+
+var conventionalTitle = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// BuildChangelog collects the merged pull requests and direct commits of every repo since its previous release.
+// Once the version is tagged the previous release tag is compared with the version tag; before that the
+// production branch is compared with the RC branch. Results keep repoList order; the error lists the failed repos.
+func BuildChangelog(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) ([]RepoChangelog, error) {
+	changelogs := make([]RepoChangelog, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		changelogs[i] = buildRepoChangelog(ctx, l, githubRepo, cfg, repoList[i])
+	})
+
+	var failures []string
+	for _, changelog := range changelogs {
+		if changelog.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", changelog.Repo, changelog.Error))
+		}
+	}
+	if len(failures) > 0 {
+		return changelogs, fmt.Errorf("failed to build the changelog of %d repo(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return changelogs, nil
+}
+
+func buildRepoChangelog(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) RepoChangelog {
+	changelog := RepoChangelog{Repo: repo}
+
+	var err error
+	changelog.Base, changelog.Head, err = changelogRange(ctx, githubRepo, cfg, repo)
+	if errors.Is(err, githubrepo.ErrBranchNotFound) {
+		// Repos without changes since the last release get no RC branch
+		l.Info("No %s branch in repo %s, so no changes", cfg.RCBranch, repo)
+		changelog.Skipped = fmt.Sprintf("no changes, there is no %s branch", cfg.RCBranch)
+		return changelog
+	}
+	if err != nil {
+		l.Error("Error finding what to compare in repo %s: %v", repo, err)
+		changelog.Error = err.Error()
+		return changelog
+	}
+	if changelog.Base == "" {
+		l.Info("No previous release of repo %s to compare %s with", repo, changelog.Head)
+		changelog.Skipped = "no previous release to compare with"
+		return changelog
+	}
+
+	comparison, err := githubRepo.CompareCommits(ctx, cfg.Owner, repo, changelog.Base, changelog.Head)
+	if err != nil {
+		changelog.Error = err.Error()
+		return changelog
+	}
+	changelog.CompareURL = comparison.HTMLURL
+	changelog.Commits = len(comparison.Commits)
+
+	entries, err := changelogEntries(ctx, githubRepo, cfg, repo, comparison.Commits)
+	if err != nil {
+		changelog.Error = err.Error()
+		return changelog
+	}
+
+	changelog.Groups = groupChangelogEntries(entries, cfg.ChangelogGroupBy)
+	l.Info("Changelog of repo %s (%s...%s): %d commit(s), %d change(s)", repo, changelog.Base, changelog.Head, changelog.Commits, len(entries))
+	return changelog
+}
+
+// changelogEntries lists the pull requests merged into the development branch that brought in commits, followed by
+// the commits that came without one. The pull requests are listed once and matched with the commits locally.
+func changelogEntries(ctx context.Context, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string, commits []githubrepo.RespCommit) ([]ChangelogEntry, error) {
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	// Pull requests are merged after their commits were made
+	oldest := commits[0].Date
+	for _, commit := range commits {
+		if commit.Date.Before(oldest) {
+			oldest = commit.Date
+		}
+	}
+	pulls, err := githubRepo.ListMergedPullRequests(ctx, cfg.Owner, repo, cfg.DevelopmentBranch, oldest)
+	if err != nil {
+		return nil, err
+	}
+	pullOf := pullRequestsOfCommits(commits, pulls)
+
+	var entries, directCommits []ChangelogEntry
+	seen := make(map[int]bool)
+	for _, commit := range commits {
+		if pull, ok := pullOf[commit.SHA]; ok {
+			if !seen[pull.Number] {
+				seen[pull.Number] = true
+				entries = append(entries, changelogEntry(pull.Title, pull.Labels, ChangelogEntry{Number: pull.Number, URL: pull.HTMLURL, Author: pull.Author}))
+			}
+			continue
+		}
+		// Merge commits only bring in changes that are listed already
+		if len(commit.Parents) <= 1 {
+			title, _, _ := strings.Cut(commit.Message, "\n")
+			entry := changelogEntry(title, nil, ChangelogEntry{SHA: commit.SHA, URL: commit.HTMLURL, Author: commit.Author})
+			entry.Breaking = entry.Breaking || strings.Contains(commit.Message, "BREAKING CHANGE")
+			directCommits = append(directCommits, entry)
+		}
+	}
+	return append(entries, directCommits...), nil
+}
+
+// pullRequestsOfCommits maps the commits to the pull request that brought them in. A pull request owns the commit
+// it was merged with, and for a merge commit also the commits it merged that aren't on the mainline already.
+// Rebase merges are only matched by their last commit.
+func pullRequestsOfCommits(commits []githubrepo.RespCommit, pulls []githubrepo.RespPullRequest) map[string]githubrepo.RespPullRequest {
+	bySHA := make(map[string]githubrepo.RespCommit, len(commits))
+	for _, commit := range commits {
+		bySHA[commit.SHA] = commit
+	}
+	pullByMerge := make(map[string]githubrepo.RespPullRequest, len(pulls))
+	for _, pull := range pulls {
+		if pull.MergeCommitSHA != "" {
+			pullByMerge[pull.MergeCommitSHA] = pull
+		}
+	}
+
+	// ancestors walks the parents of the commits within the comparison
+	ancestors := func(from []string, visit func(sha string) bool) {
+		stack := append([]string(nil), from...)
+		for len(stack) > 0 {
+			sha := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			commit, ok := bySHA[sha]
+			if !ok || !visit(sha) {
+				continue
+			}
+			stack = append(stack, commit.Parents...)
+		}
+	}
+
+	pullOf := make(map[string]githubrepo.RespPullRequest)
+	// Oldest first, so pull requests merged into a branch that was merged later keep their commits
+	for _, commit := range commits {
+		pull, ok := pullByMerge[commit.SHA]
+		if !ok {
+			continue
+		}
+		pullOf[commit.SHA] = pull
+		if len(commit.Parents) < 2 {
+			continue
+		}
+		mainline := make(map[string]bool)
+		ancestors(commit.Parents[:1], func(sha string) bool {
+			if mainline[sha] {
+				return false
+			}
+			mainline[sha] = true
+			return true
+		})
+		ancestors(commit.Parents[1:], func(sha string) bool {
+			if mainline[sha] {
+				return false
+			}
+			if _, owned := pullOf[sha]; owned {
+				return false
+			}
+			pullOf[sha] = pull
+			return true
+		})
+	}
+	return pullOf
+}
+
+// changelogRange returns the refs to compare, with an empty base when the version is the first release of the repo
+func changelogRange(ctx context.Context, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) (base string, head string, err error) {
+	tagSHA, err := githubRepo.GetTagSHA(ctx, cfg.Owner, repo, cfg.RCVersion)
+	if err != nil {
+		return "", "", err
+	}
+	if tagSHA == "" {
+		if _, err := githubRepo.GetBranchSHA(ctx, cfg.Owner, repo, cfg.RCBranch); err != nil {
+			return "", "", err
+		}
+		return cfg.ProductionBranch, cfg.RCBranch, nil
+	}
+
+	base, err = previousReleaseTag(ctx, githubRepo, cfg, repo)
+	if err != nil {
+		return "", "", err
+	}
+	return base, cfg.RCVersion, nil
+}
+
+// previousReleaseTag returns the tag of the published release of a repo with the latest version before cfg.RCVersion,
+// which needn't be its latest release, or "" if there is none
+func previousReleaseTag(ctx context.Context, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) (string, error) {
+	releases, err := githubRepo.ListReleases(ctx, cfg.Owner, repo)
+	if err != nil {
+		return "", err
+	}
+	current, err := utils.ParseVersion(cfg.RCVersion, cfg.AllowCalVer)
+	if err != nil {
+		return "", err
+	}
+	var previous *utils.Version
+	previousTag := ""
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		version, err := utils.ParseVersion(release.TagName, cfg.AllowCalVer)
+		if err == nil && version.Less(current) && (previous == nil || previous.Less(version)) {
+			previous, previousTag = &version, release.TagName
+		}
+	}
+	return previousTag, nil
+}
+
+// changelogEntry completes entry from a pull request or commit title
+func changelogEntry(title string, labels []string, entry ChangelogEntry) ChangelogEntry {
+	entry.Title = strings.TrimSpace(title)
+	entry.Labels = labels
+	if match := conventionalTitle.FindStringSubmatch(entry.Title); match != nil {
+		entry.Type = strings.ToLower(match[1])
+		entry.Scope = match[2]
+		entry.Breaking = match[3] == "!"
+		entry.Title = match[4]
+	}
+	for _, label := range labels {
+		label = strings.ToLower(label)
+		if entry.Type == "" && changelogLabelTypes[label] != "" {
+			entry.Type = changelogLabelTypes[label]
+		}
+		if label == "breaking" || label == "breaking-change" {
+			entry.Breaking = true
+		}
+	}
+	return entry
+}
+
+// groupChangelogEntries groups the entries by type or by label. Breaking changes always come first in their own group.
+func groupChangelogEntries(entries []ChangelogEntry, groupBy string) []ChangelogGroup {
+	byName := make(map[string][]ChangelogEntry)
+	for _, entry := range entries {
+		name := changelogOtherGroup
+		switch {
+		case entry.Breaking:
+			name = changelogBreakingGroup
+		case groupBy == configs.ChangelogGroupByLabel:
+			if len(entry.Labels) > 0 {
+				labels := append([]string(nil), entry.Labels...)
+				sort.Strings(labels)
+				name = labels[0]
+			}
+		default:
+			for _, t := range changelogTypes {
+				if t.name == entry.Type {
+					name = t.group
+				}
+			}
+		}
+		byName[name] = append(byName[name], entry)
+	}
+
+	var order []string
+	if groupBy == configs.ChangelogGroupByLabel {
+		for name := range byName {
+			if name != changelogBreakingGroup && name != changelogOtherGroup {
+				order = append(order, name)
+			}
+		}
+		sort.Strings(order)
+	} else {
+		for _, t := range changelogTypes {
+			order = append(order, t.group)
+		}
+	}
+	order = append(append([]string{changelogBreakingGroup}, order...), changelogOtherGroup)
+
+	var groups []ChangelogGroup
+	for _, name := range order {
+		if len(byName[name]) > 0 {
+			groups = append(groups, ChangelogGroup{Name: name, Entries: byName[name]})
+		}
+	}
+	return groups
+}
+
+// ChangelogMarkdown renders the changelogs as one Markdown document
+func ChangelogMarkdown(rcVersion string, changelogs []RepoChangelog) string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Changelog %s\n", rcVersion)
+	for _, changelog := range changelogs {
+		fmt.Fprintf(&md, "\n## %s\n\n", changelog.Repo)
+		switch {
+		case changelog.Error != "":
+			fmt.Fprintf(&md, "_Could not be compiled: %s_\n", changelog.Error)
+			continue
+		case changelog.Skipped != "":
+			fmt.Fprintf(&md, "_Skipped: %s._\n", changelog.Skipped)
+			continue
+		}
+		fmt.Fprintf(&md, "[`%s...%s`](%s) · %d commit(s)\n", changelog.Base, changelog.Head, changelog.CompareURL, changelog.Commits)
+		if len(changelog.Groups) == 0 {
+			md.WriteString("\nNo changes.\n")
+		}
+		for _, group := range changelog.Groups {
+			fmt.Fprintf(&md, "\n### %s\n\n", group.Name)
+			for _, entry := range group.Entries {
+				md.WriteString("- ")
+				if entry.Scope != "" {
+					fmt.Fprintf(&md, "**%s:** ", entry.Scope)
+				}
+				md.WriteString(entry.Title)
+				if entry.Number != 0 {
+					fmt.Fprintf(&md, " ([#%d](%s))", entry.Number, entry.URL)
+				} else if entry.SHA != "" {
+					fmt.Fprintf(&md, " ([%s](%s))", entry.SHA[:min(len(entry.SHA), 7)], entry.URL)
+				}
+				if entry.Author != "" {
+					fmt.Fprintf(&md, " by @%s", entry.Author)
+				}
+				md.WriteString("\n")
+			}
+		}
+	}
+	return md.String()
+}
+
+// ChangelogUseCase builds the changelog of the version across the selected repos
+func ChangelogUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) {
+	l.Info("Changelog use case")
+
+	repoList, err := githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
+	if err != nil {
+		l.Fatal("Error listing repositories: %v", err)
+	}
+	l.Info("repoList: %v", repoList)
+
+	changelogs, err := BuildChangelog(ctx, l, githubRepo, cfg, repoList)
+
+	if changelogJSON, jsonErr := json.Marshal(changelogs); jsonErr != nil {
+		l.Error("Error marshalling changelog: %v", jsonErr)
+	} else {
+		safeSetOutput("changelog", string(changelogJSON), cfg, l)
+	}
+	safeSetOutput("changelog_markdown", ChangelogMarkdown(cfg.RCVersion, changelogs), cfg, l)
+
+	var changelogItems []map[string]interface{}
+	for _, changelog := range changelogs {
+		var groups []map[string]interface{}
+		for _, group := range changelog.Groups {
+			groups = append(groups, map[string]interface{}{"name": group.Name, "count": len(group.Entries)})
+		}
+		changelogItems = append(changelogItems, map[string]interface{}{
+			"repo":        changelog.Repo,
+			"compare_url": changelog.CompareURL,
+			"groups":      groups,
+			"skipped":     changelog.Skipped,
+			"error":       changelog.Error,
+		})
+	}
+	slackPayload, slackErr := utils.ChangelogSlackPayloadBuilder(cfg.RCVersion, changelogItems)
+	if slackErr != nil {
+		l.Error("Error building changelog slack payload: %v", slackErr)
+	} else {
+		setSlackPayloadOutput("slack_payload", slackPayload, cfg, l)
+	}
+
+	if err != nil {
+		l.Fatal("Error building changelog: %v", err)
+	}
+}
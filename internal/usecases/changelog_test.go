@@ -0,0 +1,218 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"strings"
+	"testing"
+)
+
+// addChangelogRepo adds a repo released as v1.0.0 at c0, followed by a feature merged with a merge commit,
+// a squashed fix and a commit pushed without a pull request, and the rc/v1.1.0 branch
+func addChangelogRepo(fake *githubrepo.FakeGithubRepo, name string) *githubrepo.FakeRepo {
+	repo := fake.AddRepo(name).
+		AddCommit("c0", "chore: release v1.0.0", "alice").
+		AddCommit("f1", "wip search", "bob").
+		AddCommit("f2", "search paging", "bob").
+		AddMergeCommit("m1", "Merge pull request #1 from o/search", "bob", "c0", "f2").
+		AddCommit("s1", "fix: retry uploads (#2)", "carol").
+		AddCommit("d1", "docs: fix typo\n\nSpotted in review", "dave").
+		AddBranch("main", "c0", true).
+		AddBranch("rc/v1.1.0", "d1", false).
+		AddTag("v1.0.0", "c0").
+		AddRelease("v1.0.0", false)
+
+	feature := fake.AddPullRequest(name, "search", "development")
+	feature.Title, feature.Author, feature.Merged, feature.MergeCommit = "feat(search)!: add search", "bob", true, "m1"
+	fix := fake.AddPullRequest(name, "retry", "development")
+	fix.Title, fix.Author, fix.Labels, fix.Merged, fix.MergeCommit = "Retry uploads", "carol", []string{"bug"}, true, "s1"
+	hotfix := fake.AddPullRequest(name, "hotfix/v1.0.1", "main")
+	hotfix.Title, hotfix.Merged, hotfix.MergeCommit = "fix: hotfix", true, "f1"
+	return repo
+}
+
+func TestBuildChangelog(t *testing.T) {
+	wantGroups := []ChangelogGroup{
+		{Name: changelogBreakingGroup, Entries: []ChangelogEntry{{Type: "feat", Scope: "search", Breaking: true, Title: "add search", Number: 1, URL: "https://github.com/fake/api/pull/1", Author: "bob"}}},
+		{Name: "Bug Fixes", Entries: []ChangelogEntry{{Type: "fix", Title: "Retry uploads", Number: 2, URL: "https://github.com/fake/api/pull/2", Author: "carol", Labels: []string{"bug"}}}},
+		{Name: "Documentation", Entries: []ChangelogEntry{{Type: "docs", Title: "fix typo", SHA: "d1", URL: "https://github.com/fake/api/commit/d1", Author: "dave"}}},
+	}
+	tests := []struct {
+		name string
+		// setup adds the api repo
+		setup       func(fake *githubrepo.FakeGithubRepo)
+		wantBase    string
+		wantHead    string
+		wantCommits int
+		wantGroups  []ChangelogGroup
+		wantSkipped string
+		wantErr     bool
+	}{
+		{
+			name:        "compares the production branch with the RC branch",
+			setup:       func(fake *githubrepo.FakeGithubRepo) { addChangelogRepo(fake, "api") },
+			wantBase:    "main",
+			wantHead:    "rc/v1.1.0",
+			wantCommits: 5,
+			wantGroups:  wantGroups,
+		},
+		{
+			name: "compares a tagged version with the previous release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				addChangelogRepo(fake, "api").AddTag("v1.1.0", "d1").AddRelease("v1.1.0-rc.1", true)
+			},
+			wantBase:    "v1.0.0",
+			wantHead:    "v1.1.0",
+			wantCommits: 5,
+			wantGroups:  wantGroups,
+		},
+		{
+			name: "has no changes without an RC branch",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddCommit("c0", "chore: release v1.0.0", "alice").AddBranch("main", "c0", true)
+			},
+			wantSkipped: "no changes, there is no rc/v1.1.0 branch",
+		},
+		{
+			name: "skips the first release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddCommit("c0", "feat: first", "alice").AddTag("v1.1.0", "c0")
+			},
+			wantHead:    "v1.1.0",
+			wantSkipped: "no previous release to compare with",
+		},
+		{
+			name: "reports a failure to list the pull requests",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				addChangelogRepo(fake, "api")
+				fake.FailOn("ListMergedPullRequests", "api", errors.New("boom"))
+			},
+			wantBase:    "main",
+			wantHead:    "rc/v1.1.0",
+			wantCommits: 5,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			tt.setup(fake)
+
+			changelogs, err := BuildChangelog(context.Background(), testLogger(), fake, testConfig(), []string{"api"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildChangelog() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := changelogs[0]
+			if got.Base != tt.wantBase || got.Head != tt.wantHead || got.Commits != tt.wantCommits || got.Skipped != tt.wantSkipped {
+				t.Errorf("changelog = %s...%s, %d commit(s), skipped %q, want %s...%s, %d commit(s), skipped %q",
+					got.Base, got.Head, got.Commits, got.Skipped, tt.wantBase, tt.wantHead, tt.wantCommits, tt.wantSkipped)
+			}
+			if (got.Error != "") != tt.wantErr {
+				t.Errorf("changelog error = %q, wantErr %v", got.Error, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got.Groups, tt.wantGroups) {
+				t.Errorf("groups = %+v, want %+v", got.Groups, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestPullRequestsOfCommits(t *testing.T) {
+	// Two features are merged into a release branch, which is merged in turn
+	commits := []githubrepo.RespCommit{
+		{SHA: "a1", Parents: []string{"c0"}},
+		{SHA: "a2", Parents: []string{"a1"}},
+		{SHA: "ma", Parents: []string{"c0", "a2"}},
+		{SHA: "b1", Parents: []string{"ma"}},
+		{SHA: "mr", Parents: []string{"c0", "b1"}},
+	}
+	pulls := []githubrepo.RespPullRequest{
+		{Number: 2, MergeCommitSHA: "mr"},
+		{Number: 1, MergeCommitSHA: "ma"},
+		{Number: 9, MergeCommitSHA: "elsewhere"},
+	}
+	want := map[string]int{"a1": 1, "a2": 1, "ma": 1, "b1": 2, "mr": 2}
+
+	got := make(map[string]int)
+	for sha, pull := range pullRequestsOfCommits(commits, pulls) {
+		got[sha] = pull.Number
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pullRequestsOfCommits() = %v, want %v", got, want)
+	}
+}
+
+func TestChangelogEntry(t *testing.T) {
+	tests := []struct {
+		title  string
+		labels []string
+		want   ChangelogEntry
+	}{
+		{title: "feat(api)!: add search", want: ChangelogEntry{Type: "feat", Scope: "api", Breaking: true, Title: "add search"}},
+		{title: "Fix: retry", want: ChangelogEntry{Type: "fix", Title: "retry"}},
+		{title: "Retry uploads", labels: []string{"Bug"}, want: ChangelogEntry{Type: "fix", Title: "Retry uploads", Labels: []string{"Bug"}}},
+		{title: "docs: drop v1", labels: []string{"breaking-change"}, want: ChangelogEntry{Type: "docs", Breaking: true, Title: "drop v1", Labels: []string{"breaking-change"}}},
+		{title: "  Bump deps ", want: ChangelogEntry{Title: "Bump deps"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := changelogEntry(tt.title, tt.labels, ChangelogEntry{}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changelogEntry() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupChangelogEntries(t *testing.T) {
+	entries := []ChangelogEntry{
+		{Type: "fix", Title: "retry", Labels: []string{"infra", "bug"}},
+		{Type: "feat", Title: "search", Breaking: true},
+		{Title: "bump deps"},
+		{Type: "feat", Title: "export", Labels: []string{"api"}},
+	}
+	names := func(groups []ChangelogGroup) []string {
+		var names []string
+		for _, group := range groups {
+			names = append(names, group.Name)
+		}
+		return names
+	}
+
+	if got, want := names(groupChangelogEntries(entries, configs.ChangelogGroupByType)), []string{changelogBreakingGroup, "Features", "Bug Fixes", changelogOtherGroup}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups by type = %v, want %v", got, want)
+	}
+	if got, want := names(groupChangelogEntries(entries, configs.ChangelogGroupByLabel)), []string{changelogBreakingGroup, "api", "bug", changelogOtherGroup}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups by label = %v, want %v", got, want)
+	}
+}
+
+func TestChangelogMarkdown(t *testing.T) {
+	changelogs := []RepoChangelog{
+		{
+			Repo: "api", Base: "main", Head: "rc/v1.1.0", CompareURL: "https://github.com/o/api/compare/main...rc/v1.1.0", Commits: 2,
+			Groups: []ChangelogGroup{{Name: "Features", Entries: []ChangelogEntry{
+				{Scope: "search", Title: "add search", Number: 1, URL: "https://github.com/o/api/pull/1", Author: "bob"},
+				{Title: "fix typo", SHA: "d1e2f3a4b5", URL: "https://github.com/o/api/commit/d1e2f3a4b5"},
+			}}},
+		},
+		{Repo: "web", Skipped: "no changes, there is no rc/v1.1.0 branch"},
+		{Repo: "billing", Error: "boom"},
+	}
+	markdown := ChangelogMarkdown("v1.1.0", changelogs)
+	for _, want := range []string{
+		"# Changelog v1.1.0",
+		"[`main...rc/v1.1.0`](https://github.com/o/api/compare/main...rc/v1.1.0) · 2 commit(s)",
+		"### Features",
+		"- **search:** add search ([#1](https://github.com/o/api/pull/1)) by @bob",
+		"- fix typo ([d1e2f3a](https://github.com/o/api/commit/d1e2f3a4b5))",
+		"_Skipped: no changes, there is no rc/v1.1.0 branch._",
+		"_Could not be compiled: boom_",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("ChangelogMarkdown() = %s\nwant it to contain %q", markdown, want)
+		}
+	}
+}
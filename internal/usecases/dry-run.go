@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"fmt"
+	"os"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+)
+
+// setSlackPayloadOutput sets a Slack payload output unless running in dry-run mode,
+// where the plan payload set by ReportDryRunPlan replaces it
+func setSlackPayloadOutput(key, payload string, cfg *configs.Config, l utils.LogInterface) {
+	if cfg.DryRun {
+		l.Info("Dry run: %s will contain the dry-run plan", key)
+		return
+	}
+	safeSetOutput(key, slackPayloadForChannel(payload, cfg, l), cfg, l)
+}
+
+// slackPayloadForChannel addresses a payload to the configured Slack channel, if there is one
+func slackPayloadForChannel(payload string, cfg *configs.Config, l utils.LogInterface) string {
+	if cfg.SlackChannel == "" {
+		return payload
+	}
+	addressed, err := utils.WithSlackChannel(payload, cfg.SlackChannel)
+	if err != nil {
+		l.Error("Error adding slack channel to payload: %v", err)
+		return payload
+	}
+	return addressed
+}
+
+// ReportDryRunPlan prints the recorded plan as a table to stderr, keeping stdout for the outputs of a CLI run,
+// and exposes it through the dry_run_plan output and the Slack payload outputs
+func ReportDryRunPlan(l utils.LogInterface, cfg *configs.Config, plan *githubrepo.DryRunPlan) {
+	actions := plan.Actions()
+	l.Info("Dry run recorded %d action(s)", len(actions))
+	fmt.Fprintf(os.Stderr, "\nDry-run plan for %s %s:\n%s\n", cfg.UseCase, cfg.RCVersion, plan.Table())
+
+	planJSON, err := plan.JSON()
+	if err != nil {
+		l.Error("Error building dry-run plan JSON: %v", err)
+	} else {
+		safeSetOutput("dry_run_plan", planJSON, cfg, l)
+	}
+
+	var actionItems []map[string]interface{}
+	for _, action := range actions {
+		actionItems = append(actionItems, map[string]interface{}{
+			"action":  action.Action,
+			"repo":    action.Repo,
+			"target":  action.Target,
+			"details": action.Details,
+		})
+	}
+	slackPayload, err := utils.DryRunPlanSlackPayloadBuilder(cfg.UseCase, cfg.RCVersion, actionItems)
+	if err != nil {
+		l.Error("Error building dry-run slack payload: %v", err)
+		return
+	}
+	slackPayload = slackPayloadForChannel(slackPayload, cfg, l)
+	safeSetOutput("slack_payload", slackPayload, cfg, l)
+	if cfg.UseCase == "Main-To-Epic-Sync" || cfg.EnableMainToEpicSync {
+		safeSetOutput("sync_pr_slack_payload", slackPayload, cfg, l)
+	}
+}
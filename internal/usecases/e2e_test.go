@@ -0,0 +1,78 @@
+package usecases
+
+import (
+	"context"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/ghemulator"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"testing"
+)
+
+// TestProductionReleaseEndToEnd runs a locked production release on tags through the real GithubRepo against the emulator
+func TestProductionReleaseEndToEnd(t *testing.T) {
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{
+		{Name: "control"},
+		{
+			Name:      "api",
+			Branches:  []*ghemulator.Branch{{Name: "main", SHA: "a2"}},
+			Workflows: []*ghemulator.Workflow{{ID: 1, Name: "Prod", Path: prodWorkflowPath}},
+		},
+		{
+			Name:      "web",
+			Branches:  []*ghemulator.Branch{{Name: "main", SHA: "w2"}},
+			Tags:      []*ghemulator.Tag{{Name: "v1.1.0", SHA: "w1"}},
+			Workflows: []*ghemulator.Workflow{{ID: 2, Name: "Prod", Path: prodWorkflowPath}},
+		},
+	}}
+	server := ghemulator.New(state).Start()
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Token = "test-token"
+	cfg.GitHubAPIURL = server.URL
+	cfg.ProductionRef = configs.ProductionRefTag
+	cfg.WaitForWorkflows = true
+	cfg.LockRepository = "control"
+	l := testLogger()
+	client, err := utils.CreateGitHubClient(l, cfg)
+	if err != nil {
+		t.Fatalf("CreateGitHubClient() error = %v", err)
+	}
+	githubRepo := githubrepo.NewGithubRepo(client, l)
+	ctx := context.Background()
+
+	release, err := AcquireReleaseLock(ctx, l, githubRepo, cfg)
+	if err != nil {
+		t.Fatalf("AcquireReleaseLock() error = %v", err)
+	}
+	if _, err := AcquireReleaseLock(ctx, l, githubRepo, cfg); err == nil {
+		t.Error("a second run acquired the held release lock")
+	}
+	results, _, dispatchErr := ProductionWorkflowDispatch(ctx, l, githubRepo, cfg, []string{"api", "web"})
+	release()
+
+	if dispatchErr == nil {
+		t.Error("ProductionWorkflowDispatch() error = nil, want the failure of web whose tag points elsewhere")
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	if api := results[0]; api.Status != DispatchStatusDispatched || api.Ref != "refs/tags/v1.1.0" || api.SHA != "a2" || api.Conclusion != "success" {
+		t.Errorf("api result = %+v, want a successful run on tag v1.1.0 at a2", api)
+	}
+	if web := results[1]; web.Status != DispatchStatusFailed {
+		t.Errorf("web result = %+v, want it failed", web)
+	}
+
+	api, web := state.Repos[1], state.Repos[2]
+	if len(api.Tags) != 1 || api.Tags[0].SHA != "a2" || len(api.Dispatches) != 1 {
+		t.Errorf("api has tags %+v and %d dispatch(es), want tag v1.1.0 at a2 and one dispatch", api.Tags, len(api.Dispatches))
+	}
+	if len(web.Tags) != 1 || web.Tags[0].SHA != "w1" || len(web.Dispatches) != 0 {
+		t.Errorf("web has tags %+v and %d dispatch(es), want its tag unmoved and no dispatch", web.Tags, len(web.Dispatches))
+	}
+	if len(state.Repos[0].Files) != 0 {
+		t.Errorf("control repo files = %v, want the lock released", state.Repos[0].Files)
+	}
+}
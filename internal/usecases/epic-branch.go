@@ -2,10 +2,12 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"release-candidate/internal/usecases/githubrepo"
 	"release-candidate/internal/utils"
+	"sort"
 	"strings"
 )
 
@@ -65,58 +67,82 @@ type EpicBranchMatch struct {
 	Found       bool
 }
 
-// FindEpicBranchesInRepos checks each repo for matching epic branches
+// FindEpicBranchesInRepos checks each repo for matching epic branches, up to concurrency repos at a time
 // Returns a map of repo -> []EpicBranchMatch for all active epics
-func FindEpicBranchesInRepos(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GithubRepo, owner string, repoList []string, activeEpics []string) (map[string][]EpicBranchMatch, error) {
-	results := make(map[string][]EpicBranchMatch)
+func FindEpicBranchesInRepos(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, owner string, repoList []string, activeEpics []string, concurrency int) (map[string][]EpicBranchMatch, error) {
+	repoMatches := make([][]EpicBranchMatch, len(repoList))
+	repoErrs := make([]error, len(repoList))
 
-	for _, repo := range repoList {
-		l.Info("Checking epic branches in repo: %s", repo)
+	utils.ForEachConcurrently(len(repoList), concurrency, func(i int) {
+		repoMatches[i], repoErrs[i] = findEpicBranchesInRepo(ctx, l, githubRepo, owner, repoList[i], activeEpics)
+	})
 
-		epicBranches, err := githubRepo.ListEpicBranches(ctx, owner, repo)
-		if err != nil {
-			l.Error("Error listing epic branches for %s: %v", repo, err)
-			return nil, err
-		}
+	if err := errors.Join(repoErrs...); err != nil {
+		return nil, err
+	}
 
-		if len(epicBranches) == 0 {
-			l.Info("No epic branches found in %s", repo)
-			continue
+	results := make(map[string][]EpicBranchMatch)
+	for i, repo := range repoList {
+		if len(repoMatches[i]) > 0 {
+			results[repo] = repoMatches[i]
 		}
+	}
+
+	return results, nil
+}
 
-		l.Debug("Found %d epic branches in %s: %v", len(epicBranches), repo, epicBranches)
+func findEpicBranchesInRepo(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, owner string, repo string, activeEpics []string) ([]EpicBranchMatch, error) {
+	l.Info("Checking epic branches in repo: %s", repo)
 
-		var matches []EpicBranchMatch
-		for _, epic := range activeEpics {
-			matchedBranches := FindMatchingBranches(epic, epicBranches)
-			match := EpicBranchMatch{
-				Repo:        repo,
-				Epic:        epic,
-				BranchNames: matchedBranches,
-				Found:       len(matchedBranches) > 0,
-			}
-			matches = append(matches, match)
+	epicBranches, err := githubRepo.ListEpicBranches(ctx, owner, repo)
+	if err != nil {
+		l.Error("Error listing epic branches for %s: %v", repo, err)
+		return nil, err
+	}
 
-			if match.Found {
-				l.Info("Found %d matching branch(es) %v for epic '%s' in repo '%s'", len(matchedBranches), matchedBranches, epic, repo)
-			} else {
-				l.Info("No matching branch for epic '%s' in repo '%s'", epic, repo)
-			}
+	if len(epicBranches) == 0 {
+		l.Info("No epic branches found in %s", repo)
+		return nil, nil
+	}
+
+	l.Debug("Found %d epic branches in %s: %v", len(epicBranches), repo, epicBranches)
+
+	var matches []EpicBranchMatch
+	for _, epic := range activeEpics {
+		matchedBranches := FindMatchingBranches(epic, epicBranches)
+		match := EpicBranchMatch{
+			Repo:        repo,
+			Epic:        epic,
+			BranchNames: matchedBranches,
+			Found:       len(matchedBranches) > 0,
 		}
+		matches = append(matches, match)
 
-		if len(matches) > 0 {
-			results[repo] = matches
+		if match.Found {
+			l.Info("Found %d matching branch(es) %v for epic '%s' in repo '%s'", len(matchedBranches), matchedBranches, epic, repo)
+		} else {
+			l.Info("No matching branch for epic '%s' in repo '%s'", epic, repo)
 		}
 	}
 
-	return results, nil
+	return matches, nil
+}
+
+// sortedRepos returns the repos of an epic branch lookup in a stable order
+func sortedRepos(results map[string][]EpicBranchMatch) []string {
+	repos := make([]string, 0, len(results))
+	for repo := range results {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	return repos
 }
 
 // GetReposWithEpicBranch returns repos that have a matching branch for the given epic
 func GetReposWithEpicBranch(results map[string][]EpicBranchMatch, epic string) []string {
 	var repos []string
-	for repo, matches := range results {
-		for _, match := range matches {
+	for _, repo := range sortedRepos(results) {
+		for _, match := range results[repo] {
 			if match.Epic == epic && match.Found {
 				repos = append(repos, repo)
 				break
@@ -136,14 +162,17 @@ type SyncBranchResult struct {
 	Error           string
 }
 
-// CreateSyncBranchesForEpics creates sync branches for each epic in repos where the epic branch exists
+// CreateSyncBranchesForEpics creates sync branches for each epic in repos where the epic branch exists,
+// up to concurrency repos at a time. Results are ordered by repo name.
 // Branch name format: sync/{release-version}-{formatted-epic-name}
-func CreateSyncBranchesForEpics(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GithubRepo, owner string, baseBranch string, releaseVersion string, epicBranchResults map[string][]EpicBranchMatch) ([]SyncBranchResult, error) {
-	var results []SyncBranchResult
-	var errs []string
-
-	for repo, matches := range epicBranchResults {
-		for _, match := range matches {
+func CreateSyncBranchesForEpics(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, owner string, baseBranch string, releaseVersion string, epicBranchResults map[string][]EpicBranchMatch, concurrency int) ([]SyncBranchResult, error) {
+	repos := sortedRepos(epicBranchResults)
+	repoResults := make([][]SyncBranchResult, len(repos))
+	repoErrs := make([][]string, len(repos))
+
+	utils.ForEachConcurrently(len(repos), concurrency, func(i int) {
+		repo := repos[i]
+		for _, match := range epicBranchResults[repo] {
 			if !match.Found {
 				continue
 			}
@@ -166,15 +195,22 @@ func CreateSyncBranchesForEpics(ctx context.Context, l utils.LogInterface, githu
 				l.Error("Error creating sync branch '%s' in repo '%s': %v", syncBranchName, repo, err)
 				result.Created = false
 				result.Error = err.Error()
-				errs = append(errs, fmt.Sprintf("%s/%s: %v", repo, syncBranchName, err))
+				repoErrs[i] = append(repoErrs[i], fmt.Sprintf("%s/%s: %v", repo, syncBranchName, err))
 			} else {
 				l.Info("Successfully created sync branch '%s' in repo '%s'", syncBranchName, repo)
 				result.Created = true
 
 			}
 
-			results = append(results, result)
+			repoResults[i] = append(repoResults[i], result)
 		}
+	})
+
+	var results []SyncBranchResult
+	var errs []string
+	for i := range repos {
+		results = append(results, repoResults[i]...)
+		errs = append(errs, repoErrs[i]...)
 	}
 
 	if len(errs) > 0 {
@@ -196,79 +232,107 @@ type SyncToEpicPRResult struct {
 	Error        string
 }
 
-// CreatePRsFromSyncToEpic creates PRs from sync branches to their corresponding epic branches
-func CreatePRsFromSyncToEpic(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GithubRepo, owner string, releaseVersion string, syncResults []SyncBranchResult) ([]SyncToEpicPRResult, error) {
+// CreatePRsFromSyncToEpic creates PRs from sync branches to their corresponding epic branches,
+// up to concurrency repos at a time. Results keep the order of syncResults.
+func CreatePRsFromSyncToEpic(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, owner string, releaseVersion string, syncResults []SyncBranchResult, concurrency int) ([]SyncToEpicPRResult, error) {
+	// Group sync branches by repo so each repo's PRs are created in order by a single worker
+	var repos []string
+	syncResultsByRepo := make(map[string][]int)
+	for i, syncResult := range syncResults {
+		if _, ok := syncResultsByRepo[syncResult.Repo]; !ok {
+			repos = append(repos, syncResult.Repo)
+		}
+		syncResultsByRepo[syncResult.Repo] = append(syncResultsByRepo[syncResult.Repo], i)
+	}
+
+	syncPRResults := make([][]SyncToEpicPRResult, len(syncResults))
+	syncErrs := make([][]string, len(syncResults))
+
+	utils.ForEachConcurrently(len(repos), concurrency, func(r int) {
+		for _, i := range syncResultsByRepo[repos[r]] {
+			syncPRResults[i], syncErrs[i] = createPRsFromSyncBranch(ctx, l, githubRepo, owner, releaseVersion, syncResults[i])
+		}
+	})
+
 	var results []SyncToEpicPRResult
 	var errs []string
+	for i := range syncResults {
+		results = append(results, syncPRResults[i]...)
+		errs = append(errs, syncErrs[i]...)
+	}
 
-	for _, syncResult := range syncResults {
-		// Skip if sync branch wasn't created successfully
-		if !syncResult.Created {
-			l.Debug("Skipping PR creation for %s/%s - sync branch was not created", syncResult.Repo, syncResult.BranchName)
-			continue
-		}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("failed to create some PRs: %s", strings.Join(errs, "; "))
+	}
 
-		// Create PR to each matching epic branch for each epic in each repo
-		for _, epicBranch := range syncResult.EpicBranchNames {
-			prTitle := fmt.Sprintf("Sync %s to %s", releaseVersion, epicBranch)
-			prBody := fmt.Sprintf("Syncing release %s changes to epic branch %s", releaseVersion, epicBranch)
+	return results, nil
+}
 
-			l.Info("Creating PR from '%s' to '%s' in repo '%s'", syncResult.BranchName, epicBranch, syncResult.Repo)
+func createPRsFromSyncBranch(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, owner string, releaseVersion string, syncResult SyncBranchResult) ([]SyncToEpicPRResult, []string) {
+	var results []SyncToEpicPRResult
+	var errs []string
 
-			prURL, prError, hasConflicts, err := githubRepo.CreatePullRequest(ctx, owner, syncResult.Repo, syncResult.BranchName, epicBranch, prTitle, prBody)
+	// Skip if sync branch wasn't created successfully
+	if !syncResult.Created {
+		l.Debug("Skipping PR creation for %s/%s - sync branch was not created", syncResult.Repo, syncResult.BranchName)
+		return nil, nil
+	}
 
-			result := SyncToEpicPRResult{
-				Repo:         syncResult.Repo,
-				Epic:         syncResult.Epic,
-				SyncBranch:   syncResult.BranchName,
-				EpicBranch:   epicBranch,
-				HasConflicts: hasConflicts,
-			}
+	// Create PR to each matching epic branch for each epic in each repo
+	for _, epicBranch := range syncResult.EpicBranchNames {
+		prTitle := fmt.Sprintf("Sync %s to %s", releaseVersion, epicBranch)
+		prBody := fmt.Sprintf("Syncing release %s changes to epic branch %s", releaseVersion, epicBranch)
 
-			if err != nil {
-				l.Error("Error creating PR from '%s' to '%s' in repo '%s': %v", syncResult.BranchName, epicBranch, syncResult.Repo, err)
-				result.Created = false
-				result.Error = err.Error()
-				errs = append(errs, fmt.Sprintf("%s: %s->%s: %v", syncResult.Repo, syncResult.BranchName, epicBranch, err))
-			} else if prError != "" {
-				l.Warn("PR created with warning from '%s' to '%s' in repo '%s': %s", syncResult.BranchName, epicBranch, syncResult.Repo, prError)
-				result.Created = true
-				result.PRURL = prURL
-				result.Error = prError
-			} else {
-				l.Info("Successfully created PR from '%s' to '%s' in repo '%s': %s", syncResult.BranchName, epicBranch, syncResult.Repo, prURL)
-				result.Created = true
-				result.PRURL = prURL
-			}
+		l.Info("Creating PR from '%s' to '%s' in repo '%s'", syncResult.BranchName, epicBranch, syncResult.Repo)
 
-			results = append(results, result)
+		prURL, prError, hasConflicts, err := githubRepo.CreatePullRequest(ctx, owner, syncResult.Repo, syncResult.BranchName, epicBranch, prTitle, prBody)
+
+		result := SyncToEpicPRResult{
+			Repo:         syncResult.Repo,
+			Epic:         syncResult.Epic,
+			SyncBranch:   syncResult.BranchName,
+			EpicBranch:   epicBranch,
+			HasConflicts: hasConflicts,
 		}
-	}
 
-	if len(errs) > 0 {
-		return results, fmt.Errorf("failed to create some PRs: %s", strings.Join(errs, "; "))
+		if err != nil {
+			l.Error("Error creating PR from '%s' to '%s' in repo '%s': %v", syncResult.BranchName, epicBranch, syncResult.Repo, err)
+			result.Created = false
+			result.Error = err.Error()
+			errs = append(errs, fmt.Sprintf("%s: %s->%s: %v", syncResult.Repo, syncResult.BranchName, epicBranch, err))
+		} else if prError != "" {
+			l.Warn("PR created with warning from '%s' to '%s' in repo '%s': %s", syncResult.BranchName, epicBranch, syncResult.Repo, prError)
+			result.Created = true
+			result.PRURL = prURL
+			result.Error = prError
+		} else {
+			l.Info("Successfully created PR from '%s' to '%s' in repo '%s': %s", syncResult.BranchName, epicBranch, syncResult.Repo, prURL)
+			result.Created = true
+			result.PRURL = prURL
+		}
+
+		results = append(results, result)
 	}
 
-	return results, nil
+	return results, errs
 }
 
 // CleanupOldSyncBranches checks for open PRs targeting the epic branches and closes them if they are from old sync branches and deletes the sync branch
-func CleanupOldSyncBranches(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GithubRepo, owner string, releaseVersion string, epicBranchResults map[string][]EpicBranchMatch) error {
-	for repo, matches := range epicBranchResults {
-		for _, match := range matches {
+// Sync branches of releases after releaseVersion are left alone, so a rerun of an older release doesn't close them.
+func CleanupOldSyncBranches(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, owner string, releaseVersion string, epicBranchResults map[string][]EpicBranchMatch) error {
+	// Calendar versions are parsed too, rc_version was checked against allow_calver already
+	currentVersion, err := utils.ParseVersion(releaseVersion, true)
+	if err != nil {
+		l.Error("Error parsing release version '%s': %v", releaseVersion, err)
+		return fmt.Errorf("error parsing release version '%s': %v", releaseVersion, err)
+	}
+
+	for _, repo := range sortedRepos(epicBranchResults) {
+		for _, match := range epicBranchResults[repo] {
 			if !match.Found {
 				continue
 			}
 
-			// Regex to match sync branches for THIS specific epic: sync/{any-version}-{epic-name}
-			// We use QuoteMeta to ensure the epic name is treated as a literal string
-			patternStr := fmt.Sprintf(`^sync/v\d+\.\d+\.\d+-%s$`, regexp.QuoteMeta(match.Epic))
-			syncBranchPattern, err := regexp.Compile(patternStr)
-			if err != nil {
-				l.Error("Error compiling regex for epic '%s': %v", match.Epic, err)
-				return fmt.Errorf("error compiling regex for epic '%s': %v", match.Epic, err)
-			}
-
 			for _, epicBranch := range match.BranchNames {
 				l.Info("Checking for old sync PRs targeting '%s' in repo '%s' for epic '%s'", epicBranch, repo, match.Epic)
 
@@ -280,7 +344,12 @@ func CleanupOldSyncBranches(ctx context.Context, l utils.LogInterface, githubRep
 
 				for _, pr := range prs {
 					headBranch := pr.Head.GetRef()
-					if syncBranchPattern.MatchString(headBranch) {
+					syncVersion, ok := syncBranchVersion(headBranch, match.Epic)
+					if ok && currentVersion.Less(syncVersion) {
+						l.Info("Keeping sync PR #%d from branch '%s' of a later release than %s", pr.GetNumber(), headBranch, releaseVersion)
+						continue
+					}
+					if ok {
 						l.Info("Found old sync PR #%d from branch '%s' targeting '%s'", pr.GetNumber(), headBranch, epicBranch)
 
 						// Close PR
@@ -302,3 +371,12 @@ func CleanupOldSyncBranches(ctx context.Context, l utils.LogInterface, githubRep
 	}
 	return nil
 }
+
+// syncBranchVersion returns the release version of a sync branch of the epic: sync/{release-version}-{epic-name}
+func syncBranchVersion(branch string, epic string) (utils.Version, bool) {
+	if !strings.HasPrefix(branch, "sync/") || !strings.HasSuffix(branch, "-"+epic) {
+		return utils.Version{}, false
+	}
+	version, err := utils.ParseVersion(strings.TrimSuffix(strings.TrimPrefix(branch, "sync/"), "-"+epic), true)
+	return version, err == nil
+}
@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"release-candidate/internal/usecases/githubrepo"
+	"testing"
+)
+
+func TestCleanupOldSyncBranches(t *testing.T) {
+	tests := []struct {
+		name       string
+		head       string
+		wantClosed bool
+	}{
+		{name: "earlier release", head: "sync/v1.0.0-epic-beta", wantClosed: true},
+		{name: "same release", head: "sync/v1.1.0-epic-beta", wantClosed: true},
+		{name: "later release", head: "sync/v1.2.0-epic-beta"},
+		{name: "other epic", head: "sync/v1.0.0-epic-gamma"},
+		{name: "not a sync branch", head: "feature/login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			fake.AddRepo("api").AddBranch("main", "a1", true).AddBranch("epic-beta", "b1", true).AddBranch(tt.head, "a0", false)
+			pr := fake.AddPullRequest("api", tt.head, "epic-beta")
+			matches := map[string][]EpicBranchMatch{
+				"api": {{Repo: "api", Epic: "epic-beta", BranchNames: []string{"epic-beta"}, Found: true}},
+			}
+
+			if err := CleanupOldSyncBranches(context.Background(), testLogger(), fake, "o", "v1.1.0", matches); err != nil {
+				t.Fatalf("CleanupOldSyncBranches() error = %v", err)
+			}
+			if closed := pr.State == "closed"; closed != tt.wantClosed {
+				t.Errorf("PR closed = %v, want %v", closed, tt.wantClosed)
+			}
+			if _, exists := fake.Repo("api").Branches[tt.head]; exists == tt.wantClosed {
+				t.Errorf("branch %s exists = %v, want %v", tt.head, exists, !tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestCleanupOldSyncBranchesCloseFailure(t *testing.T) {
+	fake := githubrepo.NewFakeGithubRepo()
+	fake.AddRepo("api").AddBranch("epic-beta", "b1", true).AddBranch("sync/v1.0.0-epic-beta", "a0", false)
+	fake.AddPullRequest("api", "sync/v1.0.0-epic-beta", "epic-beta")
+	fake.FailOn("ClosePullRequest", "api", errors.New("boom"))
+	matches := map[string][]EpicBranchMatch{
+		"api": {{Repo: "api", Epic: "epic-beta", BranchNames: []string{"epic-beta"}, Found: true}},
+	}
+
+	if err := CleanupOldSyncBranches(context.Background(), testLogger(), fake, "o", "v1.1.0", matches); err == nil {
+		t.Fatal("CleanupOldSyncBranches() error = nil, want the close failure")
+	}
+	if _, exists := fake.Repo("api").Branches["sync/v1.0.0-epic-beta"]; !exists {
+		t.Error("sync branch was deleted although its PR could not be closed")
+	}
+}
+
+func TestCreateSyncBranchesForEpics(t *testing.T) {
+	fake := githubrepo.NewFakeGithubRepo()
+	fake.AddRepo("api").AddBranch("main", "a1", true)
+	fake.AddRepo("web").AddBranch("main", "w1", true)
+	fake.AddRepo("docs").AddBranch("main", "d1", true)
+	fake.FailOn("CreateBranch", "web", errors.New("boom"))
+	matches := map[string][]EpicBranchMatch{
+		"web": {{Repo: "web", Epic: "epic-beta", BranchNames: []string{"epic-beta"}, Found: true}},
+		"api": {
+			{Repo: "api", Epic: "epic-beta", BranchNames: []string{"epic-beta", "epic-BETA"}, Found: true},
+			{Repo: "api", Epic: "epic-gamma"},
+		},
+		"docs": {{Repo: "docs", Epic: "epic-beta"}},
+	}
+
+	results, err := CreateSyncBranchesForEpics(context.Background(), testLogger(), fake, "o", "main", "v1.1.0", matches, 2)
+	if err == nil {
+		t.Fatal("CreateSyncBranchesForEpics() error = nil, want the web failure")
+	}
+
+	want := []SyncBranchResult{
+		{Repo: "api", Epic: "epic-beta", BranchName: "sync/v1.1.0-epic-beta", Created: true},
+		{Repo: "web", Epic: "epic-beta", BranchName: "sync/v1.1.0-epic-beta", Error: "boom"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Repo != w.Repo || got.Epic != w.Epic || got.BranchName != w.BranchName || got.Created != w.Created || got.Error != w.Error {
+			t.Errorf("results[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if branch := fake.Repo("api").Branches["sync/v1.1.0-epic-beta"]; branch == nil || branch.SHA != "a1" {
+		t.Errorf("api sync branch = %+v, want a branch of main at a1", branch)
+	}
+}
@@ -0,0 +1,55 @@
+package usecases
+
+import (
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/utils"
+	"time"
+)
+
+// checkReleaseFreeze fails the run when now is in a window of the freeze calendar, unless the freeze is overridden.
+// It runs before every production dispatch: Production-Release, the Hotfix release stage and Rollback.
+// It returns the Slack notice recording an override, or "" when nothing was overridden or there is no calendar.
+func checkReleaseFreeze(l utils.LogInterface, cfg *configs.Config, now time.Time) string {
+	if cfg.FreezeCalendar == nil {
+		return ""
+	}
+	window, until := cfg.FreezeCalendar.ActiveWindow(now)
+	if window == nil {
+		if cfg.FreezeOverride {
+			l.Info("No freeze window is active, the freeze override isn't needed")
+		}
+		return ""
+	}
+
+	untilText := "further notice"
+	if !until.IsZero() {
+		untilText = until.Format("Mon 2 Jan 2006 15:04 MST")
+	}
+	if cfg.FreezeOverride {
+		l.Warn("Release %s overrides freeze window %s (until %s): %s", cfg.RCVersion, window.Name, untilText, cfg.FreezeOverrideReason)
+		return fmt.Sprintf(":ice_cube: Released during freeze window *%s* (until %s). Override reason: %s", window.Name, untilText, cfg.FreezeOverrideReason)
+	}
+
+	slackPayload, err := utils.FreezeSlackPayloadBuilder(cfg.RCVersion, window.Name, untilText)
+	if err != nil {
+		l.Error("Error building freeze slack payload: %v", err)
+	} else {
+		setSlackPayloadOutput("slack_payload", slackPayload, cfg, l)
+	}
+	l.Fatal("Production releases are frozen by window %s until %s, nothing was dispatched. Set freeze_override with a freeze_override_reason to release anyway", window.Name, untilText)
+	return ""
+}
+
+// withFreezeNotice adds the notice of an overridden freeze to a Slack payload, leaving it as is without one
+func withFreezeNotice(l utils.LogInterface, slackPayload string, freezeNotice string) string {
+	if slackPayload == "" || freezeNotice == "" {
+		return slackPayload
+	}
+	noticedPayload, err := utils.WithSlackNotice(slackPayload, freezeNotice)
+	if err != nil {
+		l.Error("Error adding the freeze override to the slack payload: %v", err)
+		return slackPayload
+	}
+	return noticedPayload
+}
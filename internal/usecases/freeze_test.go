@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"release-candidate/internal/configs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckReleaseFreeze(t *testing.T) {
+	calendar, err := configs.ParseFreezeCalendar("windows:\n  - name: holidays\n    from: 12-24\n    to: 01-02\n")
+	if err != nil {
+		t.Fatalf("ParseFreezeCalendar() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		override bool
+		// noCalendar runs without a freeze calendar
+		noCalendar bool
+		// wantNotice is part of the override notice, "" when none is expected
+		wantNotice string
+	}{
+		{name: "no calendar", now: time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC), override: true, noCalendar: true},
+		{name: "outside the window", now: time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "override outside the window", now: time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC), override: true},
+		{
+			name:       "override in the window",
+			now:        time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC),
+			override:   true,
+			wantNotice: "*holidays* (until Fri 3 Jan 2025 00:00 UTC). Override reason: outage fix",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			if !tt.noCalendar {
+				cfg.FreezeCalendar = calendar
+			}
+			cfg.FreezeOverride = tt.override
+			cfg.FreezeOverrideReason = "outage fix"
+
+			notice := checkReleaseFreeze(testLogger(), cfg, tt.now)
+			if tt.wantNotice == "" {
+				if notice != "" {
+					t.Errorf("checkReleaseFreeze() = %q, want no notice", notice)
+				}
+				return
+			}
+			if !strings.Contains(notice, tt.wantNotice) {
+				t.Errorf("checkReleaseFreeze() = %q, want it to contain %q", notice, tt.wantNotice)
+			}
+		})
+	}
+}
+
+func TestWithFreezeNotice(t *testing.T) {
+	payload := `{"blocks":[{"type":"header"},{"type":"section"}]}`
+	if got := withFreezeNotice(testLogger(), payload, ""); got != payload {
+		t.Errorf("withFreezeNotice() without a notice = %s, want the payload unchanged", got)
+	}
+	if got := withFreezeNotice(testLogger(), "", "notice"); got != "" {
+		t.Errorf("withFreezeNotice() without a payload = %s, want none", got)
+	}
+	got := withFreezeNotice(testLogger(), payload, "Override reason: outage fix")
+	if header, notice := strings.Index(got, "header"), strings.Index(got, "outage fix"); header < 0 || notice < header {
+		t.Errorf("withFreezeNotice() = %s, want the notice below the header", got)
+	}
+}
@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+)
+
+// Statuses of GitHubReleaseResult
+const (
+	ReleaseStatusCreated = "created"
+	// ReleaseStatusExists marks repos whose release of the version was created by an earlier run
+	ReleaseStatusExists = "exists"
+	ReleaseStatusFailed = "failed"
+)
+
+// GitHubReleaseResult is the outcome of releasing the version in one repo
+type GitHubReleaseResult struct {
+	Repo        string `json:"repo"`
+	Tag         string `json:"tag"`
+	SHA         string `json:"sha,omitempty"`
+	PreviousTag string `json:"previous_tag,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// CreateGitHubReleases tags every repo with the release version and publishes a GitHub Release of the tag, with notes
+// generated from the pull requests merged since the previous release. shas pins the commit tagged per repo, e.g. to
+// the one dispatched to production; other repos are released at their existing tag or the head of the production branch.
+// It carries on past failures and returns the results in repoList order, the Slack payload, and an error listing the failures.
+func CreateGitHubReleases(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string, shas map[string]string) (results []GitHubReleaseResult, slackpayload string, err error) {
+	results = make([]GitHubReleaseResult, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		results[i] = createGitHubRelease(ctx, l, githubRepo, cfg, repoList[i], shas[repoList[i]])
+	})
+
+	var releaseItems []map[string]interface{}
+	var failures []string
+	for _, result := range results {
+		releaseItems = append(releaseItems, map[string]interface{}{
+			"repo":   result.Repo,
+			"url":    result.URL,
+			"status": result.Status,
+			"error":  result.Error,
+		})
+		if result.Status == ReleaseStatusFailed {
+			l.Error("Release %s failed in repo %s: %s", result.Tag, result.Repo, result.Error)
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Repo, result.Error))
+		}
+	}
+
+	slackpayload, err = utils.GitHubReleaseSlackPayloadBuilder(cfg.RCVersion, releaseItems, cfg.ReleaseDraft)
+	if err != nil {
+		l.Error("Error building release slack payload: %v", err)
+		return results, "", fmt.Errorf("error building slack payload: %v", err)
+	}
+
+	if len(failures) > 0 {
+		return results, slackpayload, fmt.Errorf("failed to create %d release(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	return results, slackpayload, nil
+}
+
+func createGitHubRelease(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string, sha string) GitHubReleaseResult {
+	tag := cfg.RCVersion
+	result := GitHubReleaseResult{Repo: repo, Tag: tag, SHA: sha, Status: ReleaseStatusFailed}
+
+	existing, err := githubRepo.GetReleaseByTag(ctx, cfg.Owner, repo, tag)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if existing != nil {
+		l.Info("Release %s already exists in repo %s: %s", tag, repo, existing.HTMLURL)
+		result.URL = existing.HTMLURL
+		result.Status = ReleaseStatusExists
+		return result
+	}
+
+	// Unless pinned, an existing tag (e.g. from production_ref tag) is released where it is
+	if result.SHA == "" {
+		if result.SHA, err = githubRepo.GetTagSHA(ctx, cfg.Owner, repo, tag); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+	if result.SHA == "" {
+		if result.SHA, err = githubRepo.GetBranchSHA(ctx, cfg.Owner, repo, cfg.ProductionBranch); err != nil {
+			result.Error = fmt.Sprintf("error resolving production branch: %v", err)
+			return result
+		}
+	}
+	if err := githubRepo.CreateTag(ctx, cfg.Owner, repo, tag, result.SHA); err != nil {
+		result.Error = fmt.Sprintf("error creating release tag: %v", err)
+		return result
+	}
+
+	previous, err := githubRepo.GetLatestRelease(ctx, cfg.Owner, repo)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if previous != nil {
+		result.PreviousTag = previous.TagName
+	}
+	notes, err := githubRepo.GenerateReleaseNotes(ctx, cfg.Owner, repo, tag, result.SHA, result.PreviousTag)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// A pre-release version such as v1.4.0-rc.1 is always released as a pre-release
+	version, err := utils.ParseVersion(tag, cfg.AllowCalVer)
+	prerelease := cfg.ReleasePrerelease || (err == nil && version.Prerelease != "")
+	release, err := githubRepo.CreateRelease(ctx, cfg.Owner, repo, tag, tag, notes, cfg.ReleaseDraft, prerelease)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	l.Info("Created release %s in repo %s: %s", tag, repo, release.HTMLURL)
+	result.URL = release.HTMLURL
+	result.Status = ReleaseStatusCreated
+	return result
+}
+
+// releaseSHAs returns the commits that were dispatched to production per repo
+func releaseSHAs(dispatchResults []WorkflowDispatchResult) map[string]string {
+	shas := make(map[string]string)
+	for _, result := range dispatchResults {
+		if result.SHA != "" {
+			shas[result.Repo] = result.SHA
+		}
+	}
+	return shas
+}
+
+// publishGitHubReleases creates the releases, sets the release outputs and fails the run if any release failed
+func publishGitHubReleases(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string, shas map[string]string) {
+	l.Info("Creating GitHub releases %s", cfg.RCVersion)
+	results, slackPayload, err := CreateGitHubReleases(ctx, l, githubRepo, cfg, repoList, shas)
+	if resultsJSON, jsonErr := json.Marshal(results); jsonErr != nil {
+		l.Error("Error marshalling release results: %v", jsonErr)
+	} else {
+		safeSetOutput("release_results", string(resultsJSON), cfg, l)
+	}
+	if slackPayload != "" {
+		setSlackPayloadOutput("release_slack_payload", slackPayload, cfg, l)
+	}
+	if err != nil {
+		l.Fatal("Error creating GitHub releases: %v", err)
+	}
+}
+
+// GitHubReleaseUseCase releases the version in every selected repo, at its existing tag or the head of the production branch
+func GitHubReleaseUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) {
+	l.Info("GitHub-Release use case")
+
+	repoList, err := githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
+	if err != nil {
+		l.Fatal("Error listing repositories: %v", err)
+	}
+	l.Info("repoList: %v", repoList)
+
+	publishGitHubReleases(ctx, l, githubRepo, cfg, repoList, nil)
+}
@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"release-candidate/internal/usecases/githubrepo"
+	"strings"
+	"testing"
+)
+
+func TestCreateGitHubReleases(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup adds the repos to release
+		setup   func(fake *githubrepo.FakeGithubRepo)
+		repos   []string
+		shas    map[string]string
+		draft   bool
+		want    []GitHubReleaseResult
+		wantErr bool
+	}{
+		{
+			name: "releases the head of the production branch",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true).AddTag("v1.0.0", "a1").AddRelease("v1.0.0", false).AddRelease("v1.1.0-rc.1", true)
+			},
+			repos: []string{"api"},
+			want:  []GitHubReleaseResult{{Repo: "api", Tag: "v1.1.0", SHA: "a2", PreviousTag: "v1.0.0", Status: ReleaseStatusCreated}},
+		},
+		{
+			name: "releases an existing tag where it is",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true).AddTag("v1.1.0", "a1")
+			},
+			repos: []string{"api"},
+			want:  []GitHubReleaseResult{{Repo: "api", Tag: "v1.1.0", SHA: "a1", Status: ReleaseStatusCreated}},
+		},
+		{
+			name: "releases the pinned commit",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true)
+			},
+			repos: []string{"api"},
+			shas:  map[string]string{"api": "a1"},
+			draft: true,
+			want:  []GitHubReleaseResult{{Repo: "api", Tag: "v1.1.0", SHA: "a1", Status: ReleaseStatusCreated}},
+		},
+		{
+			name: "leaves an existing release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true).AddTag("v1.1.0", "a2").AddRelease("v1.1.0", false)
+			},
+			repos: []string{"api"},
+			want:  []GitHubReleaseResult{{Repo: "api", Tag: "v1.1.0", Status: ReleaseStatusExists}},
+		},
+		{
+			name: "carries on past failures",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true)
+				fake.AddRepo("moved").AddBranch("main", "m2", true).AddTag("v1.1.0", "m1")
+				fake.AddRepo("web").AddBranch("main", "w2", true)
+				fake.FailOn("CreateRelease", "api", errors.New("boom"))
+			},
+			repos: []string{"api", "moved", "web"},
+			shas:  map[string]string{"moved": "m2"},
+			want: []GitHubReleaseResult{
+				{Repo: "api", Tag: "v1.1.0", SHA: "a2", Status: ReleaseStatusFailed},
+				{Repo: "moved", Tag: "v1.1.0", SHA: "m2", Status: ReleaseStatusFailed},
+				{Repo: "web", Tag: "v1.1.0", SHA: "w2", Status: ReleaseStatusCreated},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			tt.setup(fake)
+			cfg := testConfig()
+			cfg.ReleaseDraft = tt.draft
+
+			results, payload, err := CreateGitHubReleases(context.Background(), testLogger(), fake, cfg, tt.repos, tt.shas)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateGitHubReleases() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if payload == "" {
+				t.Error("CreateGitHubReleases() returned no Slack payload")
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("got %d results, want %d: %+v", len(results), len(tt.want), results)
+			}
+			for i, want := range tt.want {
+				got := results[i]
+				if got.Repo != want.Repo || got.Tag != want.Tag || got.SHA != want.SHA || got.PreviousTag != want.PreviousTag || got.Status != want.Status {
+					t.Errorf("results[%d] = %+v, want %+v", i, got, want)
+				}
+				if (got.Status == ReleaseStatusFailed) != (got.Error != "") {
+					t.Errorf("results[%d] status %s with error %q", i, got.Status, got.Error)
+				}
+				if got.Status == ReleaseStatusCreated {
+					releases := fake.Repo(got.Repo).Releases
+					release := releases[len(releases)-1]
+					if release.Tag != "v1.1.0" || release.Draft != tt.draft || !strings.Contains(release.Body, "Full Changelog") || got.URL != release.URL {
+						t.Errorf("release of %s = %+v, want v1.1.0 with the generated notes, draft %v", got.Repo, release, tt.draft)
+					}
+					if sha := fake.Repo(got.Repo).Tags["v1.1.0"]; sha != got.SHA {
+						t.Errorf("tag v1.1.0 of %s is at %q, want %s", got.Repo, sha, got.SHA)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseSHAs(t *testing.T) {
+	results := []WorkflowDispatchResult{
+		{Repo: "api", SHA: "a1", Status: DispatchStatusDispatched},
+		{Repo: "docs", Status: DispatchStatusSkippedNoWorkflow},
+	}
+	shas := releaseSHAs(results)
+	if len(shas) != 1 || shas["api"] != "a1" {
+		t.Errorf("releaseSHAs() = %v, want only api at a1", shas)
+	}
+}
@@ -0,0 +1,65 @@
+package githubrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// PlannedAction is a mutating GitHub call that was recorded instead of executed in dry-run mode
+type PlannedAction struct {
+	Action  string `json:"action"`
+	Repo    string `json:"repo"`
+	Target  string `json:"target"`
+	Details string `json:"details,omitempty"`
+}
+
+// DryRunPlan collects the actions a run would have performed
+type DryRunPlan struct {
+	mu      sync.Mutex
+	actions []PlannedAction
+}
+
+func NewDryRunPlan() *DryRunPlan {
+	return &DryRunPlan{}
+}
+
+func (p *DryRunPlan) Record(action PlannedAction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.actions = append(p.actions, action)
+}
+
+// Actions returns a copy of the recorded actions grouped by repo. Repos are processed
+// concurrently, so only the order of actions within a repo is meaningful.
+func (p *DryRunPlan) Actions() []PlannedAction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	actions := make([]PlannedAction, len(p.actions))
+	copy(actions, p.actions)
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Repo < actions[j].Repo })
+	return actions
+}
+
+// Table renders the plan as an aligned plain-text table
+func (p *DryRunPlan) Table() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tACTION\tREPO\tTARGET\tDETAILS")
+	for i, action := range p.Actions() {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, action.Action, action.Repo, action.Target, action.Details)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+func (p *DryRunPlan) JSON() (string, error) {
+	planJSON, err := json.Marshal(p.Actions())
+	if err != nil {
+		return "", fmt.Errorf("error marshalling dry-run plan: %v", err)
+	}
+	return string(planJSON), nil
+}
@@ -0,0 +1,99 @@
+package githubrepo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"release-candidate/internal/utils"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestDryRunPlan(t *testing.T) {
+	plan := NewDryRunPlan()
+	plan.Record(PlannedAction{Action: "create-branch", Repo: "api", Target: "rc/v1.1.0", Details: "from development@a1"})
+	plan.Record(PlannedAction{Action: "delete-branch", Repo: "web", Target: "sync/v1.0.0-epic-beta"})
+
+	actions := plan.Actions()
+	actions[0].Repo = "changed"
+	if plan.Actions()[0].Repo != "api" {
+		t.Error("Actions() returned the recorded actions instead of a copy")
+	}
+
+	table := plan.Table()
+	for _, want := range []string{"#  ACTION", "1  create-branch  api   rc/v1.1.0", "2  delete-branch  web   sync/v1.0.0-epic-beta"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Table() = %q, want it to contain %q", table, want)
+		}
+	}
+
+	planJSON, err := plan.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var decoded []PlannedAction
+	if err := json.Unmarshal([]byte(planJSON), &decoded); err != nil {
+		t.Fatalf("JSON() = %s is not a list of actions: %v", planJSON, err)
+	}
+	if !reflect.DeepEqual(decoded, plan.Actions()) {
+		t.Errorf("JSON() = %+v, want %+v", decoded, plan.Actions())
+	}
+}
+
+// TestDryRunRecordsMutations serves only reads, so any mutating call that reaches the API fails the test
+func TestDryRunRecordsMutations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("dry run called %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Path == "/repos/o/api/git/ref/heads/development" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ref": "refs/heads/development", "object": map[string]string{"sha": "a1"}})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	plan := NewDryRunPlan()
+	githubRepo := NewGithubRepo(client, utils.NewLogger("error")).WithDryRun(plan)
+	ctx := context.Background()
+	if !githubRepo.DryRun() {
+		t.Fatal("DryRun() = false after WithDryRun")
+	}
+
+	if err := githubRepo.CreateBranch(ctx, "o", "api", "development", "rc/v1.1.0"); err != nil {
+		t.Errorf("CreateBranch() error = %v", err)
+	}
+	if _, _, _, err := githubRepo.CreatePullRequest(ctx, "o", "api", "rc/v1.1.0", "main", "Release v1.1.0", ""); err != nil {
+		t.Errorf("CreatePullRequest() error = %v", err)
+	}
+	if err := githubRepo.CreateWorkflowDispatchEventByID(ctx, "o", "api", "main", 7, map[string]interface{}{"environment": "production"}); err != nil {
+		t.Errorf("CreateWorkflowDispatchEventByID() error = %v", err)
+	}
+	if err := githubRepo.DeleteBranch(ctx, "o", "api", "sync/v1.0.0-epic-beta"); err != nil {
+		t.Errorf("DeleteBranch() error = %v", err)
+	}
+	if err := githubRepo.ClosePullRequest(ctx, "o", "api", 3, "Superseded"); err != nil {
+		t.Errorf("ClosePullRequest() error = %v", err)
+	}
+
+	want := []PlannedAction{
+		{Action: "create-branch", Repo: "api", Target: "rc/v1.1.0", Details: "from development@a1"},
+		{Action: "create-pull-request", Repo: "api", Target: "rc/v1.1.0 -> main", Details: "Release v1.1.0"},
+		{Action: "dispatch-workflow", Repo: "api", Target: "workflow 7 @ refs/heads/main", Details: `{"environment":"production"}`},
+		{Action: "delete-branch", Repo: "api", Target: "sync/v1.0.0-epic-beta"},
+		{Action: "close-pull-request", Repo: "api", Target: "#3", Details: "Superseded"},
+	}
+	if got := plan.Actions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded actions = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,890 @@
+package githubrepo
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+var _ GitHubWebApis = (*FakeGithubRepo)(nil)
+
+// FakeGithubRepo is an in-memory GitHubWebApis implementation for unit tests.
+// It models repositories, branches, pull requests, workflows and PR mergeability
+// closely enough for the use cases to exercise their real control flow.
+type FakeGithubRepo struct {
+	mu           sync.Mutex
+	repos        map[string]*FakeRepo
+	failures     map[string]error
+	nextPRNumber int
+	nextRunID    int64
+
+	// Dispatches records every workflow dispatch in call order
+	Dispatches []FakeWorkflowDispatch
+	// RepositoryDispatches records every repository_dispatch event in call order
+	RepositoryDispatches []FakeRepositoryDispatch
+}
+
+// FakeRepo is a repository held by FakeGithubRepo
+type FakeRepo struct {
+	Name             string
+	Archived         bool
+	Topics           []string
+	CustomProperties map[string]interface{}
+	Branches         map[string]*FakeBranch
+	// Tags maps tag names to the commit they point at
+	Tags         map[string]string
+	PullRequests []*FakePullRequest
+	Workflows    []RespWorkflow
+	// Conflicts marks head->base pairs whose PRs are reported as not mergeable
+	Conflicts map[string]bool
+	// Runs holds the workflow runs started by dispatches
+	Runs []*RespWorkflowRun
+	// RunConclusions sets the conclusion of runs per workflow ID; unset workflows succeed
+	// and an empty conclusion leaves runs in progress
+	RunConclusions map[int64]string
+	// Files maps file paths to their content on every ref
+	Files map[string]string
+	// Releases are in creation order
+	Releases []*FakeRelease
+	// Commits is the history every branch and tag points into, oldest first
+	Commits []RespCommit
+	// Checks maps commit SHAs to their check runs and commit statuses
+	Checks map[string][]RespCheck
+}
+
+// FakeWorkflowFile is the content AddWorkflow gives workflows: a workflow_dispatch trigger
+// declaring the environment and release_version inputs sent by default
+const FakeWorkflowFile = `on:
+  workflow_dispatch:
+    inputs:
+      environment:
+        required: true
+      release_version:
+        required: true
+`
+
+type FakeBranch struct {
+	Name      string
+	SHA       string
+	Protected bool
+}
+
+type FakePullRequest struct {
+	Number   int
+	Head     string
+	Base     string
+	Title    string
+	Body     string
+	State    string
+	URL      string
+	Comments []string
+	// MergeCommit is the commit merging the pull request created
+	Merged      bool
+	MergeCommit string
+	Labels      []string
+	Author      string
+	Reviews     []RespReview
+}
+
+type FakeRelease struct {
+	Tag        string
+	Name       string
+	Body       string
+	Draft      bool
+	Prerelease bool
+	URL        string
+}
+
+type FakeWorkflowDispatch struct {
+	Repo       string
+	Ref        string
+	WorkflowID int64
+	Inputs     map[string]interface{}
+}
+
+type FakeRepositoryDispatch struct {
+	Repo          string
+	EventType     string
+	ClientPayload map[string]interface{}
+}
+
+func NewFakeGithubRepo() *FakeGithubRepo {
+	return &FakeGithubRepo{
+		repos:        make(map[string]*FakeRepo),
+		failures:     make(map[string]error),
+		nextPRNumber: 1,
+		nextRunID:    1001,
+	}
+}
+
+// AddRepo adds an empty repository and returns it for further setup
+func (f *FakeGithubRepo) AddRepo(name string) *FakeRepo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	repo := &FakeRepo{
+		Name:           name,
+		Branches:       make(map[string]*FakeBranch),
+		Tags:           make(map[string]string),
+		Conflicts:      make(map[string]bool),
+		RunConclusions: make(map[int64]string),
+		Files:          make(map[string]string),
+	}
+	f.repos[name] = repo
+	return repo
+}
+
+// Repo returns the repository with the given name, or nil if it does not exist
+func (f *FakeGithubRepo) Repo(name string) *FakeRepo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.repos[name]
+}
+
+// FailOn makes every call of method (e.g. "CreateBranch") on repo return err.
+// An empty repo fails the method for all repositories.
+func (f *FakeGithubRepo) FailOn(method string, repo string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[method+"/"+repo] = err
+}
+
+func (f *FakeGithubRepo) failure(method string, repo string) error {
+	if err, ok := f.failures[method+"/"+repo]; ok {
+		return err
+	}
+	return f.failures[method+"/"]
+}
+
+func (f *FakeGithubRepo) repo(name string) (*FakeRepo, error) {
+	repo, ok := f.repos[name]
+	if !ok {
+		return nil, fmt.Errorf("repository %s not found", name)
+	}
+	return repo, nil
+}
+
+// AddBranch adds or replaces a branch pointing at sha
+func (r *FakeRepo) AddBranch(name string, sha string, protected bool) *FakeRepo {
+	r.Branches[name] = &FakeBranch{Name: name, SHA: sha, Protected: protected}
+	return r
+}
+
+// AddPullRequest adds an open pull request from head into base
+func (f *FakeGithubRepo) AddPullRequest(repoName string, head string, base string) *FakePullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addPullRequest(f.repos[repoName], head, base, "", "")
+}
+
+func (f *FakeGithubRepo) addPullRequest(repo *FakeRepo, head string, base string, title string, body string) *FakePullRequest {
+	pr := &FakePullRequest{
+		Number: f.nextPRNumber,
+		Head:   head,
+		Base:   base,
+		Title:  title,
+		Body:   body,
+		State:  "open",
+		URL:    fmt.Sprintf("https://github.com/fake/%s/pull/%d", repo.Name, f.nextPRNumber),
+	}
+	f.nextPRNumber++
+	repo.PullRequests = append(repo.PullRequests, pr)
+	return pr
+}
+
+// AddWorkflow adds a workflow definition at path (e.g. .github/workflows/prod-release.yml).
+// Its file is FakeWorkflowFile unless a file was added at path already.
+func (r *FakeRepo) AddWorkflow(id int64, name string, path string) *FakeRepo {
+	r.Workflows = append(r.Workflows, RespWorkflow{ID: id, Name: name, Path: path, Repo: r.Name})
+	if _, ok := r.Files[path]; !ok {
+		r.Files[path] = FakeWorkflowFile
+	}
+	return r
+}
+
+// AddFile adds or replaces a file
+func (r *FakeRepo) AddFile(path string, content string) *FakeRepo {
+	r.Files[path] = content
+	return r
+}
+
+// AddTag adds or moves a tag to sha
+func (r *FakeRepo) AddTag(name string, sha string) *FakeRepo {
+	r.Tags[name] = sha
+	return r
+}
+
+// AddCommit appends a commit to the history, as a child of the last one
+func (r *FakeRepo) AddCommit(sha string, message string, author string) *FakeRepo {
+	var parents []string
+	if len(r.Commits) > 0 {
+		parents = []string{r.Commits[len(r.Commits)-1].SHA}
+	}
+	return r.addCommit(sha, message, author, parents)
+}
+
+// AddMergeCommit appends a merge commit of its parents to the history
+func (r *FakeRepo) AddMergeCommit(sha string, message string, author string, parents ...string) *FakeRepo {
+	return r.addCommit(sha, message, author, parents)
+}
+
+func (r *FakeRepo) addCommit(sha string, message string, author string, parents []string) *FakeRepo {
+	r.Commits = append(r.Commits, RespCommit{SHA: sha, Message: message, Author: author, HTMLURL: fmt.Sprintf("https://github.com/fake/%s/commit/%s", r.Name, sha), Parents: parents})
+	return r
+}
+
+// AddRelease adds a published release of tag
+func (r *FakeRepo) AddRelease(tag string, prerelease bool) *FakeRepo {
+	r.Releases = append(r.Releases, &FakeRelease{Tag: tag, Name: tag, Prerelease: prerelease, URL: fmt.Sprintf("https://github.com/fake/%s/releases/tag/%s", r.Name, tag)})
+	return r
+}
+
+// SetConflicts marks PRs from head into base as having merge conflicts
+func (r *FakeRepo) SetConflicts(head string, base string, conflicts bool) *FakeRepo {
+	r.Conflicts[head+"->"+base] = conflicts
+	return r
+}
+
+func (f *FakeGithubRepo) CreateBranch(ctx context.Context, owner string, repo string, baseBranch string, newBranch string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("CreateBranch", repo); err != nil {
+		return err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return fmt.Errorf("error getting ref: %v", err)
+	}
+	base, ok := r.Branches[baseBranch]
+	if !ok {
+		return fmt.Errorf("error getting ref: branch %s not found in %s", baseBranch, repo)
+	}
+	if _, exists := r.Branches[newBranch]; !exists {
+		r.AddBranch(newBranch, base.SHA, false)
+	}
+	return nil
+}
+
+func (f *FakeGithubRepo) CreatePullRequest(ctx context.Context, owner string, repo string, fromBranch string, toBranch string, title string, body string) (prUrl string, prError string, hasConflicts bool, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("CreatePullRequest", repo); err != nil {
+		return "", "", false, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return "", "", false, err
+	}
+	head, headOK := r.Branches[fromBranch]
+	base, baseOK := r.Branches[toBranch]
+	if !headOK || !baseOK {
+		return "", "", false, fmt.Errorf("error 422 Unprocessable Entity creating PR: branch not found")
+	}
+	for _, pr := range r.PullRequests {
+		if pr.State == "open" && pr.Head == fromBranch && pr.Base == toBranch {
+			return pr.URL, fmt.Sprintf("A pull request already exists for %s:%s.", owner, fromBranch), r.Conflicts[fromBranch+"->"+toBranch], nil
+		}
+	}
+	if head.SHA == base.SHA {
+		return "", fmt.Sprintf("No commits between %s and %s", toBranch, fromBranch), false, nil
+	}
+	pr := f.addPullRequest(r, fromBranch, toBranch, title, body)
+	return pr.URL, "", r.Conflicts[fromBranch+"->"+toBranch], nil
+}
+
+func (f *FakeGithubRepo) ListRepositories(ctx context.Context, owner string, query RepositoryQuery) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListRepositories", ""); err != nil {
+		return nil, err
+	}
+	selection, err := ParseRepositorySelection(query)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing repository filters: %v", err)
+	}
+
+	var names []string
+	if !selection.Include.Empty() && selection.Include.ExactOnly() {
+		for _, name := range selection.Include.ExactNames() {
+			if _, err := f.repo(name); err != nil {
+				return nil, fmt.Errorf("error getting repository: %v", err)
+			}
+			names = append(names, name)
+		}
+	} else {
+		for name := range f.repos {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	repos := make([]*github.Repository, 0, len(names))
+	for _, name := range names {
+		repos = append(repos, &github.Repository{
+			Name:             github.String(name),
+			Archived:         github.Bool(f.repos[name].Archived),
+			Topics:           f.repos[name].Topics,
+			CustomProperties: f.repos[name].CustomProperties,
+		})
+	}
+	repoList, _ := filterRepositories(repos, query.UseCase, selection)
+	return repoList, nil
+}
+
+func (f *FakeGithubRepo) CreateRepositoryDispatches(ctx context.Context, owner string, repo string, eventType string, clientPayload map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("CreateRepositoryDispatches", repo); err != nil {
+		return err
+	}
+	if _, err := f.repo(repo); err != nil {
+		return fmt.Errorf("error dispatching event: %v", err)
+	}
+	f.RepositoryDispatches = append(f.RepositoryDispatches, FakeRepositoryDispatch{Repo: repo, EventType: eventType, ClientPayload: clientPayload})
+	return nil
+}
+
+func (f *FakeGithubRepo) ListWorkFlowsByRepoFileFilter(ctx context.Context, owner string, repo string, fileFilterRegex string) ([]RespWorkflow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListWorkFlowsByRepoFileFilter", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing workflows: %v", err)
+	}
+	re, err := regexp.Compile(fileFilterRegex)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling regex: %v", err)
+	}
+	filteredWorkflows := make([]RespWorkflow, 0)
+	for _, workflow := range r.Workflows {
+		if re.MatchString(workflow.Path) {
+			filteredWorkflows = append(filteredWorkflows, workflow)
+		}
+	}
+	return filteredWorkflows, nil
+}
+
+func (f *FakeGithubRepo) CreateWorkflowDispatchEventByID(ctx context.Context, owner string, repo string, ref string, workflowID int64, clientPayload map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("CreateWorkflowDispatchEventByID", repo); err != nil {
+		return err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return fmt.Errorf("error dispatching event: %v", err)
+	}
+	found := false
+	for _, workflow := range r.Workflows {
+		if workflow.ID == workflowID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("error dispatching event: workflow %d not found in %s", workflowID, repo)
+	}
+	// Like GitHub, refs/tags/ refs run on the tag and anything else on a branch
+	name := ShortRefName(ref)
+	sha, ok := r.Tags[name]
+	if !strings.HasPrefix(ref, "refs/tags/") {
+		var branch *FakeBranch
+		if branch, ok = r.Branches[name]; ok {
+			sha = branch.SHA
+		}
+	}
+	if !ok {
+		return fmt.Errorf("error dispatching event: no ref found for %s in %s", ref, repo)
+	}
+	f.Dispatches = append(f.Dispatches, FakeWorkflowDispatch{Repo: repo, Ref: ref, WorkflowID: workflowID, Inputs: clientPayload})
+
+	run := &RespWorkflowRun{
+		ID:         f.nextRunID,
+		WorkflowID: workflowID,
+		Status:     "completed",
+		Conclusion: "success",
+		HeadBranch: name,
+		HeadSHA:    sha,
+		HTMLURL:    fmt.Sprintf("https://github.com/fake/%s/actions/runs/%d", repo, f.nextRunID),
+		CreatedAt:  time.Now(),
+	}
+	if conclusion, ok := r.RunConclusions[workflowID]; ok {
+		run.Conclusion = conclusion
+		if conclusion == "" {
+			run.Status = "in_progress"
+		}
+	}
+	r.Runs = append(r.Runs, run)
+	f.nextRunID++
+	return nil
+}
+
+func (f *FakeGithubRepo) ListEpicBranches(ctx context.Context, owner string, repo string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListEpicBranches", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing branches for %s: %v", repo, err)
+	}
+	var epicBranches []string
+	for name, branch := range r.Branches {
+		if branch.Protected && strings.HasPrefix(strings.ToLower(name), "epic-") {
+			epicBranches = append(epicBranches, name)
+		}
+	}
+	sort.Strings(epicBranches)
+	return epicBranches, nil
+}
+
+func (f *FakeGithubRepo) DeleteBranch(ctx context.Context, owner string, repo string, branchName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("DeleteBranch", repo); err != nil {
+		return err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return fmt.Errorf("error deleting branch %s in repo %s: %v", branchName, repo, err)
+	}
+	// Deleting a missing branch is a no-op, like GithubRepo.DeleteBranch
+	delete(r.Branches, branchName)
+	return nil
+}
+
+func (f *FakeGithubRepo) ClosePullRequest(ctx context.Context, owner string, repo string, prNumber int, comment string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ClosePullRequest", repo); err != nil {
+		return err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return fmt.Errorf("error closing PR %d in repo %s: %v", prNumber, repo, err)
+	}
+	for _, pr := range r.PullRequests {
+		if pr.Number == prNumber {
+			if comment != "" {
+				pr.Comments = append(pr.Comments, comment)
+			}
+			pr.State = "closed"
+			return nil
+		}
+	}
+	return fmt.Errorf("error closing PR %d in repo %s: not found", prNumber, repo)
+}
+
+func (f *FakeGithubRepo) ListOpenPullRequestsByBase(ctx context.Context, owner string, repo string, baseBranch string) ([]*github.PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListOpenPullRequestsByBase", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing PRs: %v", err)
+	}
+	var prs []*github.PullRequest
+	for _, pr := range r.PullRequests {
+		if pr.State != "open" || pr.Base != baseBranch {
+			continue
+		}
+		prs = append(prs, &github.PullRequest{
+			Number:  github.Int(pr.Number),
+			State:   github.String(pr.State),
+			HTMLURL: github.String(pr.URL),
+			Title:   github.String(pr.Title),
+			Head:    &github.PullRequestBranch{Ref: github.String(pr.Head)},
+			Base:    &github.PullRequestBranch{Ref: github.String(pr.Base)},
+		})
+	}
+	return prs, nil
+}
+
+func (f *FakeGithubRepo) ListWorkflowDispatchRuns(ctx context.Context, owner string, repo string, workflowID int64, branch string, createdAfter time.Time) ([]RespWorkflowRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListWorkflowDispatchRuns", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing workflow runs: %v", err)
+	}
+	var runs []RespWorkflowRun
+	for _, run := range r.Runs {
+		if run.WorkflowID == workflowID && run.HeadBranch == branch && !run.CreatedAt.Before(createdAfter) {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+func (f *FakeGithubRepo) GetWorkflowRun(ctx context.Context, owner string, repo string, runID int64) (RespWorkflowRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GetWorkflowRun", repo); err != nil {
+		return RespWorkflowRun{}, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return RespWorkflowRun{}, fmt.Errorf("error getting workflow run: %v", err)
+	}
+	for _, run := range r.Runs {
+		if run.ID == runID {
+			return *run, nil
+		}
+	}
+	return RespWorkflowRun{}, fmt.Errorf("error getting workflow run: run %d not found", runID)
+}
+
+func (f *FakeGithubRepo) GetFileContent(ctx context.Context, owner string, repo string, path string, ref string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GetFileContent", repo); err != nil {
+		return "", err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return "", fmt.Errorf("error getting file %s: %v", path, err)
+	}
+	content, ok := r.Files[path]
+	if !ok {
+		return "", fmt.Errorf("error getting file %s: 404 Not Found", path)
+	}
+	return content, nil
+}
+
+func (f *FakeGithubRepo) GetBranchSHA(ctx context.Context, owner string, repo string, branch string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GetBranchSHA", repo); err != nil {
+		return "", err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return "", fmt.Errorf("error getting ref of branch %s: %v", branch, err)
+	}
+	b, ok := r.Branches[branch]
+	if !ok {
+		return "", fmt.Errorf("error getting ref of branch %s: %w", branch, ErrBranchNotFound)
+	}
+	return b.SHA, nil
+}
+
+func (f *FakeGithubRepo) GetTagSHA(ctx context.Context, owner string, repo string, tag string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GetTagSHA", repo); err != nil {
+		return "", err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return "", fmt.Errorf("error getting ref of tag %s: %v", tag, err)
+	}
+	return r.Tags[tag], nil
+}
+
+func (f *FakeGithubRepo) CreateTag(ctx context.Context, owner string, repo string, tag string, sha string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("CreateTag", repo); err != nil {
+		return err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return fmt.Errorf("error creating tag %s on %s: %v", tag, repo, err)
+	}
+	if existing, ok := r.Tags[tag]; ok {
+		if existing != sha {
+			return fmt.Errorf("tag %s already exists at %s, not %s", tag, existing, sha)
+		}
+		return nil
+	}
+	r.Tags[tag] = sha
+	return nil
+}
+
+func (r *FakeRelease) resp() *RespRelease {
+	return &RespRelease{TagName: r.Tag, Name: r.Name, HTMLURL: r.URL, Draft: r.Draft, Prerelease: r.Prerelease}
+}
+
+func (f *FakeGithubRepo) GetReleaseByTag(ctx context.Context, owner string, repo string, tag string) (*RespRelease, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GetReleaseByTag", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error getting release %s: %v", tag, err)
+	}
+	for _, release := range r.Releases {
+		if release.Tag == tag {
+			return release.resp(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *FakeGithubRepo) GetLatestRelease(ctx context.Context, owner string, repo string) (*RespRelease, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GetLatestRelease", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error getting latest release: %v", err)
+	}
+	for i := len(r.Releases) - 1; i >= 0; i-- {
+		if !r.Releases[i].Draft && !r.Releases[i].Prerelease {
+			return r.Releases[i].resp(), nil
+		}
+	}
+	return nil, nil
+}
+
+// GenerateReleaseNotes returns the Full Changelog line GitHub ends its notes with
+func (f *FakeGithubRepo) GenerateReleaseNotes(ctx context.Context, owner string, repo string, tag string, target string, previousTag string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GenerateReleaseNotes", repo); err != nil {
+		return "", err
+	}
+	if _, err := f.repo(repo); err != nil {
+		return "", fmt.Errorf("error generating release notes: %v", err)
+	}
+	if previousTag == "" {
+		return fmt.Sprintf("**Full Changelog**: https://github.com/fake/%s/commits/%s", repo, tag), nil
+	}
+	return fmt.Sprintf("**Full Changelog**: https://github.com/fake/%s/compare/%s...%s", repo, previousTag, tag), nil
+}
+
+func (f *FakeGithubRepo) CreateRelease(ctx context.Context, owner string, repo string, tag string, name string, body string, draft bool, prerelease bool) (RespRelease, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("CreateRelease", repo); err != nil {
+		return RespRelease{}, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return RespRelease{}, fmt.Errorf("error creating release %s: %v", tag, err)
+	}
+	for _, release := range r.Releases {
+		if release.Tag == tag {
+			return RespRelease{}, fmt.Errorf("error creating release %s: 422 Validation Failed: already_exists", tag)
+		}
+	}
+	release := &FakeRelease{Tag: tag, Name: name, Body: body, Draft: draft, Prerelease: prerelease, URL: fmt.Sprintf("https://github.com/fake/%s/releases/tag/%s", repo, tag)}
+	r.Releases = append(r.Releases, release)
+	return *release.resp(), nil
+}
+
+func (f *FakeGithubRepo) ListReleases(ctx context.Context, owner string, repo string) ([]RespRelease, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListReleases", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing releases: %v", err)
+	}
+	var releases []RespRelease
+	for i := len(r.Releases) - 1; i >= 0; i-- {
+		releases = append(releases, *r.Releases[i].resp())
+	}
+	return releases, nil
+}
+
+// commitIndex resolves a branch, tag or SHA to its position in the history
+func (r *FakeRepo) commitIndex(ref string) int {
+	sha := ref
+	if branch, ok := r.Branches[ref]; ok {
+		sha = branch.SHA
+	} else if tagSHA, ok := r.Tags[ref]; ok {
+		sha = tagSHA
+	}
+	for i, commit := range r.Commits {
+		if commit.SHA == sha {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *FakeGithubRepo) CompareCommits(ctx context.Context, owner string, repo string, base string, head string) (RespComparison, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("CompareCommits", repo); err != nil {
+		return RespComparison{}, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return RespComparison{}, fmt.Errorf("error comparing %s...%s: %v", base, head, err)
+	}
+	from, to := r.commitIndex(base), r.commitIndex(head)
+	if from < 0 || to < 0 {
+		return RespComparison{}, fmt.Errorf("error comparing %s...%s: 404 Not Found", base, head)
+	}
+	comparison := RespComparison{HTMLURL: fmt.Sprintf("https://github.com/fake/%s/compare/%s...%s", repo, base, head)}
+	if to > from {
+		comparison.Commits = append(comparison.Commits, r.Commits[from+1:to+1]...)
+	}
+	return comparison, nil
+}
+
+// ListMergedPullRequests returns the merged pull requests into base, most recent first. mergedSince is ignored.
+func (f *FakeGithubRepo) ListMergedPullRequests(ctx context.Context, owner string, repo string, base string, mergedSince time.Time) ([]RespPullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListMergedPullRequests", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pull requests merged into %s: %v", base, err)
+	}
+	var pulls []RespPullRequest
+	for i := len(r.PullRequests) - 1; i >= 0; i-- {
+		if pr := r.PullRequests[i]; pr.Merged && pr.Base == base {
+			pulls = append(pulls, pr.resp())
+		}
+	}
+	return pulls, nil
+}
+
+func (f *FakeGithubRepo) ListMergedPullRequestsByHead(ctx context.Context, owner string, repo string, head string, base string) ([]RespPullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListMergedPullRequestsByHead", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing PRs from %s to %s: %v", head, base, err)
+	}
+	var pulls []RespPullRequest
+	for i := len(r.PullRequests) - 1; i >= 0; i-- {
+		if pr := r.PullRequests[i]; pr.Merged && pr.Head == head && pr.Base == base {
+			pulls = append(pulls, pr.resp())
+		}
+	}
+	return pulls, nil
+}
+
+func (pr *FakePullRequest) resp() RespPullRequest {
+	return RespPullRequest{Number: pr.Number, Title: pr.Title, Author: pr.Author, Labels: pr.Labels, HTMLURL: pr.URL, MergeCommitSHA: pr.MergeCommit, Head: pr.Head, Base: pr.Base}
+}
+
+func (f *FakeGithubRepo) ListPullRequestReviews(ctx context.Context, owner string, repo string, number int) ([]RespReview, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListPullRequestReviews", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error listing reviews of PR %d: %v", number, err)
+	}
+	for _, pr := range r.PullRequests {
+		if pr.Number == number {
+			return append([]RespReview(nil), pr.Reviews...), nil
+		}
+	}
+	return nil, fmt.Errorf("error listing reviews of PR %d: 404 Not Found", number)
+}
+
+func (f *FakeGithubRepo) ListCommitChecks(ctx context.Context, owner string, repo string, ref string) ([]RespCheck, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("ListCommitChecks", repo); err != nil {
+		return nil, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the status of %s: %v", ref, err)
+	}
+	sha := ref
+	if branch, ok := r.Branches[ref]; ok {
+		sha = branch.SHA
+	} else if tagSHA, ok := r.Tags[ref]; ok {
+		sha = tagSHA
+	}
+	return append([]RespCheck(nil), r.Checks[sha]...), nil
+}
+
+// fileSHA is the git blob SHA of content, which changes with every version of a file
+func fileSHA(content string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(content), content))))
+}
+
+func (f *FakeGithubRepo) GetFile(ctx context.Context, owner string, repo string, path string, branch string) (RespFile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("GetFile", repo); err != nil {
+		return RespFile{}, err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return RespFile{}, fmt.Errorf("error getting file %s: %v", path, err)
+	}
+	content, ok := r.Files[path]
+	if !ok {
+		return RespFile{}, nil
+	}
+	return RespFile{Content: content, SHA: fileSHA(content)}, nil
+}
+
+func (f *FakeGithubRepo) PutFile(ctx context.Context, owner string, repo string, path string, branch string, message string, content string, sha string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("PutFile", repo); err != nil {
+		return "", err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return "", fmt.Errorf("error committing %s: %v", path, err)
+	}
+	current, ok := r.Files[path]
+	if (sha == "" && ok) || (sha != "" && (!ok || fileSHA(current) != sha)) {
+		return "", fmt.Errorf("error committing %s: %w", path, ErrFileChanged)
+	}
+	if r.Files == nil {
+		r.Files = make(map[string]string)
+	}
+	r.Files[path] = content
+	return fileSHA(content), nil
+}
+
+func (f *FakeGithubRepo) DeleteFile(ctx context.Context, owner string, repo string, path string, branch string, message string, sha string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.failure("DeleteFile", repo); err != nil {
+		return err
+	}
+	r, err := f.repo(repo)
+	if err != nil {
+		return fmt.Errorf("error deleting %s: %v", path, err)
+	}
+	current, ok := r.Files[path]
+	if !ok {
+		return fmt.Errorf("error deleting %s: not found", path)
+	}
+	if fileSHA(current) != sha {
+		return fmt.Errorf("error deleting %s: %w", path, ErrFileChanged)
+	}
+	delete(r.Files, path)
+	return nil
+}
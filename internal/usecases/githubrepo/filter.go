@@ -0,0 +1,154 @@
+package githubrepo
+
+import (
+	"fmt"
+	"release-candidate/internal/configs"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// RepositoryQuery describes which repositories of an organization a use case runs against,
+// in the raw comma-separated form of the action inputs
+type RepositoryQuery struct {
+	UseCase                        string
+	IncludeRepositories            string
+	ExcludeRepositories            string
+	ExcludeProdReleaseRepositories string
+	// Topics selects repositories tagged with any of the listed topics (e.g. release-wave)
+	Topics string
+	// Properties selects repositories whose organization custom properties match every
+	// listed name=value pair (e.g. release_train=payments). Repeating a name allows any of its values.
+	Properties string
+}
+
+// RepositorySelection holds the parsed filters applied by ListRepositories.
+// A repository is kept when it matches every given selector (include, topics, properties) and no exclude.
+type RepositorySelection struct {
+	Include            configs.RepoFilter
+	Exclude            configs.RepoFilter
+	ExcludeProdRelease configs.RepoFilter
+	Topics             []string
+	Properties         map[string][]string
+}
+
+func ParseRepositorySelection(query RepositoryQuery) (RepositorySelection, error) {
+	include, err := configs.ParseRepoFilter(query.IncludeRepositories)
+	if err != nil {
+		return RepositorySelection{}, fmt.Errorf("include_repositories: %v", err)
+	}
+	exclude, err := configs.ParseRepoFilter(query.ExcludeRepositories)
+	if err != nil {
+		return RepositorySelection{}, fmt.Errorf("exclude_repositories: %v", err)
+	}
+	excludeProdRelease, err := configs.ParseRepoFilter(query.ExcludeProdReleaseRepositories)
+	if err != nil {
+		return RepositorySelection{}, fmt.Errorf("exclude_prod_release_repositories: %v", err)
+	}
+
+	var topics []string
+	for _, topic := range strings.Split(query.Topics, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, strings.ToLower(topic))
+		}
+	}
+
+	properties := make(map[string][]string)
+	for _, pair := range strings.Split(query.Properties, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return RepositorySelection{}, fmt.Errorf("repository_properties: %q should be in the format name=value", pair)
+		}
+		properties[name] = append(properties[name], strings.TrimSpace(value))
+	}
+
+	return RepositorySelection{
+		Include:            include,
+		Exclude:            exclude,
+		ExcludeProdRelease: excludeProdRelease,
+		Topics:             topics,
+		Properties:         properties,
+	}, nil
+}
+
+func (s RepositorySelection) matchesTopics(repo *github.Repository) bool {
+	if len(s.Topics) == 0 {
+		return true
+	}
+	for _, topic := range repo.Topics {
+		for _, wanted := range s.Topics {
+			if strings.EqualFold(topic, wanted) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s RepositorySelection) matchesProperties(repo *github.Repository) bool {
+	for name, allowed := range s.Properties {
+		if !customPropertyMatches(repo.CustomProperties[name], allowed) {
+			return false
+		}
+	}
+	return true
+}
+
+// customPropertyMatches compares a custom property value, which is a string,
+// a list of strings for multi-select properties, or nil when unset
+func customPropertyMatches(value interface{}, allowed []string) bool {
+	var values []string
+	switch v := value.(type) {
+	case string:
+		values = []string{v}
+	case []string:
+		values = v
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+	for _, got := range values {
+		for _, want := range allowed {
+			if strings.EqualFold(got, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterRepositories drops archived, not included and excluded repositories from a repository listing
+func filterRepositories(repos []*github.Repository, usecase string, selection RepositorySelection) ([]string, RepositoryScanSummary) {
+	var repoList []string
+	summary := RepositoryScanSummary{Scanned: len(repos)}
+	for _, repo := range repos {
+		repoName := repo.GetName()
+		if repo.GetArchived() {
+			summary.Archived++
+			continue
+		}
+		if (!selection.Include.Empty() && !selection.Include.Match(repoName)) || !selection.matchesTopics(repo) || !selection.matchesProperties(repo) {
+			summary.NotIncluded++
+			continue
+		}
+		if selection.Exclude.Match(repoName) {
+			summary.Excluded++
+			continue
+		}
+		if (usecase == "Production-Release" || usecase == "GitHub-Release" || usecase == "Hotfix" || usecase == "Rollback") && selection.ExcludeProdRelease.Match(repoName) {
+			summary.Excluded++
+			continue
+		}
+		repoList = append(repoList, repoName)
+	}
+	summary.Kept = len(repoList)
+	return repoList, summary
+}
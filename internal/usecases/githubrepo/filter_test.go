@@ -0,0 +1,85 @@
+package githubrepo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+)
+
+func TestFilterRepositories(t *testing.T) {
+	repos := []*github.Repository{
+		{Name: github.String("api")},
+		{Name: github.String("legacy"), Archived: github.Bool(true)},
+		{Name: github.String("svc-a")},
+		{Name: github.String("svc-b")},
+		{Name: github.String("tools")},
+	}
+	selection, err := ParseRepositorySelection(RepositoryQuery{IncludeRepositories: "api, svc-*", ExcludeRepositories: "svc-b", ExcludeProdReleaseRepositories: "re:^api$"})
+	if err != nil {
+		t.Fatalf("ParseRepositorySelection() error = %v", err)
+	}
+
+	tests := []struct {
+		usecase     string
+		wantRepos   []string
+		wantSummary RepositoryScanSummary
+	}{
+		{
+			usecase:     "Release-Candidate",
+			wantRepos:   []string{"api", "svc-a"},
+			wantSummary: RepositoryScanSummary{Scanned: 5, Archived: 1, NotIncluded: 1, Excluded: 1, Kept: 2},
+		},
+		{
+			usecase:     "Production-Release",
+			wantRepos:   []string{"svc-a"},
+			wantSummary: RepositoryScanSummary{Scanned: 5, Archived: 1, NotIncluded: 1, Excluded: 2, Kept: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.usecase, func(t *testing.T) {
+			repoList, summary := filterRepositories(repos, tt.usecase, selection)
+			if !reflect.DeepEqual(repoList, tt.wantRepos) {
+				t.Errorf("repos = %v, want %v", repoList, tt.wantRepos)
+			}
+			if summary != tt.wantSummary {
+				t.Errorf("summary = %+v, want %+v", summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestRepositorySelectionTopicsAndProperties(t *testing.T) {
+	repos := []*github.Repository{
+		{Name: github.String("api"), Topics: []string{"Release-Wave"}, CustomProperties: map[string]interface{}{"release_train": "payments"}},
+		{Name: github.String("web"), Topics: []string{"release-wave"}, CustomProperties: map[string]interface{}{"release_train": []interface{}{"web", "Payments"}}},
+		{Name: github.String("docs"), Topics: []string{"docs"}, CustomProperties: map[string]interface{}{"release_train": "payments"}},
+		{Name: github.String("tools"), Topics: []string{"release-wave"}},
+	}
+	tests := []struct {
+		name      string
+		query     RepositoryQuery
+		wantRepos []string
+		wantErr   bool
+	}{
+		{name: "topics", query: RepositoryQuery{Topics: "release-wave"}, wantRepos: []string{"api", "web", "tools"}},
+		{name: "properties", query: RepositoryQuery{Properties: "release_train=payments"}, wantRepos: []string{"api", "web", "docs"}},
+		{name: "topics and properties", query: RepositoryQuery{Topics: "release-wave", Properties: "release_train=payments"}, wantRepos: []string{"api", "web"}},
+		{name: "any value of a repeated property", query: RepositoryQuery{Properties: "release_train=web, release_train=none"}, wantRepos: []string{"web"}},
+		{name: "malformed property", query: RepositoryQuery{Properties: "release_train"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := ParseRepositorySelection(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRepositorySelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if repoList, _ := filterRepositories(repos, "Release-Candidate", selection); !reflect.DeepEqual(repoList, tt.wantRepos) {
+				t.Errorf("repos = %v, want %v", repoList, tt.wantRepos)
+			}
+		})
+	}
+}
@@ -3,10 +3,12 @@ package githubrepo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
 	"release-candidate/internal/utils"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,19 +18,45 @@ import (
 type GitHubWebApis interface {
 	CreateBranch(ctx context.Context, owner string, repo string, baseBranch string, newBranch string) error
 	CreatePullRequest(ctx context.Context, owner string, repo string, fromBranch string, toBranch string, title string, body string) (prUrl string, prError string, hasConflicts bool, err error)
-	ListRepositories(ctx context.Context, owner string, includeRepositories string, excludeRepositories string) ([]string, error)
+	ListRepositories(ctx context.Context, owner string, query RepositoryQuery) ([]string, error)
 	CreateRepositoryDispatches(ctx context.Context, owner string, repo string, eventType string, clientPayload map[string]interface{}) error
 	ListWorkFlowsByRepoFileFilter(ctx context.Context, owner string, repo string, fileFilterRegex string) ([]RespWorkflow, error)
-	CreateWorkflowDispatchEventByID(ctx context.Context, owner string, repo string, workflowID int64, clientPayload map[string]interface{}) error
+	CreateWorkflowDispatchEventByID(ctx context.Context, owner string, repo string, ref string, workflowID int64, clientPayload map[string]interface{}) error
 	ListEpicBranches(ctx context.Context, owner string, repo string) ([]string, error)
 	DeleteBranch(ctx context.Context, owner string, repo string, branchName string) error
 	ClosePullRequest(ctx context.Context, owner string, repo string, prNumber int, comment string) error
 	ListOpenPullRequestsByBase(ctx context.Context, owner string, repo string, baseBranch string) ([]*github.PullRequest, error)
+	ListWorkflowDispatchRuns(ctx context.Context, owner string, repo string, workflowID int64, branch string, createdAfter time.Time) ([]RespWorkflowRun, error)
+	GetWorkflowRun(ctx context.Context, owner string, repo string, runID int64) (RespWorkflowRun, error)
+	GetFileContent(ctx context.Context, owner string, repo string, path string, ref string) (string, error)
+	GetBranchSHA(ctx context.Context, owner string, repo string, branch string) (string, error)
+	CreateTag(ctx context.Context, owner string, repo string, tag string, sha string) error
+	GetTagSHA(ctx context.Context, owner string, repo string, tag string) (string, error)
+	GetReleaseByTag(ctx context.Context, owner string, repo string, tag string) (*RespRelease, error)
+	GetLatestRelease(ctx context.Context, owner string, repo string) (*RespRelease, error)
+	GenerateReleaseNotes(ctx context.Context, owner string, repo string, tag string, target string, previousTag string) (string, error)
+	CreateRelease(ctx context.Context, owner string, repo string, tag string, name string, body string, draft bool, prerelease bool) (RespRelease, error)
+	ListReleases(ctx context.Context, owner string, repo string) ([]RespRelease, error)
+	CompareCommits(ctx context.Context, owner string, repo string, base string, head string) (RespComparison, error)
+	ListMergedPullRequests(ctx context.Context, owner string, repo string, base string, mergedSince time.Time) ([]RespPullRequest, error)
+	ListMergedPullRequestsByHead(ctx context.Context, owner string, repo string, head string, base string) ([]RespPullRequest, error)
+	ListPullRequestReviews(ctx context.Context, owner string, repo string, number int) ([]RespReview, error)
+	ListCommitChecks(ctx context.Context, owner string, repo string, ref string) ([]RespCheck, error)
+	GetFile(ctx context.Context, owner string, repo string, path string, branch string) (RespFile, error)
+	PutFile(ctx context.Context, owner string, repo string, path string, branch string, message string, content string, sha string) (string, error)
+	DeleteFile(ctx context.Context, owner string, repo string, path string, branch string, message string, sha string) error
 }
 
+var _ GitHubWebApis = GithubRepo{}
+
+// ErrBranchNotFound is wrapped by the errors of lookups of branches that don't exist
+var ErrBranchNotFound = errors.New("branch not found")
+
 type GithubRepo struct {
 	client *github.Client
 	l      utils.LogInterface
+	// plan is set in dry-run mode; mutating calls are recorded into it instead of being executed
+	plan *DryRunPlan
 }
 
 func NewGithubRepo(client *github.Client, logger utils.LogInterface) GithubRepo {
@@ -38,6 +66,17 @@ func NewGithubRepo(client *github.Client, logger utils.LogInterface) GithubRepo
 	}
 }
 
+// WithDryRun returns a copy of the repo that records mutating calls into plan instead of executing them.
+// Read calls still hit the GitHub API so the plan reflects the real state of the organization.
+func (g GithubRepo) WithDryRun(plan *DryRunPlan) GithubRepo {
+	g.plan = plan
+	return g
+}
+
+func (g GithubRepo) DryRun() bool {
+	return g.plan != nil
+}
+
 func (g GithubRepo) CreateBranch(ctx context.Context, owner string, repo string, baseBranch string, newBranch string) error {
 	ref, _, err := g.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+baseBranch)
 	if err != nil {
@@ -51,7 +90,17 @@ func (g GithubRepo) CreateBranch(ctx context.Context, owner string, repo string,
 	}
 
 	if _, _, err := g.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+newBranch); err != nil {
-		if _, _, err := g.client.Git.CreateRef(ctx, owner, repo, newRCBranchRef); err != nil {
+		if g.DryRun() {
+			g.l.Info("[dry-run] Would create branch %s on %s", newBranch, repo)
+			g.plan.Record(PlannedAction{Action: "create-branch", Repo: repo, Target: newBranch, Details: fmt.Sprintf("from %s@%s", baseBranch, ref.Object.GetSHA())})
+			return nil
+		}
+		if _, resp, err := g.client.Git.CreateRef(utils.WithReplaySafe(ctx), owner, repo, newRCBranchRef); err != nil {
+			// A retried create whose first attempt went through, or a concurrent run, already made the branch
+			if resp != nil && resp.StatusCode == 422 && g.refAt(ctx, owner, repo, "refs/heads/"+newBranch, ref.Object.GetSHA()) {
+				g.l.Info("Branch %s already exists on %s at %s", newBranch, repo, ref.Object.GetSHA())
+				return nil
+			}
 			g.l.Error("Error creating branch %s on %s: %v", newBranch, repo, err)
 			return fmt.Errorf("error creating branch %s on %s: %v", newBranch, repo, err)
 		} else {
@@ -61,7 +110,79 @@ func (g GithubRepo) CreateBranch(ctx context.Context, owner string, repo string,
 	return nil
 }
 
+// refAt reports whether ref exists and points at sha
+func (g GithubRepo) refAt(ctx context.Context, owner string, repo string, ref string, sha string) bool {
+	existing, _, err := g.client.Git.GetRef(ctx, owner, repo, ref)
+	return err == nil && existing.Object.GetSHA() == sha
+}
+
+// GetBranchSHA returns the commit the head of a branch points at
+func (g GithubRepo) GetBranchSHA(ctx context.Context, owner string, repo string, branch string) (string, error) {
+	ref, resp, err := g.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return "", fmt.Errorf("error getting ref of branch %s: %w", branch, ErrBranchNotFound)
+		}
+		g.l.Error("Error getting ref of branch %s on %s: %v", branch, repo, err)
+		return "", fmt.Errorf("error getting ref of branch %s: %v", branch, err)
+	}
+	return ref.Object.GetSHA(), nil
+}
+
+// CreateTag creates a lightweight tag pointing at sha. A tag that already points at sha is left as is,
+// so reruns succeed, but a tag pointing elsewhere is an error: tags are never moved.
+func (g GithubRepo) CreateTag(ctx context.Context, owner string, repo string, tag string, sha string) error {
+	if ref, _, err := g.client.Git.GetRef(ctx, owner, repo, "refs/tags/"+tag); err == nil {
+		if ref.Object.GetSHA() != sha {
+			g.l.Error("Tag %s on %s already points at %s instead of %s", tag, repo, ref.Object.GetSHA(), sha)
+			return fmt.Errorf("tag %s already exists at %s, not %s", tag, ref.Object.GetSHA(), sha)
+		}
+		g.l.Info("Tag %s on %s already exists at %s", tag, repo, sha)
+		return nil
+	}
+
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would create tag %s on %s", tag, repo)
+		g.plan.Record(PlannedAction{Action: "create-tag", Repo: repo, Target: tag, Details: "at " + sha})
+		return nil
+	}
+	tagRef := &github.Reference{
+		Ref:    github.String("refs/tags/" + tag),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}
+	if _, resp, err := g.client.Git.CreateRef(utils.WithReplaySafe(ctx), owner, repo, tagRef); err != nil {
+		// A retried create whose first attempt went through, or a concurrent run, already made the tag
+		if resp != nil && resp.StatusCode == 422 && g.refAt(ctx, owner, repo, "refs/tags/"+tag, sha) {
+			g.l.Info("Tag %s on %s already exists at %s", tag, repo, sha)
+			return nil
+		}
+		g.l.Error("Error creating tag %s on %s: %v", tag, repo, err)
+		return fmt.Errorf("error creating tag %s on %s: %v", tag, repo, err)
+	}
+	g.l.Info("Created tag %s on %s at %s", tag, repo, sha)
+	return nil
+}
+
+// GetTagSHA returns the object a tag points at, or "" if the tag doesn't exist
+func (g GithubRepo) GetTagSHA(ctx context.Context, owner string, repo string, tag string) (string, error) {
+	ref, resp, err := g.client.Git.GetRef(ctx, owner, repo, "refs/tags/"+tag)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return "", nil
+		}
+		g.l.Error("Error getting ref of tag %s on %s: %v", tag, repo, err)
+		return "", fmt.Errorf("error getting ref of tag %s: %v", tag, err)
+	}
+	return ref.Object.GetSHA(), nil
+}
+
 func (g GithubRepo) CreatePullRequest(ctx context.Context, owner string, repo string, fromBranch string, toBranch string, title string, body string) (prUrl string, prError string, hasConflicts bool, err error) {
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would create PR %s -> %s on %s", fromBranch, toBranch, repo)
+		g.plan.Record(PlannedAction{Action: "create-pull-request", Repo: repo, Target: fromBranch + " -> " + toBranch, Details: title})
+		return "", "", false, nil
+	}
+
 	prInfo := &github.NewPullRequest{
 		Title: github.String(title),
 		Body:  github.String(body),
@@ -97,7 +218,7 @@ func (g GithubRepo) CreatePullRequest(ctx context.Context, owner string, repo st
 
 	if pr != nil {
 		prUrl = pr.GetHTMLURL()
-		
+
 		// Poll to check for merge conflicts.
 		// GitHub calculates PR mergeability asynchronously in the background.
 		// When a PR is first created, prCheck.Mergeable is often nil while GitHub computes it.
@@ -106,13 +227,13 @@ func (g GithubRepo) CreatePullRequest(ctx context.Context, owner string, repo st
 		maxRetries := 5
 		for attempt := 1; attempt <= maxRetries; attempt++ {
 			time.Sleep(2 * time.Second) // Wait for GitHub to calculate mergeability
-			
+
 			prCheck, _, checkErr := g.client.PullRequests.Get(ctx, owner, repo, pr.GetNumber())
 			if checkErr != nil {
 				g.l.Error("Error checking PR mergeability: %v", checkErr)
 				break
 			}
-			
+
 			if prCheck.Mergeable != nil {
 				hasConflicts = !*prCheck.Mergeable
 				if hasConflicts {
@@ -120,7 +241,7 @@ func (g GithubRepo) CreatePullRequest(ctx context.Context, owner string, repo st
 				}
 				break
 			}
-			
+
 			if attempt == maxRetries {
 				g.l.Warn("Could not determine mergeability for PR %d after %d attempts", pr.GetNumber(), maxRetries)
 			}
@@ -138,53 +259,96 @@ func (g GithubRepo) CreatePullRequest(ctx context.Context, owner string, repo st
 		if len(prs) > 0 {
 			prUrl = prs[0].GetHTMLURL()
 		}
-		print(prUrl)
 	}
 	g.l.Info("PR URL: %s", prUrl)
 	return prUrl, prError, hasConflicts, nil
 }
 
-func (g GithubRepo) ListRepositories(ctx context.Context, owner string, usecase string, includeRepositories string, excludeRepositories string, excludeProdReleaseRepostories string) ([]string, error) {
-	var repoList []string
-	if includeRepositories != "" {
-		repoList = strings.Split(includeRepositories, ",")
-		for _, repo := range repoList {
-			_, _, err := g.client.Repositories.Get(ctx, owner, repo)
+func (g GithubRepo) ListRepositories(ctx context.Context, owner string, query RepositoryQuery) ([]string, error) {
+	selection, err := ParseRepositorySelection(query)
+	if err != nil {
+		g.l.Error("Error parsing repository filters: %v", err)
+		return nil, fmt.Errorf("error parsing repository filters: %v", err)
+	}
+
+	var repos []*github.Repository
+	if !selection.Include.Empty() && selection.Include.ExactOnly() {
+		// Only exact names are included, so fetch them directly instead of walking the organization
+		for _, repoName := range selection.Include.ExactNames() {
+			repo, _, err := g.client.Repositories.Get(ctx, owner, repoName)
 			if err != nil {
 				g.l.Error("Error getting repository: %v", err)
 				return nil, fmt.Errorf("error getting repository: %v", err)
 			}
+			repos = append(repos, repo)
 		}
-
 	} else {
-		repos, _, err := g.client.Repositories.ListByOrg(ctx, owner, &github.RepositoryListByOrgOptions{
+		// GitHub caps per_page at 100, so walk every page to see the whole organization
+		opts := &github.RepositoryListByOrgOptions{
 			ListOptions: github.ListOptions{
-				PerPage: 500,
+				PerPage: 100,
 			},
-		})
-		if err != nil {
-			g.l.Error("Error listing repositories: %v", err)
-			return nil, fmt.Errorf("error listing repositories: %v", err)
 		}
-
-		for _, repo := range repos {
-			if repo.GetArchived() {
-				continue
-			}
-			repoName := repo.GetName()
-			if strings.Contains(excludeRepositories, repoName) {
-				continue
+		for {
+			page, resp, err := g.client.Repositories.ListByOrg(ctx, owner, opts)
+			if err != nil {
+				g.l.Error("Error listing repositories: %v", err)
+				return nil, fmt.Errorf("error listing repositories: %v", err)
 			}
-			if usecase == "Production-Release" && strings.Contains(excludeProdReleaseRepostories, repoName) {
-				continue
+			repos = append(repos, page...)
+
+			if resp.NextPage == 0 {
+				break
 			}
-			repoList = append(repoList, repoName)
+			opts.Page = resp.NextPage
+		}
+	}
+
+	if len(selection.Properties) > 0 {
+		if err := g.loadCustomProperties(ctx, owner, repos); err != nil {
+			return nil, err
 		}
 	}
+
+	repoList, summary := filterRepositories(repos, query.UseCase, selection)
+	g.l.Info("Scanned %d repositories in %s: %d archived, %d not included, %d excluded, %d kept", summary.Scanned, owner, summary.Archived, summary.NotIncluded, summary.Excluded, summary.Kept)
+	if summary.Kept == 0 {
+		g.l.Warn("No repositories left in %s after filtering %d scanned repositories", owner, summary.Scanned)
+	}
 	g.l.Info("Repositories: %v", repoList)
 	return repoList, nil
 }
 
+// loadCustomProperties fills in the organization custom property values of each repository
+func (g GithubRepo) loadCustomProperties(ctx context.Context, owner string, repos []*github.Repository) error {
+	valuesByRepo := make(map[string]map[string]interface{})
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		values, resp, err := g.client.Organizations.ListCustomPropertyValues(ctx, owner, opts)
+		if err != nil {
+			g.l.Error("Error listing custom property values: %v", err)
+			return fmt.Errorf("error listing custom property values: %v", err)
+		}
+		for _, repoValues := range values {
+			properties := make(map[string]interface{})
+			for _, property := range repoValues.Properties {
+				properties[property.PropertyName] = property.Value
+			}
+			valuesByRepo[strings.ToLower(repoValues.RepositoryName)] = properties
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	for _, repo := range repos {
+		repo.CustomProperties = valuesByRepo[strings.ToLower(repo.GetName())]
+	}
+	return nil
+}
+
 func (g GithubRepo) CreateRepositoryDispatches(ctx context.Context, owner string, repo string, eventType string, clientPayload map[string]interface{}) error {
 
 	payloadBytes, err := json.Marshal(clientPayload)
@@ -192,6 +356,11 @@ func (g GithubRepo) CreateRepositoryDispatches(ctx context.Context, owner string
 		g.l.Error("Error marshalling client payload: %v", err)
 		return fmt.Errorf("error marshalling client payload: %v", err)
 	}
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would dispatch event %s to %s", eventType, repo)
+		g.plan.Record(PlannedAction{Action: "repository-dispatch", Repo: repo, Target: eventType, Details: string(payloadBytes)})
+		return nil
+	}
 	payload := json.RawMessage(payloadBytes)
 
 	dispatchOptions := &github.DispatchRequestOptions{
@@ -237,19 +406,30 @@ func (g GithubRepo) ListWorkFlowsByRepoFileFilter(ctx context.Context, owner str
 		}
 	}
 
-	fmt.Printf("Workflows: %v", filteredWorkflows)
+	g.l.Debug("Workflows: %v", filteredWorkflows)
 
 	return filteredWorkflows, nil
 }
 
+// CreateWorkflowDispatchEventByID dispatches a workflow on ref, a branch name or a full ref such as refs/tags/v1.4.0
 func (g GithubRepo) CreateWorkflowDispatchEventByID(ctx context.Context, owner string, repo string, ref string, workflowID int64, clientPayload map[string]interface{}) error {
+	if !strings.HasPrefix(ref, "refs/") {
+		ref = "refs/heads/" + ref
+	}
+	if g.DryRun() {
+		inputs, _ := json.Marshal(clientPayload)
+		g.l.Info("[dry-run] Would dispatch workflow %d on %s", workflowID, repo)
+		g.plan.Record(PlannedAction{Action: "dispatch-workflow", Repo: repo, Target: fmt.Sprintf("workflow %d @ %s", workflowID, ref), Details: string(inputs)})
+		return nil
+	}
 
 	dispatchOptions := &github.CreateWorkflowDispatchEventRequest{
-		Ref:    "refs/heads/" + ref,
+		Ref:    ref,
 		Inputs: clientPayload,
 	}
 
-	_, err := g.client.Actions.CreateWorkflowDispatchEventByID(ctx, owner, repo, workflowID, *dispatchOptions)
+	// A dispatch only queues a run, so it is retried on 502/503s rather than failing the release
+	_, err := g.client.Actions.CreateWorkflowDispatchEventByID(utils.WithReplaySafe(ctx), owner, repo, workflowID, *dispatchOptions)
 	if err != nil {
 		g.l.Error("Error dispatching event: %v", err)
 		return fmt.Errorf("error dispatching event: %v", err)
@@ -259,6 +439,16 @@ func (g GithubRepo) CreateWorkflowDispatchEventByID(ctx context.Context, owner s
 	return nil
 }
 
+// ShortRefName returns the branch or tag name of a full ref, and other refs unchanged
+func ShortRefName(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if name, ok := strings.CutPrefix(ref, prefix); ok {
+			return name
+		}
+	}
+	return ref
+}
+
 // ListEpicBranches returns all branches matching epic-* pattern (case insensitive)
 func (g GithubRepo) ListEpicBranches(ctx context.Context, owner string, repo string) ([]string, error) {
 	var epicBranches []string
@@ -292,6 +482,11 @@ func (g GithubRepo) ListEpicBranches(ctx context.Context, owner string, repo str
 }
 
 func (g GithubRepo) DeleteBranch(ctx context.Context, owner string, repo string, branchName string) error {
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would delete branch %s in repo %s", branchName, repo)
+		g.plan.Record(PlannedAction{Action: "delete-branch", Repo: repo, Target: branchName})
+		return nil
+	}
 	resp, err := g.client.Git.DeleteRef(ctx, owner, repo, "refs/heads/"+branchName)
 	if err != nil {
 		// The branch may already be gone - GitHub can auto-delete the head branch
@@ -310,6 +505,11 @@ func (g GithubRepo) DeleteBranch(ctx context.Context, owner string, repo string,
 }
 
 func (g GithubRepo) ClosePullRequest(ctx context.Context, owner string, repo string, prNumber int, comment string) error {
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would close PR %d in repo %s", prNumber, repo)
+		g.plan.Record(PlannedAction{Action: "close-pull-request", Repo: repo, Target: fmt.Sprintf("#%d", prNumber), Details: comment})
+		return nil
+	}
 	// Add comment
 	if comment != "" {
 		comment := &github.IssueComment{
@@ -362,3 +562,438 @@ func (g GithubRepo) ListOpenPullRequestsByBase(ctx context.Context, owner string
 
 	return allPRs, nil
 }
+
+// ListWorkflowDispatchRuns returns the workflow_dispatch runs of a workflow on branch created at or after createdAfter, oldest first
+func (g GithubRepo) ListWorkflowDispatchRuns(ctx context.Context, owner string, repo string, workflowID int64, branch string, createdAfter time.Time) ([]RespWorkflowRun, error) {
+	opts := &github.ListWorkflowRunsOptions{
+		Branch:  branch,
+		Event:   "workflow_dispatch",
+		Created: ">=" + createdAfter.UTC().Format(time.RFC3339),
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var runs []RespWorkflowRun
+	for {
+		workflowRuns, resp, err := g.client.Actions.ListWorkflowRunsByID(ctx, owner, repo, workflowID, opts)
+		if err != nil {
+			g.l.Error("Error listing runs of workflow %d in repo %s: %v", workflowID, repo, err)
+			return nil, fmt.Errorf("error listing workflow runs: %v", err)
+		}
+		for _, run := range workflowRuns.WorkflowRuns {
+			runs = append(runs, respWorkflowRun(run))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	// GitHub lists newest first
+	sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedAt.Before(runs[j].CreatedAt) })
+	return runs, nil
+}
+
+func (g GithubRepo) GetWorkflowRun(ctx context.Context, owner string, repo string, runID int64) (RespWorkflowRun, error) {
+	run, _, err := g.client.Actions.GetWorkflowRunByID(ctx, owner, repo, runID)
+	if err != nil {
+		g.l.Error("Error getting workflow run %d in repo %s: %v", runID, repo, err)
+		return RespWorkflowRun{}, fmt.Errorf("error getting workflow run: %v", err)
+	}
+	return respWorkflowRun(run), nil
+}
+
+func respWorkflowRun(run *github.WorkflowRun) RespWorkflowRun {
+	return RespWorkflowRun{
+		ID:         run.GetID(),
+		WorkflowID: run.GetWorkflowID(),
+		Status:     run.GetStatus(),
+		Conclusion: run.GetConclusion(),
+		HeadBranch: run.GetHeadBranch(),
+		HeadSHA:    run.GetHeadSHA(),
+		HTMLURL:    run.GetHTMLURL(),
+		CreatedAt:  run.GetCreatedAt().Time,
+	}
+}
+
+// GetFileContent returns the content of a file at ref
+func (g GithubRepo) GetFileContent(ctx context.Context, owner string, repo string, path string, ref string) (string, error) {
+	file, _, _, err := g.client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		g.l.Error("Error getting %s@%s in repo %s: %v", path, ref, repo, err)
+		return "", fmt.Errorf("error getting file %s: %v", path, err)
+	}
+	if file == nil {
+		return "", fmt.Errorf("error getting file %s: it is a directory", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("error decoding file %s: %v", path, err)
+	}
+	return content, nil
+}
+
+func respRelease(release *github.RepositoryRelease) *RespRelease {
+	return &RespRelease{
+		ID:         release.GetID(),
+		TagName:    release.GetTagName(),
+		Name:       release.GetName(),
+		HTMLURL:    release.GetHTMLURL(),
+		Draft:      release.GetDraft(),
+		Prerelease: release.GetPrerelease(),
+	}
+}
+
+// GetReleaseByTag returns the release of a tag, or nil if the tag has none
+func (g GithubRepo) GetReleaseByTag(ctx context.Context, owner string, repo string, tag string) (*RespRelease, error) {
+	release, resp, err := g.client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return nil, nil
+		}
+		g.l.Error("Error getting release %s of %s: %v", tag, repo, err)
+		return nil, fmt.Errorf("error getting release %s: %v", tag, err)
+	}
+	return respRelease(release), nil
+}
+
+// GetLatestRelease returns the most recent published release that isn't a pre-release, or nil if there is none
+func (g GithubRepo) GetLatestRelease(ctx context.Context, owner string, repo string) (*RespRelease, error) {
+	release, resp, err := g.client.Repositories.GetLatestRelease(ctx, owner, repo)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return nil, nil
+		}
+		g.l.Error("Error getting latest release of %s: %v", repo, err)
+		return nil, fmt.Errorf("error getting latest release: %v", err)
+	}
+	return respRelease(release), nil
+}
+
+// GenerateReleaseNotes returns the notes GitHub generates for tag from the pull requests merged since previousTag.
+// target is the commit the tag is created at if it doesn't exist yet; an empty previousTag lets GitHub pick one.
+// It changes nothing, so it runs in dry-run mode too.
+func (g GithubRepo) GenerateReleaseNotes(ctx context.Context, owner string, repo string, tag string, target string, previousTag string) (string, error) {
+	opts := &github.GenerateNotesOptions{
+		TagName:         tag,
+		TargetCommitish: github.String(target),
+	}
+	if previousTag != "" {
+		opts.PreviousTagName = github.String(previousTag)
+	}
+	notes, _, err := g.client.Repositories.GenerateReleaseNotes(ctx, owner, repo, opts)
+	if err != nil {
+		g.l.Error("Error generating release notes for %s of %s: %v", tag, repo, err)
+		return "", fmt.Errorf("error generating release notes: %v", err)
+	}
+	return notes.Body, nil
+}
+
+// CreateRelease publishes a release of an existing tag
+func (g GithubRepo) CreateRelease(ctx context.Context, owner string, repo string, tag string, name string, body string, draft bool, prerelease bool) (RespRelease, error) {
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would create release %s on %s", tag, repo)
+		g.plan.Record(PlannedAction{Action: "create-release", Repo: repo, Target: tag, Details: fmt.Sprintf("%q draft=%t prerelease=%t", name, draft, prerelease)})
+		return RespRelease{TagName: tag, Name: name, Draft: draft, Prerelease: prerelease}, nil
+	}
+
+	release, _, err := g.client.Repositories.CreateRelease(ctx, owner, repo, &github.RepositoryRelease{
+		TagName:    github.String(tag),
+		Name:       github.String(name),
+		Body:       github.String(body),
+		Draft:      github.Bool(draft),
+		Prerelease: github.Bool(prerelease),
+	})
+	if err != nil {
+		g.l.Error("Error creating release %s on %s: %v", tag, repo, err)
+		return RespRelease{}, fmt.Errorf("error creating release %s: %v", tag, err)
+	}
+	g.l.Info("Created release %s on %s", tag, repo)
+	return *respRelease(release), nil
+}
+
+// ListReleases returns the releases of a repo, newest first
+func (g GithubRepo) ListReleases(ctx context.Context, owner string, repo string) ([]RespRelease, error) {
+	var releases []RespRelease
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := g.client.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			g.l.Error("Error listing releases of %s: %v", repo, err)
+			return nil, fmt.Errorf("error listing releases: %v", err)
+		}
+		for _, release := range page {
+			releases = append(releases, *respRelease(release))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return releases, nil
+}
+
+// CompareCommits returns the commits between base and head, which can be branches, tags or SHAs
+func (g GithubRepo) CompareCommits(ctx context.Context, owner string, repo string, base string, head string) (RespComparison, error) {
+	var comparison RespComparison
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := g.client.Repositories.CompareCommits(ctx, owner, repo, base, head, opts)
+		if err != nil {
+			g.l.Error("Error comparing %s...%s in %s: %v", base, head, repo, err)
+			return RespComparison{}, fmt.Errorf("error comparing %s...%s: %v", base, head, err)
+		}
+		comparison.HTMLURL = page.GetHTMLURL()
+		for _, commit := range page.Commits {
+			author := commit.GetAuthor().GetLogin()
+			if author == "" {
+				author = commit.GetCommit().GetAuthor().GetName()
+			}
+			var parents []string
+			for _, parent := range commit.Parents {
+				parents = append(parents, parent.GetSHA())
+			}
+			comparison.Commits = append(comparison.Commits, RespCommit{
+				SHA:     commit.GetSHA(),
+				Message: commit.GetCommit().GetMessage(),
+				Author:  author,
+				HTMLURL: commit.GetHTMLURL(),
+				Parents: parents,
+				Date:    commit.GetCommit().GetCommitter().GetDate().Time,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return comparison, nil
+}
+
+// ListMergedPullRequests returns the pull requests merged into base, most recently updated first.
+// Listing stops at the first pull request last updated before mergedSince, unless mergedSince is zero.
+func (g GithubRepo) ListMergedPullRequests(ctx context.Context, owner string, repo string, base string, mergedSince time.Time) ([]RespPullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		Base:        base,
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var merged []RespPullRequest
+	for {
+		pulls, resp, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			g.l.Error("Error listing pull requests merged into %s in %s: %v", base, repo, err)
+			return nil, fmt.Errorf("error listing pull requests merged into %s: %v", base, err)
+		}
+		for _, pull := range pulls {
+			// A pull request is updated when it is merged, so the rest were merged earlier
+			if !mergedSince.IsZero() && pull.GetUpdatedAt().Before(mergedSince) {
+				return merged, nil
+			}
+			if pull.MergedAt != nil {
+				merged = append(merged, respPullRequest(pull))
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return merged, nil
+}
+
+// ListMergedPullRequestsByHead returns the pull requests merged from the head branch into the base branch, newest first
+func (g GithubRepo) ListMergedPullRequestsByHead(ctx context.Context, owner string, repo string, head string, base string) ([]RespPullRequest, error) {
+	opts := &github.PullRequestListOptions{
+		Head:  owner + ":" + head,
+		Base:  base,
+		State: "closed",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	var merged []RespPullRequest
+	for {
+		pulls, resp, err := g.client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			g.l.Error("Error listing PRs from %s to %s in repo %s: %v", head, base, repo, err)
+			return nil, fmt.Errorf("error listing PRs from %s to %s: %v", head, base, err)
+		}
+		for _, pull := range pulls {
+			if pull.MergedAt != nil {
+				merged = append(merged, respPullRequest(pull))
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return merged, nil
+}
+
+func respPullRequest(pull *github.PullRequest) RespPullRequest {
+	var labels []string
+	for _, label := range pull.Labels {
+		labels = append(labels, label.GetName())
+	}
+	return RespPullRequest{
+		Number:         pull.GetNumber(),
+		Title:          pull.GetTitle(),
+		Author:         pull.GetUser().GetLogin(),
+		Labels:         labels,
+		HTMLURL:        pull.GetHTMLURL(),
+		MergeCommitSHA: pull.GetMergeCommitSHA(),
+		Head:           pull.GetHead().GetRef(),
+		Base:           pull.GetBase().GetRef(),
+	}
+}
+
+// ListPullRequestReviews returns the reviews of a pull request in submission order
+func (g GithubRepo) ListPullRequestReviews(ctx context.Context, owner string, repo string, number int) ([]RespReview, error) {
+	var reviews []RespReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := g.client.PullRequests.ListReviews(ctx, owner, repo, number, opts)
+		if err != nil {
+			g.l.Error("Error listing reviews of PR %d in repo %s: %v", number, repo, err)
+			return nil, fmt.Errorf("error listing reviews of PR %d: %v", number, err)
+		}
+		for _, review := range page {
+			reviews = append(reviews, RespReview{User: review.GetUser().GetLogin(), State: review.GetState()})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return reviews, nil
+}
+
+// ListCommitChecks returns the commit statuses and the check runs of a ref
+func (g GithubRepo) ListCommitChecks(ctx context.Context, owner string, repo string, ref string) ([]RespCheck, error) {
+	var checks []RespCheck
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		combined, resp, err := g.client.Repositories.GetCombinedStatus(ctx, owner, repo, ref, opts)
+		if err != nil {
+			g.l.Error("Error getting the status of %s in repo %s: %v", ref, repo, err)
+			return nil, fmt.Errorf("error getting the status of %s: %v", ref, err)
+		}
+		for _, status := range combined.Statuses {
+			check := RespCheck{Name: status.GetContext(), Status: "completed", Conclusion: status.GetState(), URL: status.GetTargetURL()}
+			if check.Conclusion == "pending" {
+				check.Status, check.Conclusion = "pending", ""
+			}
+			checks = append(checks, check)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	checkOpts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := g.client.Checks.ListCheckRunsForRef(ctx, owner, repo, ref, checkOpts)
+		if err != nil {
+			g.l.Error("Error listing check runs of %s in repo %s: %v", ref, repo, err)
+			return nil, fmt.Errorf("error listing check runs of %s: %v", ref, err)
+		}
+		for _, run := range runs.CheckRuns {
+			checks = append(checks, RespCheck{Name: run.GetName(), Status: run.GetStatus(), Conclusion: run.GetConclusion(), URL: run.GetHTMLURL()})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		checkOpts.Page = resp.NextPage
+	}
+	return checks, nil
+}
+
+// ErrFileChanged is returned by PutFile and DeleteFile when the file was created, changed or deleted since it was read
+var ErrFileChanged = errors.New("file changed since it was read")
+
+// fileChanged tells the sha mismatches of the contents API apart from its other 409s and 422s, such as a protected
+// branch or an invalid path. GitHub doesn't set them apart but by the wording of its message, so the file is read
+// again: it changed if its version is no longer sha, or exists at all when sha is ""
+func (g GithubRepo) fileChanged(ctx context.Context, owner string, repo string, path string, branch string, sha string, resp *github.Response) bool {
+	if resp == nil || (resp.StatusCode != 409 && resp.StatusCode != 422) {
+		return false
+	}
+	current, err := g.GetFile(ctx, owner, repo, path, branch)
+	return err == nil && current.SHA != sha
+}
+
+// GetFile returns a file of branch, the default branch when "", with an empty SHA if it doesn't exist
+func (g GithubRepo) GetFile(ctx context.Context, owner string, repo string, path string, branch string) (RespFile, error) {
+	file, _, resp, err := g.client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: branch})
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return RespFile{}, nil
+		}
+		g.l.Error("Error getting %s in repo %s: %v", path, repo, err)
+		return RespFile{}, fmt.Errorf("error getting file %s: %v", path, err)
+	}
+	if file == nil {
+		return RespFile{}, fmt.Errorf("error getting file %s: it is a directory", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return RespFile{}, fmt.Errorf("error decoding file %s: %v", path, err)
+	}
+	return RespFile{Content: content, SHA: file.GetSHA()}, nil
+}
+
+// PutFile commits a file to branch, the default branch when "". With an empty sha the file is created and must not
+// exist yet, otherwise the version sha is replaced and must still be the current one. Both fail with ErrFileChanged
+// when that doesn't hold, so the file can serve as a lock. It returns the SHA of the new version.
+func (g GithubRepo) PutFile(ctx context.Context, owner string, repo string, path string, branch string, message string, content string, sha string) (string, error) {
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would commit %s in repo %s", path, repo)
+		g.plan.Record(PlannedAction{Action: "put-file", Repo: repo, Target: path, Details: message})
+		return "", nil
+	}
+	opts := &github.RepositoryContentFileOptions{Message: github.String(message), Content: []byte(content)}
+	if branch != "" {
+		opts.Branch = github.String(branch)
+	}
+	if sha != "" {
+		opts.SHA = github.String(sha)
+	}
+	result, resp, err := g.client.Repositories.CreateFile(ctx, owner, repo, path, opts)
+	if err != nil {
+		if g.fileChanged(ctx, owner, repo, path, branch, sha, resp) {
+			return "", fmt.Errorf("error committing %s: %w", path, ErrFileChanged)
+		}
+		g.l.Error("Error committing %s in repo %s: %v", path, repo, err)
+		return "", fmt.Errorf("error committing %s: %v", path, err)
+	}
+	return result.GetContent().GetSHA(), nil
+}
+
+// DeleteFile deletes the version sha of a file from branch, the default branch when "", failing with
+// ErrFileChanged if the file changed since. A file that no longer exists is an error of its own.
+func (g GithubRepo) DeleteFile(ctx context.Context, owner string, repo string, path string, branch string, message string, sha string) error {
+	if g.DryRun() {
+		g.l.Info("[dry-run] Would delete %s in repo %s", path, repo)
+		g.plan.Record(PlannedAction{Action: "delete-file", Repo: repo, Target: path, Details: message})
+		return nil
+	}
+	opts := &github.RepositoryContentFileOptions{Message: github.String(message), SHA: github.String(sha)}
+	if branch != "" {
+		opts.Branch = github.String(branch)
+	}
+	if _, resp, err := g.client.Repositories.DeleteFile(ctx, owner, repo, path, opts); err != nil {
+		if g.fileChanged(ctx, owner, repo, path, branch, sha, resp) {
+			return fmt.Errorf("error deleting %s: %w", path, ErrFileChanged)
+		}
+		g.l.Error("Error deleting %s in repo %s: %v", path, repo, err)
+		return fmt.Errorf("error deleting %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package githubrepo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/ghemulator"
+	"release-candidate/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEmulatedGithubRepo serves state from the emulator and returns a GithubRepo calling it through a real client
+func newEmulatedGithubRepo(t *testing.T, state *ghemulator.State) (GithubRepo, *ghemulator.Emulator) {
+	t.Helper()
+	emulator := ghemulator.New(state)
+	server := emulator.Start()
+	t.Cleanup(server.Close)
+
+	client, err := utils.CreateGitHubClient(utils.NewLogger("error"), &configs.Config{Token: "test-token", GitHubAPIURL: server.URL})
+	if err != nil {
+		t.Fatalf("CreateGitHubClient() error = %v", err)
+	}
+	return NewGithubRepo(client, utils.NewLogger("error")), emulator
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		// pulls are already in the repo
+		pulls []*ghemulator.Pull
+		head  string
+		// conflicts marks head->main as not mergeable
+		conflicts     bool
+		wantURLSuffix string
+		wantPRError   string
+		wantConflicts bool
+	}{
+		{
+			name:          "opened with conflicts",
+			head:          "rc/v1.1.0",
+			conflicts:     true,
+			wantURLSuffix: "/o/api/pull/1",
+			wantConflicts: true,
+		},
+		{
+			name:          "already exists",
+			pulls:         []*ghemulator.Pull{{Number: 7, Head: "rc/v1.1.0", Base: "main", State: "open"}},
+			head:          "rc/v1.1.0",
+			wantURLSuffix: "/o/api/pull/7",
+			wantPRError:   "A pull request already exists for o:rc/v1.1.0.",
+		},
+		{
+			name:        "no commits",
+			head:        "rc/empty",
+			wantPRError: "No commits between main and rc/empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &ghemulator.Repo{
+				Name: "api",
+				Branches: []*ghemulator.Branch{
+					{Name: "main", SHA: "a1"},
+					{Name: "rc/v1.1.0", SHA: "a2"},
+					{Name: "rc/empty", SHA: "a1"},
+				},
+				Pulls: tt.pulls,
+			}
+			if tt.conflicts {
+				repo.Conflicts = []string{tt.head + "->main"}
+			}
+			githubRepo, _ := newEmulatedGithubRepo(t, &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{repo}})
+
+			prURL, prError, hasConflicts, err := githubRepo.CreatePullRequest(context.Background(), "o", "api", tt.head, "main", "Release", "")
+			if err != nil {
+				t.Fatalf("CreatePullRequest() error = %v", err)
+			}
+			if (prURL == "") != (tt.wantURLSuffix == "") || !strings.HasSuffix(prURL, tt.wantURLSuffix) {
+				t.Errorf("PR URL = %q, want it to end with %q", prURL, tt.wantURLSuffix)
+			}
+			if prError != tt.wantPRError {
+				t.Errorf("PR error = %q, want %q", prError, tt.wantPRError)
+			}
+			if hasConflicts != tt.wantConflicts {
+				t.Errorf("has conflicts = %v, want %v", hasConflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestRefs(t *testing.T) {
+	ctx := context.Background()
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{
+		Name:     "api",
+		Branches: []*ghemulator.Branch{{Name: "main", SHA: "a2"}, {Name: "rc/v1.1.0", SHA: "a1"}},
+		Tags:     []*ghemulator.Tag{{Name: "v1.0.0", SHA: "a1"}},
+	}}}
+	githubRepo, emulator := newEmulatedGithubRepo(t, state)
+	branchSHA := func(name string) string {
+		for _, branch := range emulator.State().Repos[0].Branches {
+			if branch.Name == name {
+				return branch.SHA
+			}
+		}
+		return ""
+	}
+
+	if err := githubRepo.CreateBranch(ctx, "o", "api", "main", "rc/v1.1.0"); err != nil {
+		t.Errorf("CreateBranch() of an existing branch error = %v", err)
+	}
+	if sha := branchSHA("rc/v1.1.0"); sha != "a1" {
+		t.Errorf("existing branch moved to %q, want it left at a1", sha)
+	}
+	if err := githubRepo.CreateBranch(ctx, "o", "api", "main", "rc/v1.2.0"); err != nil {
+		t.Errorf("CreateBranch() error = %v", err)
+	}
+	if sha, err := githubRepo.GetBranchSHA(ctx, "o", "api", "rc/v1.2.0"); err != nil || sha != "a2" {
+		t.Errorf("GetBranchSHA() = %q, %v, want a2", sha, err)
+	}
+	if err := githubRepo.CreateBranch(ctx, "o", "api", "missing", "rc/v1.3.0"); err == nil {
+		t.Error("CreateBranch() from a missing branch error = nil")
+	}
+
+	if err := githubRepo.CreateTag(ctx, "o", "api", "v1.0.0", "a1"); err != nil {
+		t.Errorf("CreateTag() of the same tag error = %v", err)
+	}
+	if err := githubRepo.CreateTag(ctx, "o", "api", "v1.0.0", "a2"); err == nil || !strings.Contains(err.Error(), "already exists at a1") {
+		t.Errorf("CreateTag() moving a tag error = %v, want it refused", err)
+	}
+	if err := githubRepo.CreateTag(ctx, "o", "api", "v1.1.0", "a2"); err != nil {
+		t.Errorf("CreateTag() error = %v", err)
+	}
+	if sha, err := githubRepo.GetTagSHA(ctx, "o", "api", "v1.1.0"); err != nil || sha != "a2" {
+		t.Errorf("GetTagSHA() = %q, %v, want a2", sha, err)
+	}
+	if sha, err := githubRepo.GetTagSHA(ctx, "o", "api", "v9.9.9"); err != nil || sha != "" {
+		t.Errorf("GetTagSHA() of a missing tag = %q, %v, want no tag", sha, err)
+	}
+
+	if err := githubRepo.DeleteBranch(ctx, "o", "api", "rc/v1.2.0"); err != nil {
+		t.Errorf("DeleteBranch() error = %v", err)
+	}
+	if sha := branchSHA("rc/v1.2.0"); sha != "" {
+		t.Error("branch rc/v1.2.0 was not deleted")
+	}
+}
+
+func TestReleases(t *testing.T) {
+	ctx := context.Background()
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{
+		Name:     "api",
+		Releases: []*ghemulator.Release{{ID: 1, TagName: "v1.0.0"}, {ID: 2, TagName: "v1.1.0-rc.1", Prerelease: true}},
+	}}}
+	githubRepo, _ := newEmulatedGithubRepo(t, state)
+
+	if release, err := githubRepo.GetReleaseByTag(ctx, "o", "api", "v1.1.0"); err != nil || release != nil {
+		t.Errorf("GetReleaseByTag() of a missing release = %+v, %v, want none", release, err)
+	}
+	latest, err := githubRepo.GetLatestRelease(ctx, "o", "api")
+	if err != nil || latest == nil || latest.TagName != "v1.0.0" {
+		t.Fatalf("GetLatestRelease() = %+v, %v, want v1.0.0 and not the pre-release", latest, err)
+	}
+	notes, err := githubRepo.GenerateReleaseNotes(ctx, "o", "api", "v1.1.0", "a2", latest.TagName)
+	if err != nil || !strings.Contains(notes, "compare/v1.0.0...v1.1.0") {
+		t.Errorf("GenerateReleaseNotes() = %q, %v, want the changes since v1.0.0", notes, err)
+	}
+
+	created, err := githubRepo.CreateRelease(ctx, "o", "api", "v1.1.0", "v1.1.0", notes, false, false)
+	if err != nil || created.TagName != "v1.1.0" || created.HTMLURL == "" {
+		t.Fatalf("CreateRelease() = %+v, %v", created, err)
+	}
+	if release, err := githubRepo.GetReleaseByTag(ctx, "o", "api", "v1.1.0"); err != nil || release == nil || release.ID != created.ID {
+		t.Errorf("GetReleaseByTag() = %+v, %v, want the created release", release, err)
+	}
+	if _, err := githubRepo.CreateRelease(ctx, "o", "api", "v1.1.0", "v1.1.0", notes, false, false); err == nil {
+		t.Error("CreateRelease() of an existing release error = nil")
+	}
+}
+
+func TestChangelogApis(t *testing.T) {
+	ctx := context.Background()
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{
+		Name:     "api",
+		Branches: []*ghemulator.Branch{{Name: "main", SHA: "c0"}, {Name: "rc/v1.1.0", SHA: "m1"}},
+		Commits: []*ghemulator.Commit{
+			{SHA: "c0", Message: "chore: release", Author: "alice"},
+			{SHA: "f1", Message: "wip search", Author: "bob"},
+			{SHA: "m1", Message: "Merge pull request #1", Author: "bob", Parents: []string{"c0", "f1"}},
+		},
+		Pulls: []*ghemulator.Pull{
+			{Number: 1, Title: "feat: search", Head: "search", Base: "development", State: "closed", Merged: true, MergeCommitSHA: "m1", Labels: []string{"enhancement"}},
+			{Number: 2, Title: "Abandoned", Head: "old", Base: "development", State: "closed"},
+			{Number: 3, Title: "fix: hotfix", Head: "hotfix", Base: "main", State: "closed", Merged: true, MergeCommitSHA: "h1"},
+		},
+	}}}
+	githubRepo, _ := newEmulatedGithubRepo(t, state)
+
+	comparison, err := githubRepo.CompareCommits(ctx, "o", "api", "main", "rc/v1.1.0")
+	if err != nil {
+		t.Fatalf("CompareCommits() error = %v", err)
+	}
+	var parents [][]string
+	for _, commit := range comparison.Commits {
+		parents = append(parents, commit.Parents)
+	}
+	if want := [][]string{{"c0"}, {"c0", "f1"}}; !reflect.DeepEqual(parents, want) {
+		t.Errorf("parents = %v, want %v", parents, want)
+	}
+
+	pulls, err := githubRepo.ListMergedPullRequests(ctx, "o", "api", "development", time.Time{})
+	if err != nil {
+		t.Fatalf("ListMergedPullRequests() error = %v", err)
+	}
+	if len(pulls) != 1 || pulls[0].Number != 1 || pulls[0].MergeCommitSHA != "m1" || !reflect.DeepEqual(pulls[0].Labels, []string{"enhancement"}) {
+		t.Errorf("ListMergedPullRequests() = %+v, want only #1 merged as m1", pulls)
+	}
+
+	if _, err := githubRepo.GetBranchSHA(ctx, "o", "api", "rc/v1.2.0"); !errors.Is(err, ErrBranchNotFound) {
+		t.Errorf("GetBranchSHA() of a missing branch error = %v, want ErrBranchNotFound", err)
+	}
+}
+
+func TestListMergedPullRequestsByHead(t *testing.T) {
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{
+		Name: "api",
+		Pulls: []*ghemulator.Pull{
+			{Number: 1, Title: "Hotfix v1.1.0", Head: "hotfix/v1.1.0", Base: "main", State: "closed", Merged: true},
+			{Number: 2, Title: "Hotfix v1.1.0", Head: "hotfix/v1.1.0", Base: "development", State: "closed", Merged: true},
+			{Number: 3, Title: "Hotfix v1.1.0", Head: "hotfix/v1.1.0", Base: "main", State: "closed"},
+		},
+	}}}
+	githubRepo, _ := newEmulatedGithubRepo(t, state)
+
+	pulls, err := githubRepo.ListMergedPullRequestsByHead(context.Background(), "o", "api", "hotfix/v1.1.0", "main")
+	if err != nil {
+		t.Fatalf("ListMergedPullRequestsByHead() error = %v", err)
+	}
+	if len(pulls) != 1 || pulls[0].Number != 1 || pulls[0].Head != "hotfix/v1.1.0" || pulls[0].Base != "main" {
+		t.Errorf("ListMergedPullRequestsByHead() = %+v, want only #1", pulls)
+	}
+}
+
+func TestReadinessApis(t *testing.T) {
+	ctx := context.Background()
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{
+		Name:     "api",
+		Branches: []*ghemulator.Branch{{Name: "main", SHA: "a1"}},
+		Commits:  []*ghemulator.Commit{{SHA: "a1", Message: "chore: release", Author: "alice"}},
+		Checks: []*ghemulator.Check{
+			{SHA: "a1", Name: "build", Conclusion: "success"},
+			{SHA: "a1", Name: "deploy-preview", Status: "in_progress"},
+			{SHA: "a1", Name: "ci/lint", Type: "status", Conclusion: "failure"},
+		},
+		Pulls: []*ghemulator.Pull{{
+			Number: 1, Head: "rc/v1.1.0", Base: "main", State: "closed", Merged: true,
+			Reviews: []*ghemulator.Review{{User: "alice", State: "APPROVED"}, {User: "bob", State: "CHANGES_REQUESTED"}},
+		}},
+	}}}
+	githubRepo, _ := newEmulatedGithubRepo(t, state)
+
+	checks, err := githubRepo.ListCommitChecks(ctx, "o", "api", "main")
+	if err != nil {
+		t.Fatalf("ListCommitChecks() error = %v", err)
+	}
+	want := []RespCheck{
+		{Name: "ci/lint", Status: "completed", Conclusion: "failure"},
+		{Name: "build", Status: "completed", Conclusion: "success"},
+		{Name: "deploy-preview", Status: "in_progress"},
+	}
+	if !reflect.DeepEqual(checks, want) {
+		t.Errorf("ListCommitChecks() = %+v, want %+v", checks, want)
+	}
+
+	reviews, err := githubRepo.ListPullRequestReviews(ctx, "o", "api", 1)
+	if err != nil {
+		t.Fatalf("ListPullRequestReviews() error = %v", err)
+	}
+	if len(reviews) != 2 || reviews[0] != (RespReview{User: "alice", State: "APPROVED"}) || reviews[1].State != "CHANGES_REQUESTED" {
+		t.Errorf("ListPullRequestReviews() = %+v, want alice's approval then bob's changes requested", reviews)
+	}
+}
+
+// TestCreateBranchAlreadyCreated hides the new branch from the existence check, as when a retried create
+// already went through, so the create is rejected and the branch is compared instead
+func TestFileChanged(t *testing.T) {
+	ctx := context.Background()
+	const path = ".release-wave/locks/o.json"
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{Name: "control"}}}
+	githubRepo, emulator := newEmulatedGithubRepo(t, state)
+
+	sha, err := githubRepo.PutFile(ctx, "o", "control", path, "", "Lock", "first", "")
+	if err != nil {
+		t.Fatalf("PutFile() error = %v", err)
+	}
+	if _, err := githubRepo.PutFile(ctx, "o", "control", path, "", "Lock", "second", ""); !errors.Is(err, ErrFileChanged) {
+		t.Errorf("PutFile() creating an existing file error = %v, want ErrFileChanged", err)
+	}
+	if _, err := githubRepo.PutFile(ctx, "o", "control", path, "", "Lock", "second", "stale"); !errors.Is(err, ErrFileChanged) {
+		t.Errorf("PutFile() with a stale sha error = %v, want ErrFileChanged", err)
+	}
+	if err := githubRepo.DeleteFile(ctx, "o", "control", path, "", "Unlock", "stale"); !errors.Is(err, ErrFileChanged) {
+		t.Errorf("DeleteFile() with a stale sha error = %v, want ErrFileChanged", err)
+	}
+	if file, err := githubRepo.GetFile(ctx, "o", "control", path, ""); err != nil || file.Content != "first" || file.SHA != sha {
+		t.Errorf("GetFile() = %+v, %v, want the first version", file, err)
+	}
+
+	emulator.State().Faults = []*ghemulator.Fault{{Method: "PUT", PathPrefix: "/repos/o/control/contents/", Status: 409, Message: "Repository rule violations found", Count: 1}}
+	if _, err := githubRepo.PutFile(ctx, "o", "control", path, "", "Lock", "second", sha); err == nil || errors.Is(err, ErrFileChanged) {
+		t.Errorf("PutFile() on a protected branch error = %v, want an error other than ErrFileChanged", err)
+	}
+
+	if err := githubRepo.DeleteFile(ctx, "o", "control", path, "", "Unlock", sha); err != nil {
+		t.Fatalf("DeleteFile() error = %v", err)
+	}
+	if err := githubRepo.DeleteFile(ctx, "o", "control", path, "", "Unlock", sha); err == nil || errors.Is(err, ErrFileChanged) {
+		t.Errorf("DeleteFile() of a deleted file error = %v, want an error other than ErrFileChanged", err)
+	}
+}
+
+func TestCreateBranchAlreadyCreated(t *testing.T) {
+	tests := []struct {
+		name    string
+		sha     string
+		wantErr bool
+	}{
+		{name: "at the base sha", sha: "a2"},
+		{name: "elsewhere", sha: "a1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &ghemulator.State{
+				Owner: "o",
+				Repos: []*ghemulator.Repo{{
+					Name:     "api",
+					Branches: []*ghemulator.Branch{{Name: "main", SHA: "a2"}, {Name: "rc/v1.1.0", SHA: tt.sha}},
+				}},
+				Faults: []*ghemulator.Fault{{Method: "GET", PathPrefix: "/repos/o/api/git/ref/heads/rc/v1.1.0", Status: 404, Count: 1}},
+			}
+			githubRepo, _ := newEmulatedGithubRepo(t, state)
+
+			err := githubRepo.CreateBranch(context.Background(), "o", "api", "main", "rc/v1.1.0")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCreateTagAlreadyCreated hides the new tag from the existence check, as TestCreateBranchAlreadyCreated does
+func TestCreateTagAlreadyCreated(t *testing.T) {
+	tests := []struct {
+		name    string
+		sha     string
+		wantErr bool
+	}{
+		{name: "at the same sha", sha: "a2"},
+		{name: "elsewhere", sha: "a1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &ghemulator.State{
+				Owner:  "o",
+				Repos:  []*ghemulator.Repo{{Name: "api", Tags: []*ghemulator.Tag{{Name: "v1.1.0", SHA: tt.sha}}}},
+				Faults: []*ghemulator.Fault{{Method: "GET", PathPrefix: "/repos/o/api/git/ref/tags/v1.1.0", Status: 404, Count: 1}},
+			}
+			githubRepo, _ := newEmulatedGithubRepo(t, state)
+
+			err := githubRepo.CreateTag(context.Background(), "o", "api", "v1.1.0", "a2")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package githubrepo
 
+import "time"
+
 type RespWorkflow struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
@@ -7,3 +9,84 @@ type RespWorkflow struct {
 	Path string `json:"path"`
 	Repo string `json:"repo"`
 }
+
+// RepositoryScanSummary counts how the repositories of an organization listing were filtered
+type RepositoryScanSummary struct {
+	Scanned     int `json:"scanned"`
+	Archived    int `json:"archived"`
+	NotIncluded int `json:"not_included"`
+	Excluded    int `json:"excluded"`
+	Kept        int `json:"kept"`
+}
+
+type RespWorkflowRun struct {
+	ID         int64     `json:"id"`
+	WorkflowID int64     `json:"workflow_id"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	HeadBranch string    `json:"head_branch"`
+	HeadSHA    string    `json:"head_sha"`
+	HTMLURL    string    `json:"html_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type RespRelease struct {
+	ID         int64  `json:"id"`
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	HTMLURL    string `json:"html_url"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+type RespCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	// Author is the GitHub login of the author, or the git author name if the commit isn't linked to an account
+	Author  string `json:"author"`
+	HTMLURL string `json:"html_url"`
+	// Parents are the SHAs of the parent commits, two or more for merge commits
+	Parents []string `json:"parents"`
+	// Date is when the commit was committed
+	Date time.Time `json:"date"`
+}
+
+// RespComparison is the commits reachable from head but not from base, oldest first
+type RespComparison struct {
+	HTMLURL string       `json:"html_url"`
+	Commits []RespCommit `json:"commits"`
+}
+
+type RespPullRequest struct {
+	Number  int      `json:"number"`
+	Title   string   `json:"title"`
+	Author  string   `json:"author"`
+	Labels  []string `json:"labels"`
+	HTMLURL string   `json:"html_url"`
+	// MergeCommitSHA is the commit merging the pull request created: a merge commit, the squashed commit,
+	// or the last commit rebased onto the base branch
+	MergeCommitSHA string `json:"merge_commit_sha"`
+	// Head and Base are the branches the pull request merged from and into
+	Head string `json:"head"`
+	Base string `json:"base"`
+}
+
+// RespCheck is a check run or commit status of a commit. Commit statuses are completed unless pending, with their
+// state as the conclusion.
+type RespCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	URL        string `json:"url"`
+}
+
+// RespFile is a file and the blob SHA that changing or deleting it requires. SHA is "" when the file doesn't exist.
+type RespFile struct {
+	Content string `json:"content"`
+	SHA     string `json:"sha"`
+}
+
+type RespReview struct {
+	User  string `json:"user"`
+	State string `json:"state"`
+}
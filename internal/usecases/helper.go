@@ -6,37 +6,278 @@ import (
 	"release-candidate/internal/configs"
 	"release-candidate/internal/usecases/githubrepo"
 	"release-candidate/internal/utils"
+	"strings"
+	"time"
 )
 
-func ProductionWorkflowDispatch(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GithubRepo, variables *configs.Config, repoList []string) (slackpayload string, err error) {
-	payload := map[string]interface{}{
-		"environment":     variables.Environment,
-		"release_version": variables.RCVersion,
+// Statuses of WorkflowDispatchResult
+const (
+	DispatchStatusDispatched        = "dispatched"
+	DispatchStatusSkippedNoWorkflow = "skipped-no-workflow"
+	DispatchStatusFailed            = "failed"
+	// DispatchStatusAborted marks repos of a wave that was not dispatched because an earlier wave failed
+	DispatchStatusAborted = "aborted"
+)
+
+// WorkflowDispatchResult is the outcome of dispatching one production workflow of a repo.
+// A repo without production workflows, or whose workflows could not be listed, gets a single result without a workflow.
+// The run fields are only set when waiting for the dispatched runs.
+type WorkflowDispatchResult struct {
+	Repo         string `json:"repo"`
+	Wave         string `json:"wave,omitempty"`
+	WorkflowID   int64  `json:"workflow_id,omitempty"`
+	WorkflowName string `json:"workflow_name,omitempty"`
+	WorkflowPath string `json:"workflow_path,omitempty"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+	// Ref is the full ref the workflow was dispatched on and SHA the commit it pointed at
+	Ref          string    `json:"ref,omitempty"`
+	SHA          string    `json:"sha,omitempty"`
+	DispatchedAt time.Time `json:"-"`
+	RunID        int64     `json:"run_id,omitempty"`
+	RunURL       string    `json:"run_url,omitempty"`
+	Conclusion   string    `json:"conclusion,omitempty"`
+	// priorRunIDs are the runs of the workflow that existed before the dispatch, so they aren't mistaken for its run
+	priorRunIDs map[int64]bool
+}
+
+// ProductionWorkflowDispatch dispatches the production workflows of every repo, carrying on past failures.
+// It returns one result per workflow in repoList order, the Slack payload, and an error listing the failures if there were any.
+func ProductionWorkflowDispatch(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repoList []string) (results []WorkflowDispatchResult, slackpayload string, err error) {
+	return productionWorkflowDispatch(ctx, l, githubRepo, variables, repoList, nil)
+}
+
+// productionWorkflowDispatch is ProductionWorkflowDispatch dispatching the repos of releaseTags on their existing release tag
+func productionWorkflowDispatch(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repoList []string, releaseTags map[string]string) (results []WorkflowDispatchResult, slackpayload string, err error) {
+	// Dry-run dispatches start no runs, so there is nothing to wait for
+	results = dispatchProductionWorkflows(ctx, l, githubRepo, variables, repoList, releaseTags, variables.WaitForWorkflows && !variables.DryRun)
+
+	var dispatchItems []map[string]interface{}
+	for _, result := range results {
+		dispatchItems = append(dispatchItems, dispatchItem(result))
 	}
-	prodWorkflowFilter := "prod-release.*"
 
-	for _, repo := range repoList {
+	slackpayload, err = utils.ProductionWorkflowDispatchSlackPayloadBuilder(variables.RCVersion, dispatchItems, variables.Environment)
+	if err != nil {
+		l.Error("Error building slack payload: %v", err)
+		return results, "", fmt.Errorf("error building slack payload: %v", err)
+	}
+
+	if failures := dispatchFailures(results); len(failures) > 0 {
+		l.Error("Production workflow dispatch failed for %d workflow(s)", len(failures))
+		return results, slackpayload, fmt.Errorf("production workflow dispatch failed for %d workflow(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	l.Info("Production workflow dispatched")
+
+	return results, slackpayload, nil
+}
+
+// dispatchProductionWorkflows dispatches the production workflows of repoList concurrently and,
+// if wait is set, waits for the runs they started. Results keep repoList order.
+// releaseTags maps the repos being rolled back to the existing release tag they are dispatched on instead of releasing RCVersion.
+func dispatchProductionWorkflows(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repoList []string, releaseTags map[string]string, wait bool) []WorkflowDispatchResult {
+	repoResults := make([][]WorkflowDispatchResult, len(repoList))
+	utils.ForEachConcurrently(len(repoList), variables.Concurrency, func(i int) {
+		repo := repoList[i]
+		environment := variables.EnvironmentFor(repo)
+		releaseTag := releaseTags[repo]
+		version := variables.RCVersion
+		if releaseTag != "" {
+			version = releaseTag
+		}
+		payload, err := variables.WorkflowInputsFor(repo, version)
+		if err != nil {
+			l.Error("Error rendering workflow inputs for repo %s: %v", repo, err)
+			repoResults[i] = []WorkflowDispatchResult{{Repo: repo, Status: DispatchStatusFailed, Error: err.Error()}}
+			return
+		}
+		prodWorkflowFilter := variables.WorkflowFilterFor(repo)
 		workflows, err := githubRepo.ListWorkFlowsByRepoFileFilter(ctx, variables.Owner, repo, prodWorkflowFilter)
 		if err != nil {
 			l.Error("Error listing workflows for repo %s: %v", repo, err)
-			return "", fmt.Errorf("error listing workflows for repo %s: %v", repo, err)
+			repoResults[i] = []WorkflowDispatchResult{{Repo: repo, Status: DispatchStatusFailed, Error: fmt.Sprintf("error listing workflows: %v", err)}}
+			return
 		}
 
-		for _, workflow := range workflows {
-			err = githubRepo.CreateWorkflowDispatchEventByID(ctx, variables.Owner, repo, variables.ProductionBranch, workflow.ID, payload)
-			if err != nil {
-				l.Error("Error dispatching workflow for repo %s: %v", repo, err)
-				return "", fmt.Errorf("error dispatching workflow for repo %s: %v", repo, err)
+		if len(workflows) == 0 {
+			l.Warn("No production workflow matching %s found in repo %s, skipping", prodWorkflowFilter, repo)
+			repoResults[i] = []WorkflowDispatchResult{{Repo: repo, Status: DispatchStatusSkippedNoWorkflow}}
+			return
+		}
+
+		ref, sha, createTag, err := resolveProductionRef(ctx, l, githubRepo, variables, repo, releaseTag)
+		if err != nil {
+			repoResults[i] = []WorkflowDispatchResult{{Repo: repo, Status: DispatchStatusFailed, Error: err.Error()}}
+			return
+		}
+
+		// The inputs of every workflow are checked before the release tag is created, since a tag is permanent
+		results := make([]WorkflowDispatchResult, len(workflows))
+		passed := 0
+		for j, workflow := range workflows {
+			results[j] = WorkflowDispatchResult{
+				Repo:         repo,
+				WorkflowID:   workflow.ID,
+				WorkflowName: workflow.Name,
+				WorkflowPath: workflow.Path,
+				Status:       DispatchStatusDispatched,
+				Ref:          ref,
+				SHA:          sha,
+			}
+			if err := checkWorkflowInputs(ctx, githubRepo, variables, repo, workflow, sha, payload); err != nil {
+				l.Error("Not dispatching workflow %s for repo %s: %v", workflow.Path, repo, err)
+				results[j].Status = DispatchStatusFailed
+				results[j].Error = err.Error()
+				continue
+			}
+			passed++
+		}
+		if createTag && passed > 0 {
+			if err := githubRepo.CreateTag(ctx, variables.Owner, repo, variables.RCVersion, sha); err != nil {
+				l.Error("Error creating release tag %s in repo %s: %v", variables.RCVersion, repo, err)
+				for j := range results {
+					if results[j].Status == DispatchStatusDispatched {
+						results[j].Status = DispatchStatusFailed
+						results[j].Error = fmt.Sprintf("error creating release tag: %v", err)
+					}
+				}
+			}
+		}
+
+		for j, workflow := range workflows {
+			result := &results[j]
+			if result.Status != DispatchStatusDispatched {
+				continue
+			}
+			result.DispatchedAt = time.Now()
+			if wait {
+				result.priorRunIDs = priorWorkflowRuns(ctx, l, githubRepo, variables, repo, workflow.ID, ref, result.DispatchedAt)
+			}
+			if err := githubRepo.CreateWorkflowDispatchEventByID(ctx, variables.Owner, repo, ref, workflow.ID, payload); err != nil {
+				l.Error("Error dispatching workflow %s for repo %s: %v", workflow.Path, repo, err)
+				result.Status = DispatchStatusFailed
+				result.Error = err.Error()
+			}
+		}
+		repoResults[i] = results
+		dispatched := 0
+		for _, result := range repoResults[i] {
+			if result.Status == DispatchStatusDispatched {
+				dispatched++
 			}
 		}
-		l.Info("Production workflow dispatched for repo %s to Environment %s", repo, variables.Environment)
+		if dispatched == len(repoResults[i]) {
+			l.Info("Production workflow dispatched for repo %s to Environment %s", repo, environment)
+		} else {
+			l.Warn("Dispatched %d of %d production workflow(s) for repo %s to Environment %s", dispatched, len(repoResults[i]), repo, environment)
+		}
+	})
+
+	var results []WorkflowDispatchResult
+	for i := range repoList {
+		results = append(results, repoResults[i]...)
 	}
-	slackpayload, err = utils.ProductionWorkflowDispatchSlackPayloadBuilder(variables.RCVersion, repoList, variables.Environment)
+
+	if wait {
+		WaitForWorkflowRuns(ctx, l, githubRepo, variables, results)
+	}
+	return results
+}
+
+// resolveProductionRef returns the full ref to dispatch the production workflows of a repo on and the commit it points at.
+// With production_ref tag, it is the release tag to create from the head of the production branch, which createTag reports.
+// A repo being rolled back is dispatched on releaseTag, the existing tag of the release it is rolled back to.
+func resolveProductionRef(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repo string, releaseTag string) (ref string, sha string, createTag bool, err error) {
+	if releaseTag != "" {
+		sha, err = githubRepo.GetTagSHA(ctx, variables.Owner, repo, releaseTag)
+		if err != nil {
+			return "", "", false, fmt.Errorf("error resolving release tag %s: %v", releaseTag, err)
+		}
+		if sha == "" {
+			l.Error("Release tag %s of repo %s not found", releaseTag, repo)
+			return "", "", false, fmt.Errorf("release tag %s not found", releaseTag)
+		}
+		return "refs/tags/" + releaseTag, sha, false, nil
+	}
+
+	sha, err = githubRepo.GetBranchSHA(ctx, variables.Owner, repo, variables.ProductionBranch)
 	if err != nil {
-		l.Error("Error building slack payload: %v", err)
-		return "", fmt.Errorf("error building slack payload: %v", err)
+		l.Error("Error resolving production branch %s of repo %s: %v", variables.ProductionBranch, repo, err)
+		return "", "", false, fmt.Errorf("error resolving production branch: %v", err)
 	}
-	l.Info("Production workflow dispatched")
+	if variables.ProductionRef != configs.ProductionRefTag {
+		return "refs/heads/" + variables.ProductionBranch, sha, false, nil
+	}
+	return "refs/tags/" + variables.RCVersion, sha, true, nil
+}
 
-	return slackpayload, nil
+// checkWorkflowInputs validates the inputs against the workflow_dispatch inputs the workflow file declares at the commit being dispatched
+func checkWorkflowInputs(ctx context.Context, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repo string, workflow githubrepo.RespWorkflow, sha string, inputs map[string]interface{}) error {
+	content, err := githubRepo.GetFileContent(ctx, variables.Owner, repo, workflow.Path, sha)
+	if err != nil {
+		return fmt.Errorf("error reading workflow file: %v", err)
+	}
+	declared, err := parseWorkflowDispatchInputs(content)
+	if err != nil {
+		return err
+	}
+	if err := validateWorkflowInputs(declared, inputs); err != nil {
+		return fmt.Errorf("invalid workflow inputs: %v", err)
+	}
+	return nil
+}
+
+// dispatchFailures describes every failed dispatch and every waited for run that did not succeed
+func dispatchFailures(results []WorkflowDispatchResult) []string {
+	var failures []string
+	for _, result := range results {
+		if result.Status == DispatchStatusFailed {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Repo, result.Error))
+		} else if runFailed(result) {
+			failures = append(failures, fmt.Sprintf("%s: workflow %s run %s", result.Repo, result.WorkflowPath, result.Conclusion))
+		}
+	}
+	return failures
+}
+
+// repoDispatchOutcome merges the dispatch results of one repo into the commit dispatched, whether the repo was skipped
+// for lack of a production workflow, and its failures, empty if there were none. A repo without any result was left
+// undispatched by dispatchErr.
+func repoDispatchOutcome(repo string, results []WorkflowDispatchResult, dispatchErr error) (sha string, skipped bool, failure string) {
+	var errs []string
+	dispatched := false
+	for _, result := range results {
+		if result.Repo != repo {
+			continue
+		}
+		dispatched = true
+		sha = result.SHA
+		switch {
+		case result.Status == DispatchStatusSkippedNoWorkflow:
+			skipped = true
+		case result.Status == DispatchStatusFailed:
+			errs = append(errs, result.Error)
+		case result.Status == DispatchStatusAborted:
+			errs = append(errs, "not dispatched, an earlier wave failed")
+		case runFailed(result):
+			errs = append(errs, fmt.Sprintf("workflow %s run %s", result.WorkflowPath, result.Conclusion))
+		}
+	}
+	if !dispatched && dispatchErr != nil {
+		errs = append(errs, fmt.Sprintf("not dispatched: %v", dispatchErr))
+	}
+	return sha, skipped, strings.Join(errs, "; ")
+}
+
+func dispatchItem(result WorkflowDispatchResult) map[string]interface{} {
+	return map[string]interface{}{
+		"repo":       result.Repo,
+		"workflow":   result.WorkflowName,
+		"status":     result.Status,
+		"error":      result.Error,
+		"conclusion": result.Conclusion,
+		"run_url":    result.RunURL,
+		"ref":        githubrepo.ShortRefName(result.Ref),
+		"sha":        result.SHA,
+	}
 }
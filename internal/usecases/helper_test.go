@@ -0,0 +1,201 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"testing"
+)
+
+const prodWorkflowPath = ".github/workflows/prod-release.yml"
+
+func TestProductionWorkflowDispatch(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup adds the repos to dispatch
+		setup         func(fake *githubrepo.FakeGithubRepo)
+		repos         []string
+		productionRef string
+		wait          bool
+		wantStatuses  []string
+		wantRefs      []string
+		wantErr       bool
+		wantTag       map[string]bool
+	}{
+		{
+			name: "dispatches every repo on the production branch",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a1", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.AddRepo("web").AddBranch("main", "w1", true).AddWorkflow(2, "Prod", prodWorkflowPath)
+			},
+			repos:        []string{"api", "web"},
+			wantStatuses: []string{DispatchStatusDispatched, DispatchStatusDispatched},
+			wantRefs:     []string{"refs/heads/main", "refs/heads/main"},
+		},
+		{
+			name: "carries on past failures",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a1", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.AddRepo("bad")
+				fake.AddRepo("docs").AddBranch("main", "d1", true)
+				fake.AddRepo("web").AddBranch("main", "w1", true).AddWorkflow(2, "Prod", prodWorkflowPath)
+				fake.FailOn("ListWorkFlowsByRepoFileFilter", "bad", errors.New("boom"))
+			},
+			repos:        []string{"api", "bad", "docs", "web"},
+			wantStatuses: []string{DispatchStatusDispatched, DispatchStatusFailed, DispatchStatusSkippedNoWorkflow, DispatchStatusDispatched},
+			wantRefs:     []string{"refs/heads/main", "", "", "refs/heads/main"},
+			wantErr:      true,
+		},
+		{
+			name: "reports a failed dispatch",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a1", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.FailOn("CreateWorkflowDispatchEventByID", "api", errors.New("boom"))
+			},
+			repos:        []string{"api"},
+			wantStatuses: []string{DispatchStatusFailed},
+			wantRefs:     []string{"refs/heads/main"},
+			wantErr:      true,
+		},
+		{
+			name: "creates the release tag before dispatching on it",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a1", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+			},
+			repos:         []string{"api"},
+			productionRef: configs.ProductionRefTag,
+			wantStatuses:  []string{DispatchStatusDispatched},
+			wantRefs:      []string{"refs/tags/v1.1.0"},
+			wantTag:       map[string]bool{"api": true},
+		},
+		{
+			name: "creates no tag when every workflow rejects the inputs",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a1", true).
+					AddFile(prodWorkflowPath, "on:\n  workflow_dispatch:\n    inputs:\n      version:\n        required: true\n").
+					AddWorkflow(1, "Prod", prodWorkflowPath)
+			},
+			repos:         []string{"api"},
+			productionRef: configs.ProductionRefTag,
+			wantStatuses:  []string{DispatchStatusFailed},
+			wantRefs:      []string{"refs/tags/v1.1.0"},
+			wantErr:       true,
+			wantTag:       map[string]bool{"api": false},
+		},
+		{
+			name: "fails on a failed run when waiting",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a1", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.Repo("api").RunConclusions[1] = "failure"
+			},
+			repos:        []string{"api"},
+			wait:         true,
+			wantStatuses: []string{DispatchStatusDispatched},
+			wantRefs:     []string{"refs/heads/main"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			tt.setup(fake)
+			cfg := testConfig()
+			cfg.WaitForWorkflows = tt.wait
+			if tt.productionRef != "" {
+				cfg.ProductionRef = tt.productionRef
+			}
+
+			results, payload, err := ProductionWorkflowDispatch(context.Background(), testLogger(), fake, cfg, tt.repos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProductionWorkflowDispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if payload == "" {
+				t.Error("ProductionWorkflowDispatch() returned no Slack payload")
+			}
+			if len(results) != len(tt.wantStatuses) {
+				t.Fatalf("got %d results, want %d: %+v", len(results), len(tt.wantStatuses), results)
+			}
+			for i, result := range results {
+				if result.Repo != tt.repos[i] || result.Status != tt.wantStatuses[i] || result.Ref != tt.wantRefs[i] {
+					t.Errorf("results[%d] = %s %s %s, want %s %s %s", i, result.Repo, result.Status, result.Ref, tt.repos[i], tt.wantStatuses[i], tt.wantRefs[i])
+				}
+			}
+			for repo, want := range tt.wantTag {
+				if _, got := fake.Repo(repo).Tags[cfg.RCVersion]; got != want {
+					t.Errorf("tag %s exists in %s = %v, want %v", cfg.RCVersion, repo, got, want)
+				}
+			}
+			if tt.wait && results[0].Conclusion != "failure" {
+				t.Errorf("conclusion = %q, want failure", results[0].Conclusion)
+			}
+		})
+	}
+}
+
+func TestProductionWorkflowDispatchInputs(t *testing.T) {
+	fake := githubrepo.NewFakeGithubRepo()
+	fake.AddRepo("api").AddBranch("main", "a1", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+	cfg := testConfig()
+
+	if _, _, err := ProductionWorkflowDispatch(context.Background(), testLogger(), fake, cfg, []string{"api"}); err != nil {
+		t.Fatalf("ProductionWorkflowDispatch() error = %v", err)
+	}
+	if len(fake.Dispatches) != 1 {
+		t.Fatalf("got %d dispatches, want 1", len(fake.Dispatches))
+	}
+	inputs := fake.Dispatches[0].Inputs
+	if inputs["environment"] != "production" || inputs["release_version"] != "v1.1.0" {
+		t.Errorf("inputs = %v, want environment production and release_version v1.1.0", inputs)
+	}
+}
+
+func TestRepoDispatchOutcome(t *testing.T) {
+	dispatchErr := errors.New("production workflow dispatch failed")
+	tests := []struct {
+		name        string
+		results     []WorkflowDispatchResult
+		dispatchErr error
+		wantSHA     string
+		wantSkipped bool
+		wantFailure string
+	}{
+		{
+			name:    "dispatched",
+			results: []WorkflowDispatchResult{{Repo: "api", Status: DispatchStatusDispatched, SHA: "a1"}, {Repo: "web", Status: DispatchStatusFailed, Error: "boom"}},
+			wantSHA: "a1",
+		},
+		{
+			name:        "no production workflow",
+			results:     []WorkflowDispatchResult{{Repo: "api", Status: DispatchStatusSkippedNoWorkflow}},
+			wantSkipped: true,
+		},
+		{
+			name: "every failure",
+			results: []WorkflowDispatchResult{
+				{Repo: "api", Status: DispatchStatusFailed, Error: "boom"},
+				{Repo: "api", Status: DispatchStatusDispatched, WorkflowPath: prodWorkflowPath, Conclusion: "failure", SHA: "a1"},
+			},
+			wantSHA:     "a1",
+			wantFailure: "boom; workflow " + prodWorkflowPath + " run failure",
+		},
+		{
+			name:        "aborted wave",
+			results:     []WorkflowDispatchResult{{Repo: "api", Status: DispatchStatusAborted}},
+			wantFailure: "not dispatched, an earlier wave failed",
+		},
+		{
+			name:        "left undispatched by the dispatch error",
+			dispatchErr: dispatchErr,
+			wantFailure: "not dispatched: production workflow dispatch failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sha, skipped, failure := repoDispatchOutcome("api", tt.results, tt.dispatchErr)
+			if sha != tt.wantSHA || skipped != tt.wantSkipped || failure != tt.wantFailure {
+				t.Errorf("repoDispatchOutcome() = %q, %v, %q, want %q, %v, %q", sha, skipped, failure, tt.wantSHA, tt.wantSkipped, tt.wantFailure)
+			}
+		})
+	}
+}
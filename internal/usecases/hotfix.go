@@ -0,0 +1,265 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+	"time"
+)
+
+// Statuses of HotfixResult
+const (
+	HotfixStatusBranchCreated      = "branch-created"
+	HotfixStatusNoChanges          = "no-changes"
+	HotfixStatusPullRequestsOpened = "pull-requests-opened"
+	// HotfixStatusNotAffected marks repos whose hotfix branch was not merged into the production branch
+	HotfixStatusNotAffected = "not-affected"
+	HotfixStatusReleased    = "released"
+	HotfixStatusFailed      = "failed"
+)
+
+// HotfixResult is the outcome of a hotfix stage in one repo
+type HotfixResult struct {
+	Repo             string `json:"repo"`
+	Branch           string `json:"branch"`
+	Status           string `json:"status"`
+	ProductionPRURL  string `json:"production_pr_url,omitempty"`
+	DevelopmentPRURL string `json:"development_pr_url,omitempty"`
+	HasConflicts     bool   `json:"has_conflicts,omitempty"`
+	// SHA is the commit released
+	SHA   string `json:"sha,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// CreateHotfixBranches cuts the hotfix branch from the production branch in every repo
+func CreateHotfixBranches(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) []HotfixResult {
+	results := make([]HotfixResult, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		repo := repoList[i]
+		results[i] = HotfixResult{Repo: repo, Branch: cfg.HotfixBranch, Status: HotfixStatusBranchCreated}
+
+		l.Info("Creating hotfix branch '%s' in repo '%s' from '%s'", cfg.HotfixBranch, repo, cfg.ProductionBranch)
+		if err := githubRepo.CreateBranch(ctx, cfg.Owner, repo, cfg.ProductionBranch, cfg.HotfixBranch); err != nil {
+			results[i].Status = HotfixStatusFailed
+			results[i].Error = err.Error()
+		}
+	})
+	return results
+}
+
+// OpenHotfixPullRequests opens pull requests from the hotfix branch into the production and development branches
+// of every repo where the fix landed. Repos whose hotfix branch has nothing the production branch lacks are skipped.
+func OpenHotfixPullRequests(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) []HotfixResult {
+	title := fmt.Sprintf("Hotfix %s", cfg.RCVersion)
+
+	results := make([]HotfixResult, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		repo := repoList[i]
+		result := HotfixResult{Repo: repo, Branch: cfg.HotfixBranch, Status: HotfixStatusFailed}
+		defer func() { results[i] = result }()
+
+		comparison, err := githubRepo.CompareCommits(ctx, cfg.Owner, repo, cfg.ProductionBranch, cfg.HotfixBranch)
+		if err != nil {
+			result.Error = err.Error()
+			return
+		}
+		if len(comparison.Commits) == 0 {
+			l.Info("Hotfix branch '%s' in repo '%s' has no changes", cfg.HotfixBranch, repo)
+			result.Status = HotfixStatusNoChanges
+			return
+		}
+
+		var warnings []string
+		for _, base := range []string{cfg.ProductionBranch, cfg.DevelopmentBranch} {
+			l.Info("Creating PR from '%s' to '%s' in repo '%s'", cfg.HotfixBranch, base, repo)
+			body := fmt.Sprintf("Hotfix %s from %s into %s", cfg.RCVersion, cfg.HotfixBranch, base)
+			prURL, prError, hasConflicts, err := githubRepo.CreatePullRequest(ctx, cfg.Owner, repo, cfg.HotfixBranch, base, title, body)
+			if err != nil {
+				result.Error = fmt.Sprintf("%s->%s: %v", cfg.HotfixBranch, base, err)
+				return
+			}
+			if prError != "" {
+				l.Warn("PR created with warning from '%s' to '%s' in repo '%s': %s", cfg.HotfixBranch, base, repo, prError)
+				warnings = append(warnings, prError)
+			}
+			if base == cfg.ProductionBranch {
+				result.ProductionPRURL = prURL
+			} else {
+				result.DevelopmentPRURL = prURL
+			}
+			result.HasConflicts = result.HasConflicts || hasConflicts
+		}
+		result.Status = HotfixStatusPullRequestsOpened
+		result.Error = strings.Join(warnings, "; ")
+	})
+	return results
+}
+
+// hotfixAffected reports whether the hotfix branch of a repo was merged into the production branch. The pull request
+// is looked up by branch name, as the branch itself is often deleted once merged.
+func hotfixAffected(ctx context.Context, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) (bool, error) {
+	pulls, err := githubRepo.ListMergedPullRequestsByHead(ctx, cfg.Owner, repo, cfg.HotfixBranch, cfg.ProductionBranch)
+	if err != nil {
+		return false, err
+	}
+	return len(pulls) > 0, nil
+}
+
+// ReleaseHotfix dispatches the production workflows of the repos whose hotfix pull request into the production branch
+// was merged. It returns the hotfix results in repoList order, the dispatch results of the affected repos, and an
+// error listing the failures.
+func ReleaseHotfix(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) ([]HotfixResult, []WorkflowDispatchResult, error) {
+	results := make([]HotfixResult, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		results[i] = HotfixResult{Repo: repoList[i], Branch: cfg.HotfixBranch, Status: HotfixStatusReleased}
+		affected, err := hotfixAffected(ctx, githubRepo, cfg, repoList[i])
+		if err != nil {
+			results[i].Status = HotfixStatusFailed
+			results[i].Error = err.Error()
+		} else if !affected {
+			l.Info("Hotfix branch '%s' of repo '%s' was not merged into '%s', not releasing it", cfg.HotfixBranch, repoList[i], cfg.ProductionBranch)
+			results[i].Status = HotfixStatusNotAffected
+		}
+	})
+
+	var affectedRepos []string
+	for _, result := range results {
+		if result.Status == HotfixStatusReleased {
+			affectedRepos = append(affectedRepos, result.Repo)
+		}
+	}
+	var dispatchResults []WorkflowDispatchResult
+	var dispatchErr error
+	if len(affectedRepos) > 0 {
+		l.Info("Releasing the repos affected by the hotfix: %v", affectedRepos)
+		dispatch := ProductionWorkflowDispatch
+		if len(cfg.Waves) > 0 {
+			dispatch = ProductionWaveDispatch
+		}
+		// The failures of the dispatched repos are reported per repo below
+		dispatchResults, _, dispatchErr = dispatch(ctx, l, githubRepo, cfg, affectedRepos)
+	} else {
+		l.Info("No repo has merged hotfix '%s', nothing to release", cfg.HotfixBranch)
+	}
+
+	for i := range results {
+		if results[i].Status != HotfixStatusReleased {
+			continue
+		}
+		var failure string
+		results[i].SHA, _, failure = repoDispatchOutcome(results[i].Repo, dispatchResults, dispatchErr)
+		if failure != "" {
+			results[i].Status = HotfixStatusFailed
+			results[i].Error = failure
+		}
+	}
+
+	var failures []string
+	for _, result := range results {
+		if result.Status == HotfixStatusFailed {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Repo, result.Error))
+		}
+	}
+	if len(failures) > 0 {
+		return results, dispatchResults, fmt.Errorf("hotfix release failed in %d repo(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	if dispatchErr != nil {
+		return results, dispatchResults, fmt.Errorf("hotfix release failed: %v", dispatchErr)
+	}
+	return results, dispatchResults, nil
+}
+
+// HotfixUseCase runs one stage of a hotfix across the selected repos: cfg.HotfixStage is branch, pull-requests or release
+func HotfixUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) {
+	l.Info("Hotfix use case, stage %s", cfg.HotfixStage)
+
+	repoList, err := githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
+	if err != nil {
+		l.Fatal("Error listing repositories: %v", err)
+	}
+	l.Info("repoList: %v", repoList)
+
+	var results []HotfixResult
+	var dispatchResults []WorkflowDispatchResult
+	var releaseErr error
+	freezeNotice := ""
+	switch cfg.HotfixStage {
+	case configs.HotfixStageBranch:
+		results = CreateHotfixBranches(ctx, l, githubRepo, cfg, repoList)
+	case configs.HotfixStagePullRequests:
+		results = OpenHotfixPullRequests(ctx, l, githubRepo, cfg, repoList)
+	case configs.HotfixStageRelease:
+		freezeNotice = checkReleaseFreeze(l, cfg, time.Now())
+		// Failures are reported from the results like those of the other stages, and releaseErr covers the rest
+		results, dispatchResults, releaseErr = ReleaseHotfix(ctx, l, githubRepo, cfg, repoList)
+		if dispatchResultsJSON, jsonErr := json.Marshal(dispatchResults); jsonErr != nil {
+			l.Error("Error marshalling dispatch results: %v", jsonErr)
+		} else {
+			safeSetOutput("dispatch_results", string(dispatchResultsJSON), cfg, l)
+		}
+	}
+
+	// Dispatched repos are reported by their dispatches
+	dispatched := make(map[string]bool)
+	for _, dispatchResult := range dispatchResults {
+		dispatched[dispatchResult.Repo] = true
+	}
+	var hotfixItems []map[string]interface{}
+	var failures []string
+	for _, result := range results {
+		if result.Status == HotfixStatusFailed {
+			l.Error("Hotfix %s failed in repo %s: %s", cfg.HotfixStage, result.Repo, result.Error)
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Repo, result.Error))
+		}
+		if dispatched[result.Repo] {
+			continue
+		}
+		hotfixItems = append(hotfixItems, map[string]interface{}{
+			"repo":               result.Repo,
+			"branch":             result.Branch,
+			"status":             result.Status,
+			"production_pr_url":  result.ProductionPRURL,
+			"development_pr_url": result.DevelopmentPRURL,
+			"hasConflicts":       result.HasConflicts,
+			"error":              result.Error,
+		})
+	}
+	for _, dispatchResult := range dispatchResults {
+		hotfixItems = append(hotfixItems, dispatchItem(dispatchResult))
+	}
+
+	if resultsJSON, jsonErr := json.Marshal(results); jsonErr != nil {
+		l.Error("Error marshalling hotfix results: %v", jsonErr)
+	} else {
+		safeSetOutput("hotfix_results", string(resultsJSON), cfg, l)
+	}
+	if len(hotfixItems) > 0 {
+		slackPayload, slackErr := utils.HotfixSlackPayloadBuilder(cfg.RCVersion, cfg.HotfixStage, hotfixItems)
+		if slackErr != nil {
+			l.Error("Error building hotfix slack payload: %v", slackErr)
+		} else {
+			setSlackPayloadOutput("slack_payload", withFreezeNotice(l, slackPayload, freezeNotice), cfg, l)
+		}
+	}
+
+	if len(failures) > 0 {
+		l.Fatal("Hotfix %s failed in %d repo(s): %s", cfg.HotfixStage, len(failures), strings.Join(failures, "; "))
+	}
+	if releaseErr != nil {
+		l.Fatal("%v", releaseErr)
+	}
+
+	if cfg.HotfixStage == configs.HotfixStageRelease && cfg.CreateReleases && len(dispatchResults) > 0 {
+		var releasedRepos []string
+		for _, result := range results {
+			if result.Status == HotfixStatusReleased {
+				releasedRepos = append(releasedRepos, result.Repo)
+			}
+		}
+		publishGitHubReleases(ctx, l, githubRepo, cfg, releasedRepos, releaseSHAs(dispatchResults))
+	}
+}
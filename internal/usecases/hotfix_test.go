@@ -0,0 +1,82 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"release-candidate/internal/usecases/githubrepo"
+	"testing"
+)
+
+func TestReleaseHotfix(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup adds the api repo
+		setup          func(fake *githubrepo.FakeGithubRepo)
+		wantStatus     string
+		wantDispatches int
+		wantErr        bool
+	}{
+		{
+			name: "releases a merged hotfix whose branch was deleted",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.AddPullRequest("api", "hotfix/v1.1.0", "main").Merged = true
+			},
+			wantStatus:     HotfixStatusReleased,
+			wantDispatches: 1,
+		},
+		{
+			name: "leaves a repo whose hotfix was only merged into development",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true).AddBranch("hotfix/v1.1.0", "a2", false).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.AddPullRequest("api", "hotfix/v1.1.0", "main")
+				fake.AddPullRequest("api", "hotfix/v1.1.0", "development").Merged = true
+			},
+			wantStatus: HotfixStatusNotAffected,
+		},
+		{
+			name: "fails on a failed dispatch",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.AddPullRequest("api", "hotfix/v1.1.0", "main").Merged = true
+				fake.FailOn("CreateWorkflowDispatchEventByID", "api", errors.New("boom"))
+			},
+			wantStatus: HotfixStatusFailed,
+			wantErr:    true,
+		},
+		{
+			name: "fails when the pull requests cannot be listed",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a2", true).AddWorkflow(1, "Prod", prodWorkflowPath)
+				fake.FailOn("ListMergedPullRequestsByHead", "api", errors.New("boom"))
+			},
+			wantStatus: HotfixStatusFailed,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			tt.setup(fake)
+			cfg := testConfig()
+			cfg.UseCase = "Hotfix"
+
+			results, _, err := ReleaseHotfix(context.Background(), testLogger(), fake, cfg, []string{"api"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReleaseHotfix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(results) != 1 || results[0].Status != tt.wantStatus {
+				t.Fatalf("results = %+v, want one %s result", results, tt.wantStatus)
+			}
+			if tt.wantStatus == HotfixStatusReleased && results[0].SHA != "a2" {
+				t.Errorf("released SHA = %q, want a2", results[0].SHA)
+			}
+			if tt.wantStatus == HotfixStatusFailed && results[0].Error == "" {
+				t.Error("failed result has no error")
+			}
+			if len(fake.Dispatches) != tt.wantDispatches {
+				t.Errorf("got %d dispatches, want %d", len(fake.Dispatches), tt.wantDispatches)
+			}
+		})
+	}
+}
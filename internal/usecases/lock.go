@@ -0,0 +1,194 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReleaseLock is the lock file a run commits to the control repo while it changes the repos of an organization
+type ReleaseLock struct {
+	// ID tells the run holding the lock from others of the same holder
+	ID        string `json:"id"`
+	Owner     string `json:"owner"`
+	UseCase   string `json:"use_case"`
+	RCVersion string `json:"rc_version"`
+	// Holder is the GitHub actor who started the run, or the local user of a CLI run
+	Holder     string    `json:"holder"`
+	RunURL     string    `json:"run_url,omitempty"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// lockAttempts bounds the retries when other runs change the lock file between reading and committing it
+const lockAttempts = 3
+
+// lockRenewals is how many times per lock_ttl a run renews its lock, so that a few failed renewals don't let it expire
+const lockRenewals = 3
+
+// lockLocation returns the owner and name of the control repo and the path of the lock file of cfg.Owner
+func lockLocation(cfg *configs.Config) (string, string, string) {
+	owner, repo, ok := strings.Cut(cfg.LockRepository, "/")
+	if !ok {
+		owner, repo = cfg.Owner, cfg.LockRepository
+	}
+	return owner, repo, fmt.Sprintf(".release-wave/locks/%s.json", strings.ToLower(cfg.Owner))
+}
+
+func (lock ReleaseLock) String() string {
+	description := fmt.Sprintf("%s %s by %s since %s", lock.UseCase, lock.RCVersion, lock.Holder, lock.AcquiredAt.Format(time.RFC3339))
+	if lock.RunURL != "" {
+		description += " (" + lock.RunURL + ")"
+	}
+	return description
+}
+
+// newReleaseLock describes the current run, from the GitHub Actions environment or the local user
+func newReleaseLock(cfg *configs.Config, now time.Time) (ReleaseLock, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return ReleaseLock{}, fmt.Errorf("error generating the lock id: %v", err)
+	}
+	holder := os.Getenv("GITHUB_ACTOR")
+	if holder == "" {
+		holder = os.Getenv("USER")
+	}
+	if hostname, err := os.Hostname(); err == nil && os.Getenv("GITHUB_ACTIONS") != "true" {
+		holder += "@" + hostname
+	}
+	runURL := ""
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		runURL = fmt.Sprintf("%s/%s/actions/runs/%s", os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), runID)
+	}
+	return ReleaseLock{
+		ID:         hex.EncodeToString(id),
+		Owner:      cfg.Owner,
+		UseCase:    cfg.UseCase,
+		RCVersion:  cfg.RCVersion,
+		Holder:     holder,
+		RunURL:     runURL,
+		AcquiredAt: now.UTC(),
+		ExpiresAt:  now.Add(cfg.LockTTL).UTC(),
+	}, nil
+}
+
+// AcquireReleaseLock takes the release lock of cfg.Owner by committing a lock file to the control repo, which fails
+// while another run holds it. A lock past its expiry is taken over, since the run holding it is presumed dead, so
+// the lock is renewed in the background until it is released. It returns the function releasing the lock, which can
+// be called more than once.
+func AcquireReleaseLock(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) (func(), error) {
+	lockOwner, lockRepo, lockPath := lockLocation(cfg)
+	lock, err := newReleaseLock(cfg, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling the release lock: %v", err)
+	}
+
+	for attempt := 1; attempt <= lockAttempts; attempt++ {
+		current, err := githubRepo.GetFile(ctx, lockOwner, lockRepo, lockPath, cfg.LockBranch)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the release lock: %v", err)
+		}
+		message := fmt.Sprintf("Lock %s for %s %s", cfg.Owner, cfg.UseCase, cfg.RCVersion)
+		if current.SHA != "" {
+			var held ReleaseLock
+			if err := json.Unmarshal([]byte(current.Content), &held); err != nil {
+				l.Warn("Taking over the unreadable release lock %s/%s/%s: %v", lockOwner, lockRepo, lockPath, err)
+			} else if time.Now().Before(held.ExpiresAt) {
+				return nil, fmt.Errorf("the release lock of %s is held by %s until %s", cfg.Owner, held, held.ExpiresAt.Format(time.RFC3339))
+			} else {
+				l.Warn("Taking over the stale release lock of %s held by %s, which expired at %s", cfg.Owner, held, held.ExpiresAt.Format(time.RFC3339))
+			}
+			message = fmt.Sprintf("Take over the stale lock of %s for %s %s", cfg.Owner, cfg.UseCase, cfg.RCVersion)
+		}
+
+		sha, err := githubRepo.PutFile(ctx, lockOwner, lockRepo, lockPath, cfg.LockBranch, message, string(content), current.SHA)
+		if errors.Is(err, githubrepo.ErrFileChanged) {
+			l.Info("The release lock changed while acquiring it (attempt %d of %d)", attempt, lockAttempts)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error writing the release lock: %v", err)
+		}
+		l.Info("Acquired the release lock of %s in %s/%s until %s", cfg.Owner, lockOwner, lockRepo, lock.ExpiresAt.Format(time.RFC3339))
+
+		stop, renewed := make(chan struct{}), make(chan string)
+		go renewReleaseLock(ctx, l, githubRepo, cfg, lock, sha, stop, renewed)
+		var once sync.Once
+		return func() {
+			once.Do(func() {
+				close(stop)
+				releaseReleaseLock(ctx, l, githubRepo, cfg, lock.ID, <-renewed)
+			})
+		}, nil
+	}
+	return nil, fmt.Errorf("the release lock of %s kept changing while acquiring it, another run is starting", cfg.Owner)
+}
+
+// renewReleaseLock pushes back the expiry of the lock every lock_ttl/lockRenewals until stop is closed, then sends
+// the sha of the last version of the lock file to renewed, or "" once another run took the lock over
+func renewReleaseLock(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, lock ReleaseLock, sha string, stop <-chan struct{}, renewed chan<- string) {
+	lockOwner, lockRepo, lockPath := lockLocation(cfg)
+	ticker := time.NewTicker(cfg.LockTTL / lockRenewals)
+	defer ticker.Stop()
+	for sha != "" {
+		select {
+		case <-stop:
+			renewed <- sha
+			return
+		case now := <-ticker.C:
+			renewal := lock
+			renewal.ExpiresAt = now.Add(cfg.LockTTL).UTC()
+			content, err := json.MarshalIndent(renewal, "", "  ")
+			if err != nil {
+				l.Error("Error marshalling the release lock: %v", err)
+				continue
+			}
+			message := fmt.Sprintf("Renew the lock of %s for %s %s", cfg.Owner, cfg.UseCase, cfg.RCVersion)
+			newSHA, err := githubRepo.PutFile(ctx, lockOwner, lockRepo, lockPath, cfg.LockBranch, message, string(content), sha)
+			switch {
+			case errors.Is(err, githubrepo.ErrFileChanged):
+				l.Warn("The release lock of %s expired and was taken over by another run, consider a longer lock_ttl", cfg.Owner)
+				sha = ""
+			case err != nil:
+				l.Warn("Error renewing the release lock of %s, held until %s unless a later renewal succeeds: %v", cfg.Owner, lock.ExpiresAt.Format(time.RFC3339), err)
+			default:
+				lock, sha = renewal, newSHA
+				l.Debug("Renewed the release lock of %s until %s", cfg.Owner, lock.ExpiresAt.Format(time.RFC3339))
+			}
+		}
+	}
+	<-stop
+	renewed <- ""
+}
+
+// releaseReleaseLock deletes the lock file, unless another run took it over after it expired, which leaves sha empty
+func releaseReleaseLock(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, id string, sha string) {
+	if sha == "" {
+		return
+	}
+	lockOwner, lockRepo, lockPath := lockLocation(cfg)
+	message := fmt.Sprintf("Unlock %s after %s %s", cfg.Owner, cfg.UseCase, cfg.RCVersion)
+	err := githubRepo.DeleteFile(ctx, lockOwner, lockRepo, lockPath, cfg.LockBranch, message, sha)
+	switch {
+	case errors.Is(err, githubrepo.ErrFileChanged):
+		l.Warn("The release lock of %s expired and was taken over before this run released it, consider a longer lock_ttl", cfg.Owner)
+	case err != nil:
+		l.Error("Error releasing the release lock of %s (lock %s), if it is still there it is held until it expires: %v", cfg.Owner, id, err)
+	default:
+		l.Info("Released the release lock of %s", cfg.Owner)
+	}
+}
@@ -0,0 +1,159 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"release-candidate/internal/usecases/githubrepo"
+	"strings"
+	"testing"
+	"time"
+)
+
+const lockPath = ".release-wave/locks/o.json"
+
+func TestAcquireReleaseLock(t *testing.T) {
+	heldLock := func(expiresAt time.Time) string {
+		content, _ := json.Marshal(ReleaseLock{ID: "other", Owner: "o", UseCase: "Rollback", RCVersion: "v1.0.0", Holder: "bob", ExpiresAt: expiresAt})
+		return string(content)
+	}
+	tests := []struct {
+		name string
+		// current is the lock file already committed, if any
+		current string
+		// failPut fails committing the lock file
+		failPut error
+		wantErr string
+	}{
+		{name: "free"},
+		{name: "held", current: heldLock(time.Now().Add(time.Hour)), wantErr: "held by Rollback v1.0.0 by bob"},
+		{name: "stale", current: heldLock(time.Now().Add(-time.Minute))},
+		{name: "unreadable", current: "{"},
+		{name: "keeps changing", failPut: githubrepo.ErrFileChanged, wantErr: "kept changing"},
+		{name: "commit failure", failPut: errors.New("boom"), wantErr: "error writing the release lock: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			control := fake.AddRepo("release-control")
+			if tt.current != "" {
+				control.AddFile(lockPath, tt.current)
+			}
+			if tt.failPut != nil {
+				fake.FailOn("PutFile", "release-control", tt.failPut)
+			}
+			cfg := testConfig()
+			cfg.LockRepository = "release-control"
+
+			release, err := AcquireReleaseLock(context.Background(), testLogger(), fake, cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("AcquireReleaseLock() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if got := fake.Repo("release-control").Files[lockPath]; got != tt.current {
+					t.Errorf("lock file = %q, want it unchanged", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AcquireReleaseLock() error = %v", err)
+			}
+			var lock ReleaseLock
+			if err := json.Unmarshal([]byte(fake.Repo("release-control").Files[lockPath]), &lock); err != nil {
+				t.Fatalf("lock file is not a release lock: %v", err)
+			}
+			if lock.UseCase != cfg.UseCase || lock.RCVersion != cfg.RCVersion || !lock.ExpiresAt.After(time.Now()) {
+				t.Errorf("lock = %+v, want the lock of this run", lock)
+			}
+
+			release()
+			release()
+			if _, ok := fake.Repo("release-control").Files[lockPath]; ok {
+				t.Error("the lock file was not deleted on release")
+			}
+		})
+	}
+}
+
+func TestReleaseReleaseLockKeepsTakenOverLock(t *testing.T) {
+	fake := githubrepo.NewFakeGithubRepo()
+	fake.AddRepo("release-control")
+	cfg := testConfig()
+	cfg.LockRepository = "release-control"
+
+	release, err := AcquireReleaseLock(context.Background(), testLogger(), fake, cfg)
+	if err != nil {
+		t.Fatalf("AcquireReleaseLock() error = %v", err)
+	}
+	fake.Repo("release-control").Files[lockPath] = `{"id":"other"}`
+
+	release()
+	if got := fake.Repo("release-control").Files[lockPath]; got != `{"id":"other"}` {
+		t.Errorf("lock file = %q, want the lock of the other run", got)
+	}
+}
+
+func TestReleaseLockRenewal(t *testing.T) {
+	readLock := func(t *testing.T, fake *githubrepo.FakeGithubRepo) ReleaseLock {
+		t.Helper()
+		file, err := fake.GetFile(context.Background(), "o", "release-control", lockPath, "")
+		if err != nil {
+			t.Fatalf("GetFile() error = %v", err)
+		}
+		var lock ReleaseLock
+		if err := json.Unmarshal([]byte(file.Content), &lock); err != nil {
+			t.Fatalf("lock file is not a release lock: %v", err)
+		}
+		return lock
+	}
+	tests := []struct {
+		name string
+		// takeOver replaces the lock file with the lock of another run once it was renewed
+		takeOver bool
+	}{
+		{name: "renewed until released"},
+		{name: "taken over", takeOver: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			fake.AddRepo("release-control")
+			cfg := testConfig()
+			cfg.LockRepository = "release-control"
+			cfg.LockTTL = 30 * time.Millisecond
+
+			release, err := AcquireReleaseLock(context.Background(), testLogger(), fake, cfg)
+			if err != nil {
+				t.Fatalf("AcquireReleaseLock() error = %v", err)
+			}
+			acquired := readLock(t, fake)
+			time.Sleep(4 * cfg.LockTTL)
+			if renewed := readLock(t, fake); !renewed.ExpiresAt.After(acquired.ExpiresAt) || renewed.ID != acquired.ID {
+				t.Errorf("lock = %+v, want the lock %+v renewed", renewed, acquired)
+			}
+			if tt.takeOver {
+				// A renewal may land between reading and replacing the lock file
+				for attempt := 1; ; attempt++ {
+					file, _ := fake.GetFile(context.Background(), "o", "release-control", lockPath, "")
+					_, err := fake.PutFile(context.Background(), "o", "release-control", lockPath, "", "Take over", `{"id":"other"}`, file.SHA)
+					if err == nil {
+						break
+					}
+					if attempt == lockAttempts {
+						t.Fatalf("PutFile() error = %v", err)
+					}
+				}
+				time.Sleep(2 * cfg.LockTTL)
+			}
+
+			release()
+			got, ok := fake.Repo("release-control").Files[lockPath]
+			if tt.takeOver && got != `{"id":"other"}` {
+				t.Errorf("lock file = %q, want the lock of the other run", got)
+			}
+			if !tt.takeOver && ok {
+				t.Error("the lock file was not deleted on release")
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+	"time"
+)
+
+// versionBumps orders the bumps from the smallest
+var versionBumps = []string{configs.VersionBumpPatch, configs.VersionBumpMinor, configs.VersionBumpMajor}
+
+// repoVersion is the latest release of a repo and the bump its changes since call for
+type repoVersion struct {
+	latest *utils.Version
+	bump   string
+	err    error
+}
+
+// ResolveNextVersion replaces rc_version auto with the next version: the latest release across the selected repos
+// (or cfg.VersionSourceRepo) bumped by cfg.VersionBump. The version is also set as the rc_version output.
+func ResolveNextVersion(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) error {
+	repoList := []string{cfg.VersionSourceRepo}
+	if cfg.VersionSourceRepo == "" {
+		var err error
+		repoList, err = githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
+		if err != nil {
+			return fmt.Errorf("error listing repositories: %v", err)
+		}
+	}
+
+	versions := make([]repoVersion, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		versions[i] = latestRepoVersion(ctx, l, githubRepo, cfg, repoList[i])
+	})
+
+	var latest *utils.Version
+	bump := ""
+	var failures []string
+	for i, version := range versions {
+		if version.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", repoList[i], version.err))
+			continue
+		}
+		if version.latest != nil && (latest == nil || latest.Less(*version.latest)) {
+			latest = version.latest
+		}
+		if versionBumpRank(version.bump) > versionBumpRank(bump) {
+			bump = version.bump
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to find the latest release of %d repo(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+
+	switch {
+	case cfg.VersionBump != configs.VersionBumpAuto:
+		bump = cfg.VersionBump
+	case latest == nil:
+		// Nothing to compare with, so the first release is a minor one
+		bump = configs.VersionBumpMinor
+	}
+	switch {
+	case latest == nil && cfg.AllowCalVer:
+		// A calendar version before any year bumps to the first release of the current month
+		l.Info("No release found, starting from the first calendar version of the month")
+		latest = &utils.Version{CalVer: true}
+	case latest == nil:
+		l.Info("No release found, starting from v0.0.0")
+		latest = &utils.Version{}
+	}
+	if bump == "" {
+		l.Info("No changes since %s, bumping the patch version", latest)
+		bump = configs.VersionBumpPatch
+	}
+
+	next := latest.Bump(bump, time.Now())
+	l.Info("Next version: %s (%s bump from %s)", next, bump, latest)
+	cfg.RCVersion = next.String()
+	safeSetOutput("rc_version", cfg.RCVersion, cfg, l)
+	return nil
+}
+
+// latestRepoVersion finds the latest published release of a repo. Unless the bump is given, it is picked from the
+// conventional commit types of what was merged since into the branch being released: the development branch for
+// Release-Candidate and the production branch otherwise.
+func latestRepoVersion(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) repoVersion {
+	releases, err := githubRepo.ListReleases(ctx, cfg.Owner, repo)
+	if err != nil {
+		return repoVersion{err: err}
+	}
+	var latest *utils.Version
+	latestTag := ""
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+		version, err := utils.ParseVersion(release.TagName, cfg.AllowCalVer)
+		if err != nil {
+			l.Debug("Ignoring release %s of repo %s: %v", release.TagName, repo, err)
+			continue
+		}
+		if version.Prerelease != "" {
+			continue
+		}
+		if latest == nil || latest.Less(version) {
+			latest, latestTag = &version, release.TagName
+		}
+	}
+	if latest == nil {
+		l.Info("Repo %s has no release yet", repo)
+		return repoVersion{}
+	}
+	if cfg.VersionBump != configs.VersionBumpAuto {
+		return repoVersion{latest: latest}
+	}
+
+	head := cfg.ProductionBranch
+	if cfg.UseCase == "Release-Candidate" {
+		head = cfg.DevelopmentBranch
+	}
+	comparison, err := githubRepo.CompareCommits(ctx, cfg.Owner, repo, latestTag, head)
+	if err != nil {
+		return repoVersion{err: err}
+	}
+	entries, err := changelogEntries(ctx, githubRepo, cfg, repo, comparison.Commits)
+	if err != nil {
+		return repoVersion{err: err}
+	}
+
+	bump := ""
+	if len(entries) > 0 {
+		bump = configs.VersionBumpPatch
+	}
+	for _, entry := range entries {
+		if entry.Breaking {
+			bump = configs.VersionBumpMajor
+			break
+		}
+		if entry.Type == "feat" {
+			bump = configs.VersionBumpMinor
+		}
+	}
+	l.Info("Repo %s: latest release %s, %d change(s) since on %s call for a %s bump", repo, latestTag, len(entries), head, bumpOrNone(bump))
+	return repoVersion{latest: latest, bump: bump}
+}
+
+// versionBumpRank returns 0 for no bump and up to 3 for a major one
+func versionBumpRank(bump string) int {
+	for i, b := range versionBumps {
+		if b == bump {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func bumpOrNone(bump string) string {
+	if bump == "" {
+		return "no"
+	}
+	return bump
+}
@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"testing"
+	"time"
+)
+
+func TestResolveNextVersion(t *testing.T) {
+	firstCalVer := utils.Version{Major: time.Now().Year(), Minor: int(time.Now().Month()), CalVer: true}.String()
+
+	tests := []struct {
+		name string
+		// setup adds the api repo
+		setup       func(fake *githubrepo.FakeGithubRepo)
+		versionBump string
+		allowCalVer bool
+		want        string
+	}{
+		{
+			name:        "first release",
+			setup:       func(fake *githubrepo.FakeGithubRepo) { fake.AddRepo("api") },
+			versionBump: configs.VersionBumpAuto,
+			want:        "v0.1.0",
+		},
+		{
+			name:        "first calendar version starts at the current month",
+			setup:       func(fake *githubrepo.FakeGithubRepo) { fake.AddRepo("api") },
+			versionBump: configs.VersionBumpAuto,
+			allowCalVer: true,
+			want:        firstCalVer,
+		},
+		{
+			name: "given bump from the latest published release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddRelease("v1.2.0", false).AddRelease("v1.10.1", false).AddRelease("v2.0.0-rc.1", true).AddRelease("latest", false)
+			},
+			versionBump: configs.VersionBumpMajor,
+			want:        "v2.0.0",
+		},
+		{
+			name: "feature since the latest release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "c3", true).AddTag("v1.2.0", "c1").AddRelease("v1.2.0", false).
+					AddCommit("c1", "chore: release", "alice").AddCommit("c2", "fix: retry", "alice").AddCommit("c3", "feat: export", "bob")
+			},
+			versionBump: configs.VersionBumpAuto,
+			want:        "v1.3.0",
+		},
+		{
+			name: "breaking change since the latest release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "c2", true).AddTag("v1.2.0", "c1").AddRelease("v1.2.0", false).
+					AddCommit("c1", "chore: release", "alice").AddCommit("c2", "feat!: drop v1 endpoints", "bob")
+			},
+			versionBump: configs.VersionBumpAuto,
+			want:        "v2.0.0",
+		},
+		{
+			name: "feature pull request merged into the development branch",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "c2", true).AddTag("v1.2.0", "c1").AddRelease("v1.2.0", false).
+					AddCommit("c1", "chore: release", "alice").AddCommit("c2", "Add export (#1)", "bob")
+				pull := fake.AddPullRequest("api", "export", "development")
+				pull.Title, pull.Labels, pull.Merged, pull.MergeCommit = "Add export", []string{"enhancement"}, true, "c2"
+			},
+			versionBump: configs.VersionBumpAuto,
+			want:        "v1.3.0",
+		},
+		{
+			name: "no changes since the latest release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "c1", true).AddTag("v1.2.0", "c1").AddRelease("v1.2.0", false).
+					AddCommit("c1", "chore: release", "alice")
+			},
+			versionBump: configs.VersionBumpAuto,
+			want:        "v1.2.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			tt.setup(fake)
+			cfg := testConfig()
+			cfg.RCVersion = configs.RCVersionAuto
+			cfg.VersionSourceRepo = "api"
+			cfg.VersionBump = tt.versionBump
+			cfg.AllowCalVer = tt.allowCalVer
+
+			if err := ResolveNextVersion(context.Background(), testLogger(), fake, cfg); err != nil {
+				t.Fatalf("ResolveNextVersion() error = %v", err)
+			}
+			if cfg.RCVersion != tt.want {
+				t.Errorf("RCVersion = %s, want %s", cfg.RCVersion, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveNextVersionAcrossRepos(t *testing.T) {
+	fake := githubrepo.NewFakeGithubRepo()
+	fake.AddRepo("api").AddBranch("main", "a2", true).AddTag("v1.2.0", "a1").AddRelease("v1.2.0", false).
+		AddCommit("a1", "chore: release", "alice").AddCommit("a2", "fix: retry", "alice")
+	fake.AddRepo("web").AddBranch("main", "w2", true).AddTag("v1.4.1", "w1").AddRelease("v1.4.1", false).
+		AddCommit("w1", "chore: release", "alice").AddCommit("w2", "feat: dark mode", "bob")
+	fake.AddRepo("docs")
+	cfg := testConfig()
+	cfg.RCVersion = configs.RCVersionAuto
+	cfg.VersionBump = configs.VersionBumpAuto
+
+	if err := ResolveNextVersion(context.Background(), testLogger(), fake, cfg); err != nil {
+		t.Fatalf("ResolveNextVersion() error = %v", err)
+	}
+	// The latest release of any repo takes the largest bump of any repo
+	if cfg.RCVersion != "v1.5.0" {
+		t.Errorf("RCVersion = %s, want v1.5.0", cfg.RCVersion)
+	}
+
+	fake.FailOn("ListReleases", "docs", errors.New("boom"))
+	if err := ResolveNextVersion(context.Background(), testLogger(), fake, cfg); err == nil {
+		t.Error("ResolveNextVersion() error = nil, want the failed repo")
+	}
+}
@@ -0,0 +1,206 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+)
+
+// ReadinessCheckResult is the outcome of one readiness check in one repo
+type ReadinessCheckResult struct {
+	Check   string `json:"check"`
+	Passed  bool   `json:"passed"`
+	Details string `json:"details,omitempty"`
+}
+
+// ReadinessResult is whether a repo is ready for the production release
+type ReadinessResult struct {
+	Repo string `json:"repo"`
+	// SHA is the head of the production branch, the commit the release would dispatch
+	SHA    string                 `json:"sha,omitempty"`
+	Ready  bool                   `json:"ready"`
+	Checks []ReadinessCheckResult `json:"checks"`
+}
+
+// problems lists the details of the failed checks
+func (r ReadinessResult) problems() []string {
+	var problems []string
+	for _, check := range r.Checks {
+		if !check.Passed {
+			problems = append(problems, fmt.Sprintf("%s: %s", check.Check, check.Details))
+		}
+	}
+	return problems
+}
+
+// CheckReleaseReadiness runs the enabled readiness checks in every repo. It returns the results in repoList order and
+// an error listing the repos that aren't ready.
+func CheckReleaseReadiness(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) ([]ReadinessResult, error) {
+	results := make([]ReadinessResult, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		results[i] = checkRepoReadiness(ctx, l, githubRepo, cfg, repoList[i])
+	})
+
+	var failures []string
+	for _, result := range results {
+		if !result.Ready {
+			failures = append(failures, fmt.Sprintf("%s (%s)", result.Repo, strings.Join(result.problems(), "; ")))
+		}
+	}
+	if len(failures) > 0 {
+		return results, fmt.Errorf("%d repo(s) not ready: %s", len(failures), strings.Join(failures, ", "))
+	}
+	return results, nil
+}
+
+func checkRepoReadiness(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) ReadinessResult {
+	result := ReadinessResult{Repo: repo, Ready: true}
+	record := func(check string, passed bool, details string) {
+		result.Checks = append(result.Checks, ReadinessCheckResult{Check: check, Passed: passed, Details: details})
+		result.Ready = result.Ready && passed
+	}
+
+	if cfg.ReadinessCheck(configs.ReadinessCheckPullRequests) {
+		passed, details, err := openReleaseCandidatePullRequests(ctx, githubRepo, cfg, repo)
+		if err != nil {
+			passed, details = false, err.Error()
+		}
+		record(configs.ReadinessCheckPullRequests, passed, details)
+	}
+	if cfg.ReadinessCheck(configs.ReadinessCheckCI) {
+		sha, err := githubRepo.GetBranchSHA(ctx, cfg.Owner, repo, cfg.ProductionBranch)
+		result.SHA = sha
+		passed, details := false, ""
+		if err == nil {
+			passed, details, err = productionChecksPassed(ctx, githubRepo, cfg, repo, sha)
+		}
+		if err != nil {
+			passed, details = false, err.Error()
+		}
+		record(configs.ReadinessCheckCI, passed, details)
+	}
+	if cfg.ReadinessCheck(configs.ReadinessCheckReviews) {
+		passed, details, err := releaseCandidateReviewed(ctx, githubRepo, cfg, repo)
+		if err != nil {
+			passed, details = false, err.Error()
+		}
+		record(configs.ReadinessCheckReviews, passed, details)
+	}
+
+	if result.Ready {
+		l.Info("Repo %s is ready for release %s", repo, cfg.RCVersion)
+	} else {
+		l.Warn("Repo %s is not ready for release %s: %s", repo, cfg.RCVersion, strings.Join(result.problems(), "; "))
+	}
+	return result
+}
+
+// openReleaseCandidatePullRequests passes when no pull request from the release candidate branch into the
+// production branch is still open
+func openReleaseCandidatePullRequests(ctx context.Context, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) (bool, string, error) {
+	pulls, err := githubRepo.ListOpenPullRequestsByBase(ctx, cfg.Owner, repo, cfg.ProductionBranch)
+	if err != nil {
+		return false, "", err
+	}
+	for _, pull := range pulls {
+		if pull.GetHead().GetRef() == cfg.RCBranch {
+			return false, fmt.Sprintf("pull request #%d from %s is still open %s", pull.GetNumber(), cfg.RCBranch, pull.GetHTMLURL()), nil
+		}
+	}
+	return true, "", nil
+}
+
+// productionChecksPassed passes when every check run and commit status of sha has completed without failing.
+// A commit without any check fails, since nothing vouches for it.
+func productionChecksPassed(ctx context.Context, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string, sha string) (bool, string, error) {
+	checks, err := githubRepo.ListCommitChecks(ctx, cfg.Owner, repo, sha)
+	if err != nil {
+		return false, "", err
+	}
+	if len(checks) == 0 {
+		return false, fmt.Sprintf("no checks on %s", cfg.ProductionBranch), nil
+	}
+	var failing, pending []string
+	for _, check := range checks {
+		switch {
+		case check.Status != "completed":
+			pending = append(pending, check.Name)
+		case check.Conclusion != "success" && check.Conclusion != "neutral" && check.Conclusion != "skipped":
+			failing = append(failing, fmt.Sprintf("%s %s", check.Name, check.Conclusion))
+		}
+	}
+
+	var details []string
+	if len(failing) > 0 {
+		details = append(details, fmt.Sprintf("failing on %s: %s", cfg.ProductionBranch, strings.Join(failing, ", ")))
+	}
+	if len(pending) > 0 {
+		details = append(details, fmt.Sprintf("pending on %s: %s", cfg.ProductionBranch, strings.Join(pending, ", ")))
+	}
+	if len(details) > 0 {
+		return false, strings.Join(details, "; "), nil
+	}
+	return true, fmt.Sprintf("%d check(s) passed", len(checks)), nil
+}
+
+// releaseCandidateReviewed passes when the latest merged release candidate pull request has
+// cfg.ReadinessRequiredApprovals approvals and no reviewer's latest review requests changes.
+// Repos without a release candidate branch had nothing to review, but one whose branch was never merged fails.
+func releaseCandidateReviewed(ctx context.Context, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repo string) (bool, string, error) {
+	pulls, err := githubRepo.ListMergedPullRequestsByHead(ctx, cfg.Owner, repo, cfg.RCBranch, cfg.ProductionBranch)
+	if err != nil {
+		return false, "", err
+	}
+	if len(pulls) == 0 {
+		_, err := githubRepo.GetBranchSHA(ctx, cfg.Owner, repo, cfg.RCBranch)
+		if errors.Is(err, githubrepo.ErrBranchNotFound) {
+			return true, fmt.Sprintf("no %s branch, nothing to review", cfg.RCBranch), nil
+		}
+		if err != nil {
+			return false, "", err
+		}
+		return false, fmt.Sprintf("no merged pull request from %s into %s", cfg.RCBranch, cfg.ProductionBranch), nil
+	}
+	pull := pulls[0]
+	reviews, err := githubRepo.ListPullRequestReviews(ctx, cfg.Owner, repo, pull.Number)
+	if err != nil {
+		return false, "", err
+	}
+
+	// Comments leave the verdict of a reviewer as it was, dismissals withdraw it
+	latest := make(map[string]string)
+	var reviewers []string
+	for _, review := range reviews {
+		switch review.State {
+		case "APPROVED", "CHANGES_REQUESTED":
+			if _, ok := latest[review.User]; !ok {
+				reviewers = append(reviewers, review.User)
+			}
+			latest[review.User] = review.State
+		case "DISMISSED":
+			latest[review.User] = ""
+		}
+	}
+	approvals := 0
+	var changesRequested []string
+	for _, reviewer := range reviewers {
+		switch latest[reviewer] {
+		case "APPROVED":
+			approvals++
+		case "CHANGES_REQUESTED":
+			changesRequested = append(changesRequested, reviewer)
+		}
+	}
+
+	if len(changesRequested) > 0 {
+		return false, fmt.Sprintf("changes requested on #%d by %s", pull.Number, strings.Join(changesRequested, ", ")), nil
+	}
+	if approvals < cfg.ReadinessRequiredApprovals {
+		return false, fmt.Sprintf("#%d has %d of %d required approval(s)", pull.Number, approvals, cfg.ReadinessRequiredApprovals), nil
+	}
+	return true, fmt.Sprintf("#%d approved by %d reviewer(s)", pull.Number, approvals), nil
+}
@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"strings"
+	"testing"
+)
+
+func TestCheckReleaseReadiness(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup changes a repo passing every check
+		setup       func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest)
+		wantReady   bool
+		wantProblem string
+	}{
+		{
+			name:      "ready",
+			setup:     func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {},
+			wantReady: true,
+		},
+		{
+			name: "release candidate pull request still open",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				fake.AddPullRequest("api", "rc/v1.1.0", "main")
+			},
+			wantProblem: "pull-requests: pull request #",
+		},
+		{
+			name: "failing check",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				repo.Checks["a1"] = append(repo.Checks["a1"], githubrepo.RespCheck{Name: "test", Status: "completed", Conclusion: "failure"})
+			},
+			wantProblem: "ci: failing on main: test failure",
+		},
+		{
+			name: "pending check",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				repo.Checks["a1"] = append(repo.Checks["a1"], githubrepo.RespCheck{Name: "deploy-preview", Status: "in_progress"})
+			},
+			wantProblem: "ci: pending on main: deploy-preview",
+		},
+		{
+			name: "no checks",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				repo.Checks = nil
+			},
+			wantProblem: "ci: no checks on main",
+		},
+		{
+			name: "release candidate branch without a merged pull request",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				repo.AddBranch("rc/v1.1.0", "r1", false)
+				pull.Merged = false
+			},
+			wantProblem: "reviews: no merged pull request from rc/v1.1.0 into main",
+		},
+		{
+			name: "no release candidate branch",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				pull.Merged = false
+			},
+			wantReady: true,
+		},
+		{
+			name: "changes requested after an approval",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				pull.Reviews = append(pull.Reviews, githubrepo.RespReview{User: "alice", State: "CHANGES_REQUESTED"})
+			},
+			wantProblem: "reviews: changes requested on #1 by alice",
+		},
+		{
+			name: "dismissed approval",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				pull.Reviews = append(pull.Reviews, githubrepo.RespReview{User: "alice", State: "DISMISSED"})
+			},
+			wantProblem: "reviews: #1 has 0 of 1 required approval(s)",
+		},
+		{
+			name: "checks cannot be listed",
+			setup: func(fake *githubrepo.FakeGithubRepo, repo *githubrepo.FakeRepo, pull *githubrepo.FakePullRequest) {
+				fake.FailOn("ListCommitChecks", "api", errors.New("boom"))
+			},
+			wantProblem: "ci: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			repo := fake.AddRepo("api").AddBranch("main", "a1", true)
+			repo.Checks = map[string][]githubrepo.RespCheck{
+				"a1": {{Name: "build", Status: "completed", Conclusion: "success"}, {Name: "lint", Status: "completed", Conclusion: "skipped"}},
+			}
+			pull := fake.AddPullRequest("api", "rc/v1.1.0", "main")
+			pull.State, pull.Merged = "closed", true
+			pull.Reviews = []githubrepo.RespReview{{User: "alice", State: "APPROVED"}, {User: "bob", State: "COMMENTED"}}
+			tt.setup(fake, repo, pull)
+			cfg := testConfig()
+			cfg.ReadinessChecks = []string{configs.ReadinessCheckPullRequests, configs.ReadinessCheckCI, configs.ReadinessCheckReviews}
+			cfg.ReadinessRequiredApprovals = 1
+
+			results, err := CheckReleaseReadiness(context.Background(), testLogger(), fake, cfg, []string{"api"})
+			if (err == nil) != tt.wantReady {
+				t.Fatalf("CheckReleaseReadiness() error = %v, want ready %v", err, tt.wantReady)
+			}
+			if len(results) != 1 || results[0].Ready != tt.wantReady || len(results[0].Checks) != 3 {
+				t.Fatalf("results = %+v, want one result with 3 checks and ready %v", results, tt.wantReady)
+			}
+			if results[0].SHA != "a1" {
+				t.Errorf("SHA = %q, want a1", results[0].SHA)
+			}
+			if problems := strings.Join(results[0].problems(), "; "); !strings.Contains(problems, tt.wantProblem) {
+				t.Errorf("problems = %q, want %q", problems, tt.wantProblem)
+			}
+		})
+	}
+}
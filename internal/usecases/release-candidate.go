@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+)
+
+// ReleaseCandidateResult represents the result of cutting an RC branch and opening its PR in a repository
+type ReleaseCandidateResult struct {
+	Repo          string
+	Branch        string // rc branch name (e.g., rc/v7.0.0)
+	BranchCreated bool
+	PRURL         string
+	HasConflicts  bool
+	Error         string
+}
+
+// CreateReleaseCandidates creates the RC branch from the development branch in each repo
+// and opens a PR from it into the production branch, up to cfg.Concurrency repos at a time
+func CreateReleaseCandidates(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) ([]ReleaseCandidateResult, error) {
+	var results []ReleaseCandidateResult
+	var errs []string
+
+	prTitle := cfg.PRTitle
+	if prTitle == "" {
+		prTitle = fmt.Sprintf("Release Candidate %s", cfg.RCVersion)
+	}
+	prBody := cfg.PRBody
+	if prBody == "" {
+		prBody = fmt.Sprintf("Release candidate %s from %s to %s", cfg.RCVersion, cfg.DevelopmentBranch, cfg.ProductionBranch)
+	}
+
+	repoResults := make([]ReleaseCandidateResult, len(repoList))
+	repoErrs := make([]string, len(repoList))
+
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		repo := repoList[i]
+		result := ReleaseCandidateResult{
+			Repo:   repo,
+			Branch: cfg.RCBranch,
+		}
+
+		l.Info("Creating RC branch '%s' in repo '%s' from '%s'", cfg.RCBranch, repo, cfg.DevelopmentBranch)
+		if err := githubRepo.CreateBranch(ctx, cfg.Owner, repo, cfg.DevelopmentBranch, cfg.RCBranch); err != nil {
+			l.Error("Error creating RC branch '%s' in repo '%s': %v", cfg.RCBranch, repo, err)
+			result.Error = err.Error()
+			repoErrs[i] = fmt.Sprintf("%s/%s: %v", repo, cfg.RCBranch, err)
+			repoResults[i] = result
+			return
+		}
+		result.BranchCreated = true
+
+		l.Info("Creating PR from '%s' to '%s' in repo '%s'", cfg.RCBranch, cfg.ProductionBranch, repo)
+		prURL, prError, hasConflicts, err := githubRepo.CreatePullRequest(ctx, cfg.Owner, repo, cfg.RCBranch, cfg.ProductionBranch, prTitle, prBody)
+		if err != nil {
+			l.Error("Error creating PR from '%s' to '%s' in repo '%s': %v", cfg.RCBranch, cfg.ProductionBranch, repo, err)
+			result.Error = err.Error()
+			repoErrs[i] = fmt.Sprintf("%s: %s->%s: %v", repo, cfg.RCBranch, cfg.ProductionBranch, err)
+		} else {
+			if prError != "" {
+				l.Warn("PR created with warning from '%s' to '%s' in repo '%s': %s", cfg.RCBranch, cfg.ProductionBranch, repo, prError)
+			}
+			result.PRURL = prURL
+			result.HasConflicts = hasConflicts
+			result.Error = prError
+		}
+
+		repoResults[i] = result
+	})
+
+	for i := range repoList {
+		results = append(results, repoResults[i])
+		if repoErrs[i] != "" {
+			errs = append(errs, repoErrs[i])
+		}
+	}
+
+	if len(errs) > 0 {
+		return results, fmt.Errorf("failed to create some release candidates: %s", strings.Join(errs, "; "))
+	}
+
+	return results, nil
+}
@@ -2,44 +2,162 @@ package usecases
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"release-candidate/internal/configs"
 	"release-candidate/internal/usecases/githubrepo"
 	"release-candidate/internal/utils"
+	"strings"
+	"time"
 
-	"github.com/google/go-github/v66/github"
 	"github.com/sethvargo/go-githubactions"
 )
 
-// safeSetOutput sets GitHub Actions output only if running in GitHub Actions environment
-func safeSetOutput(key, value string, l utils.LogInterface) {
-	if os.Getenv("GITHUB_OUTPUT") != "" || os.Getenv("GITHUB_ACTIONS") == "true" {
+// safeSetOutput prints the output in CLI mode, and otherwise sets GitHub Actions output only if running in GitHub Actions environment
+func safeSetOutput(key, value string, cfg *configs.Config, l utils.LogInterface) {
+	if cfg.CLI {
+		printOutput(os.Stdout, key, value)
+	} else if os.Getenv("GITHUB_OUTPUT") != "" || os.Getenv("GITHUB_ACTIONS") == "true" {
 		githubactions.SetOutput(key, value)
 	} else {
 		l.Info("Not in GitHub Actions environment, skipping output set for %s", key)
 	}
 }
 
-func ProductionReleaseUseCase(ctx context.Context, l utils.LogInterface, client *github.Client, cfg *configs.Config) {
+// printOutput writes an output as key=value, or for multi-line values in the key<<EOF heredoc form of GITHUB_OUTPUT
+func printOutput(w io.Writer, key, value string) {
+	if strings.Contains(value, "\n") {
+		fmt.Fprintf(w, "%s<<EOF\n%s\nEOF\n", key, value)
+		return
+	}
+	fmt.Fprintf(w, "%s=%s\n", key, value)
+}
+
+// repositoryQuery builds the repository selection of a run from its configuration
+func repositoryQuery(cfg *configs.Config) githubrepo.RepositoryQuery {
+	return githubrepo.RepositoryQuery{
+		UseCase:                        cfg.UseCase,
+		IncludeRepositories:            cfg.IncludeRepositories,
+		ExcludeRepositories:            cfg.ExcludeRepositories,
+		ExcludeProdReleaseRepositories: cfg.ExcludeProdReleaseRepositories,
+		Topics:                         cfg.RepositoryTopics,
+		Properties:                     cfg.RepositoryProperties,
+	}
+}
+
+func ReleaseCandidateUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) {
+	l.Info("Starting Release Candidate creation")
+
+	repoList, err := githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
+	if err != nil {
+		l.Fatal("Error listing repositories: %v", err)
+	}
+	l.Info("repoList: %v", repoList)
+
+	rcResults, err := CreateReleaseCandidates(ctx, l, githubRepo, cfg, repoList)
+
+	prURLs := []string{}
+	var prResults []map[string]interface{}
+	for _, result := range rcResults {
+		if result.PRURL != "" {
+			prURLs = append(prURLs, result.PRURL)
+		}
+		prResults = append(prResults, map[string]interface{}{
+			"repo":         result.Repo,
+			"url":          result.PRURL,
+			"error":        result.Error,
+			"hasConflicts": result.HasConflicts,
+		})
+	}
+
+	prURLsJSON, jsonErr := json.Marshal(prURLs)
+	if jsonErr != nil {
+		l.Error("Error marshalling PR URLs: %v", jsonErr)
+	} else {
+		safeSetOutput("pr_urls", string(prURLsJSON), cfg, l)
+	}
+
+	if len(prResults) > 0 {
+		slackPayload, slackErr := utils.ReleaseCandidateSlackPayloadBuilder(cfg.RCVersion, cfg.ProductionBranch, prResults)
+		if slackErr != nil {
+			l.Error("Error building release candidate slack payload: %v", slackErr)
+		} else {
+			setSlackPayloadOutput("slack_payload", slackPayload, cfg, l)
+		}
+	}
+
+	if err != nil {
+		l.Fatal("Some release candidates failed to create: %v", err)
+	}
+}
+
+func ProductionReleaseUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) {
 	l.Info("Production-Release use case")
 
-	githubRepo := githubrepo.NewGithubRepo(client, l) // init githubRepo struct
-	repoList, err := githubRepo.ListRepositories(ctx, cfg.Owner, cfg.UseCase, cfg.IncludeRepositories, cfg.ExcludeRepositories, cfg.ExcludeProdReleaseRepositories)
+	repoList, err := githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
 	if err != nil {
 		l.Fatal("Error listing repositories: %v", err)
 	}
 	l.Info("repoList: %v", repoList)
-	var slackPayload string
 
-	// Pre-release check removed: the Hydra platform now ensures all RC -> production
-	// PRs are merged before this use case runs, so checking for open PRs here is
-	// redundant. Proceed directly to dispatching the production pipeline.
+	freezeNotice := checkReleaseFreeze(l, cfg, time.Now())
+
+	// The Hydra platform ensures all RC -> production PRs are merged before this use case runs.
+	// The optional readiness gate checks it again, with CI and reviews, before anything is dispatched.
+	if len(cfg.ReadinessChecks) > 0 {
+		l.Info("Checking release readiness: %v", cfg.ReadinessChecks)
+		readinessResults, readinessErr := CheckReleaseReadiness(ctx, l, githubRepo, cfg, repoList)
+		if readinessJSON, jsonErr := json.Marshal(readinessResults); jsonErr != nil {
+			l.Error("Error marshalling readiness results: %v", jsonErr)
+		} else {
+			safeSetOutput("readiness_results", string(readinessJSON), cfg, l)
+		}
+		if readinessErr != nil {
+			var readinessItems []map[string]interface{}
+			for _, result := range readinessResults {
+				readinessItems = append(readinessItems, map[string]interface{}{
+					"repo":     result.Repo,
+					"ready":    result.Ready,
+					"problems": strings.Join(result.problems(), "; "),
+				})
+			}
+			slackPayload, slackErr := utils.ReadinessSlackPayloadBuilder(cfg.RCVersion, readinessItems)
+			if slackErr != nil {
+				l.Error("Error building readiness slack payload: %v", slackErr)
+			} else {
+				setSlackPayloadOutput("slack_payload", slackPayload, cfg, l)
+			}
+			l.Fatal("Release %s is not ready, nothing was dispatched: %v", cfg.RCVersion, readinessErr)
+		}
+	}
+
 	l.Info("Starting Production Pipeline Dispatch")
-	slackPayload, err = ProductionWorkflowDispatch(ctx, l, githubRepo, cfg, repoList)
+	dispatch := ProductionWorkflowDispatch
+	if len(cfg.Waves) > 0 {
+		l.Info("Rolling out in waves")
+		dispatch = ProductionWaveDispatch
+	}
+	dispatchResults, slackPayload, err := dispatch(ctx, l, githubRepo, cfg, repoList)
+	if dispatchResultsJSON, jsonErr := json.Marshal(dispatchResults); jsonErr != nil {
+		l.Error("Error marshalling dispatch results: %v", jsonErr)
+	} else {
+		safeSetOutput("dispatch_results", string(dispatchResultsJSON), cfg, l)
+	}
+	slackPayload = withFreezeNotice(l, slackPayload, freezeNotice)
+	if slackPayload != "" {
+		setSlackPayloadOutput("slack_payload", slackPayload, cfg, l)
+	}
 	if err != nil {
-		l.Fatal("Error building slack payload: %v", err)
+		// Outputs are set above so the failures are still reported before the run fails
+		l.Fatal("Error dispatching production workflows: %v", err)
+	}
+
+	if cfg.CreateReleases {
+		// Released at the commits that were dispatched, even if the production branch moved since
+		publishGitHubReleases(ctx, l, githubRepo, cfg, repoList, releaseSHAs(dispatchResults))
 	}
-	safeSetOutput("slack_payload", slackPayload, l)
 
 	if cfg.EnableMainToEpicSync {
 		MainToEpicSyncUseCase(ctx, l, githubRepo, cfg, repoList)
@@ -48,12 +166,12 @@ func ProductionReleaseUseCase(ctx context.Context, l utils.LogInterface, client
 	}
 }
 
-func MainToEpicSyncUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GithubRepo, cfg *configs.Config, repoList []string) {
+func MainToEpicSyncUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) {
 	l.Info("Starting Main to Epic Sync")
 
 	if len(repoList) == 0 {
 		var err error
-		repoList, err = githubRepo.ListRepositories(ctx, cfg.Owner, cfg.UseCase, cfg.IncludeRepositories, cfg.ExcludeRepositories, cfg.ExcludeProdReleaseRepositories)
+		repoList, err = githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
 		if err != nil {
 			l.Fatal("Error listing repositories: %v", err)
 		}
@@ -70,7 +188,7 @@ func MainToEpicSyncUseCase(ctx context.Context, l utils.LogInterface, githubRepo
 		l.Info("Active epics: %v", activeEpics)
 
 		// Find epic branches in all repos
-		epicBranchResults, err := FindEpicBranchesInRepos(ctx, l, githubRepo, cfg.Owner, repoList, activeEpics)
+		epicBranchResults, err := FindEpicBranchesInRepos(ctx, l, githubRepo, cfg.Owner, repoList, activeEpics, cfg.Concurrency)
 		if err != nil {
 			l.Fatal("Error finding epic branches: %v", err)
 		}
@@ -92,7 +210,7 @@ func MainToEpicSyncUseCase(ctx context.Context, l utils.LogInterface, githubRepo
 		}
 
 		// Create sync branches for each epic in repos where epic branch exists
-		syncResults, err := CreateSyncBranchesForEpics(ctx, l, githubRepo, cfg.Owner, cfg.ProductionBranch, cfg.RCVersion, epicBranchResults)
+		syncResults, err := CreateSyncBranchesForEpics(ctx, l, githubRepo, cfg.Owner, cfg.ProductionBranch, cfg.RCVersion, epicBranchResults, cfg.Concurrency)
 
 		// Log sync branch creation results
 		for _, result := range syncResults {
@@ -108,7 +226,7 @@ func MainToEpicSyncUseCase(ctx context.Context, l utils.LogInterface, githubRepo
 		}
 
 		// Create PRs from sync branches to epic branches
-		prResults, err := CreatePRsFromSyncToEpic(ctx, l, githubRepo, cfg.Owner, cfg.RCVersion, syncResults)
+		prResults, err := CreatePRsFromSyncToEpic(ctx, l, githubRepo, cfg.Owner, cfg.RCVersion, syncResults, cfg.Concurrency)
 
 		// Log PR creation results
 		prResultsByEpic := make(map[string][]map[string]interface{})
@@ -134,7 +252,7 @@ func MainToEpicSyncUseCase(ctx context.Context, l utils.LogInterface, githubRepo
 				l.Error("Error building sync slack payload: %v", err)
 			} else {
 				l.Info("Sync PR Slack Payload:\n%s", slackPayload) //Log for manual copying
-				safeSetOutput("sync_pr_slack_payload", slackPayload, l)
+				setSlackPayloadOutput("sync_pr_slack_payload", slackPayload, cfg, l)
 			}
 		}
 
@@ -0,0 +1,178 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+	"time"
+)
+
+// Statuses of RollbackResult
+const (
+	RollbackStatusRolledBack = "rolled-back"
+	// RollbackStatusSkipped marks repos without a production workflow, which have nothing to redeploy
+	RollbackStatusSkipped = "skipped"
+	RollbackStatusFailed  = "failed"
+)
+
+// RollbackResult is the outcome of rolling back one repo
+type RollbackResult struct {
+	Repo        string `json:"repo"`
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version,omitempty"`
+	// SHA is the commit of ToVersion redeployed
+	SHA    string `json:"sha,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// rollbackTargets returns the release every repo is rolled back to: cfg.RollbackVersion, or the published release
+// before cfg.RCVersion. Repos without one are left out of the map and reported as failed results.
+func rollbackTargets(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) (map[string]string, []RollbackResult) {
+	results := make([]RollbackResult, len(repoList))
+	utils.ForEachConcurrently(len(repoList), cfg.Concurrency, func(i int) {
+		repo := repoList[i]
+		results[i] = RollbackResult{Repo: repo, FromVersion: cfg.RCVersion, ToVersion: cfg.RollbackVersion, Status: RollbackStatusRolledBack}
+		if cfg.RollbackVersion != "" {
+			return
+		}
+		previous, err := previousReleaseTag(ctx, githubRepo, cfg, repo)
+		switch {
+		case err != nil:
+			l.Error("Error finding the release before %s in repo %s: %v", cfg.RCVersion, repo, err)
+			results[i].Status = RollbackStatusFailed
+			results[i].Error = fmt.Sprintf("error finding the previous release: %v", err)
+		case previous == "":
+			results[i].Status = RollbackStatusFailed
+			results[i].Error = fmt.Sprintf("no release before %s to roll back to", cfg.RCVersion)
+		default:
+			l.Info("Repo %s is rolled back from %s to %s", repo, cfg.RCVersion, previous)
+			results[i].ToVersion = previous
+		}
+	})
+
+	targets := make(map[string]string)
+	for _, result := range results {
+		if result.Status == RollbackStatusRolledBack {
+			targets[result.Repo] = result.ToVersion
+		}
+	}
+	return targets, results
+}
+
+// Rollback redeploys the previous release of every repo by dispatching its production workflows on the release tag,
+// in waves when cfg.Waves is set. It returns the rollback results in repoList order, the dispatch results, and an
+// error listing the failures.
+func Rollback(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config, repoList []string) ([]RollbackResult, []WorkflowDispatchResult, error) {
+	targets, results := rollbackTargets(ctx, l, githubRepo, cfg, repoList)
+
+	var rollbackRepos []string
+	for _, result := range results {
+		if result.Status == RollbackStatusRolledBack {
+			rollbackRepos = append(rollbackRepos, result.Repo)
+		}
+	}
+	var dispatchResults []WorkflowDispatchResult
+	var dispatchErr error
+	if len(rollbackRepos) > 0 {
+		l.Info("Rolling back repos: %v", rollbackRepos)
+		dispatch := productionWorkflowDispatch
+		if len(cfg.Waves) > 0 {
+			dispatch = productionWaveDispatch
+		}
+		// The failures of the dispatched repos are reported per repo below
+		dispatchResults, _, dispatchErr = dispatch(ctx, l, githubRepo, cfg, rollbackRepos, targets)
+	}
+
+	for i := range results {
+		if results[i].Status != RollbackStatusRolledBack {
+			continue
+		}
+		sha, skipped, failure := repoDispatchOutcome(results[i].Repo, dispatchResults, dispatchErr)
+		results[i].SHA = sha
+		switch {
+		case failure != "":
+			results[i].Status = RollbackStatusFailed
+			results[i].Error = failure
+		case skipped:
+			results[i].Status = RollbackStatusSkipped
+		}
+	}
+
+	var failures []string
+	for _, result := range results {
+		if result.Status == RollbackStatusFailed {
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Repo, result.Error))
+		}
+	}
+	if len(failures) > 0 {
+		return results, dispatchResults, fmt.Errorf("rollback failed in %d repo(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	if dispatchErr != nil {
+		return results, dispatchResults, fmt.Errorf("rollback failed: %v", dispatchErr)
+	}
+	return results, dispatchResults, nil
+}
+
+// RollbackUseCase rolls the selected repos back from cfg.RCVersion to their previous release, or to cfg.RollbackVersion
+func RollbackUseCase(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, cfg *configs.Config) {
+	l.Info("Rollback use case from %s", cfg.RCVersion)
+
+	repoList, err := githubRepo.ListRepositories(ctx, cfg.Owner, repositoryQuery(cfg))
+	if err != nil {
+		l.Fatal("Error listing repositories: %v", err)
+	}
+	l.Info("repoList: %v", repoList)
+
+	freezeNotice := checkReleaseFreeze(l, cfg, time.Now())
+
+	results, dispatchResults, rollbackErr := Rollback(ctx, l, githubRepo, cfg, repoList)
+
+	if resultsJSON, jsonErr := json.Marshal(results); jsonErr != nil {
+		l.Error("Error marshalling rollback results: %v", jsonErr)
+	} else {
+		safeSetOutput("rollback_results", string(resultsJSON), cfg, l)
+	}
+	if dispatchResultsJSON, jsonErr := json.Marshal(dispatchResults); jsonErr != nil {
+		l.Error("Error marshalling dispatch results: %v", jsonErr)
+	} else {
+		safeSetOutput("dispatch_results", string(dispatchResultsJSON), cfg, l)
+	}
+
+	// Repos without a release to roll back to were not dispatched and are reported on their own
+	var rollbackItems []map[string]interface{}
+	toVersions := make(map[string]string)
+	for _, result := range results {
+		toVersions[result.Repo] = result.ToVersion
+		if result.ToVersion == "" {
+			rollbackItems = append(rollbackItems, map[string]interface{}{
+				"repo":   result.Repo,
+				"status": result.Status,
+				"error":  result.Error,
+			})
+		}
+	}
+	for _, dispatchResult := range dispatchResults {
+		item := dispatchItem(dispatchResult)
+		if dispatchResult.Status != DispatchStatusSkippedNoWorkflow {
+			item["to_version"] = toVersions[dispatchResult.Repo]
+		}
+		rollbackItems = append(rollbackItems, item)
+	}
+	if len(rollbackItems) > 0 {
+		slackPayload, slackErr := utils.RollbackSlackPayloadBuilder(cfg.RCVersion, rollbackItems, cfg.Environment)
+		if slackErr != nil {
+			l.Error("Error building rollback slack payload: %v", slackErr)
+		} else {
+			setSlackPayloadOutput("slack_payload", withFreezeNotice(l, slackPayload, freezeNotice), cfg, l)
+		}
+	}
+
+	if rollbackErr != nil {
+		l.Fatal("%v", rollbackErr)
+	}
+}
@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"release-candidate/internal/usecases/githubrepo"
+	"testing"
+)
+
+func TestRollback(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup adds the api repo
+		setup           func(fake *githubrepo.FakeGithubRepo)
+		rollbackVersion string
+		wantStatus      string
+		wantToVersion   string
+		wantRef         string
+		wantErr         bool
+	}{
+		{
+			name: "rolls back to the previous published release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a3", true).AddWorkflow(1, "Prod", prodWorkflowPath).
+					AddTag("v1.0.0", "a1").AddTag("v1.0.1", "a2").AddTag("v1.1.0", "a3").
+					AddRelease("v1.0.0", false).AddRelease("v1.0.1", false).AddRelease("v1.0.2-rc.1", true).AddRelease("v1.1.0", false)
+			},
+			wantStatus:    RollbackStatusRolledBack,
+			wantToVersion: "v1.0.1",
+			wantRef:       "refs/tags/v1.0.1",
+		},
+		{
+			name: "rolls back to the requested version",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a3", true).AddWorkflow(1, "Prod", prodWorkflowPath).
+					AddTag("v1.0.0", "a1").AddTag("v1.0.1", "a2").AddRelease("v1.0.0", false).AddRelease("v1.0.1", false)
+			},
+			rollbackVersion: "v1.0.0",
+			wantStatus:      RollbackStatusRolledBack,
+			wantToVersion:   "v1.0.0",
+			wantRef:         "refs/tags/v1.0.0",
+		},
+		{
+			name: "skips a repo without a production workflow",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a3", true).AddTag("v1.0.1", "a2").AddRelease("v1.0.1", false)
+			},
+			wantStatus:    RollbackStatusSkipped,
+			wantToVersion: "v1.0.1",
+		},
+		{
+			name: "fails without an earlier release",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a3", true).AddWorkflow(1, "Prod", prodWorkflowPath).AddRelease("v1.1.0", false)
+			},
+			wantStatus: RollbackStatusFailed,
+			wantErr:    true,
+		},
+		{
+			name: "fails when the release tag is missing",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a3", true).AddWorkflow(1, "Prod", prodWorkflowPath).AddRelease("v1.0.1", false)
+			},
+			wantStatus:    RollbackStatusFailed,
+			wantToVersion: "v1.0.1",
+			wantErr:       true,
+		},
+		{
+			name: "fails on a failed dispatch",
+			setup: func(fake *githubrepo.FakeGithubRepo) {
+				fake.AddRepo("api").AddBranch("main", "a3", true).AddWorkflow(1, "Prod", prodWorkflowPath).
+					AddTag("v1.0.1", "a2").AddRelease("v1.0.1", false)
+				fake.FailOn("CreateWorkflowDispatchEventByID", "api", errors.New("boom"))
+			},
+			wantStatus:    RollbackStatusFailed,
+			wantToVersion: "v1.0.1",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			tt.setup(fake)
+			cfg := testConfig()
+			cfg.UseCase = "Rollback"
+			cfg.RollbackVersion = tt.rollbackVersion
+
+			results, _, err := Rollback(context.Background(), testLogger(), fake, cfg, []string{"api"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rollback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(results) != 1 || results[0].Status != tt.wantStatus || results[0].ToVersion != tt.wantToVersion {
+				t.Fatalf("results = %+v, want one %s result to %q", results, tt.wantStatus, tt.wantToVersion)
+			}
+			if tt.wantRef == "" {
+				if len(fake.Dispatches) != 0 {
+					t.Errorf("got dispatches %+v, want none", fake.Dispatches)
+				}
+				return
+			}
+			if len(fake.Dispatches) != 1 || fake.Dispatches[0].Ref != tt.wantRef {
+				t.Fatalf("dispatches = %+v, want one on %s", fake.Dispatches, tt.wantRef)
+			}
+			if got := fake.Dispatches[0].Inputs["release_version"]; got != tt.wantToVersion {
+				t.Errorf("release_version input = %v, want %s", got, tt.wantToVersion)
+			}
+		})
+	}
+}
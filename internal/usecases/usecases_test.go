@@ -0,0 +1,36 @@
+package usecases
+
+import (
+	"release-candidate/internal/configs"
+	"release-candidate/internal/utils"
+	"time"
+)
+
+// testLogger only logs errors, so test output stays readable
+func testLogger() utils.LogInterface {
+	return utils.NewLogger("error")
+}
+
+// testConfig is a production release of v1.1.0 in the o organization with the default workflow inputs
+func testConfig() *configs.Config {
+	return &configs.Config{
+		Owner:                "o",
+		UseCase:              "Production-Release",
+		RCVersion:            "v1.1.0",
+		RCBranch:             "rc/v1.1.0",
+		ProductionBranch:     "main",
+		DevelopmentBranch:    "development",
+		HotfixBranch:         "hotfix/v1.1.0",
+		Environment:          "production",
+		Concurrency:          4,
+		ProdWorkflowFilter:   "prod-release.*",
+		ProductionRef:        configs.ProductionRefBranch,
+		WorkflowWaitTimeout:  time.Second,
+		WorkflowPollInterval: 10 * time.Millisecond,
+		LockTTL:              time.Hour,
+		WorkflowInputs: map[string]string{
+			"environment":     "{{.Environment}}",
+			"release_version": "{{.RCVersion}}",
+		},
+	}
+}
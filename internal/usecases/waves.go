@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"strings"
+)
+
+// Statuses of a wave in the rollout
+const (
+	WaveStatusSucceeded = "succeeded"
+	WaveStatusFailed    = "failed"
+	WaveStatusAborted   = "aborted"
+)
+
+// RemainingWaveName is the wave of the repos no defined wave selects; it is dispatched last
+const RemainingWaveName = "remaining"
+
+// Wave is a group of repositories whose production workflows are dispatched together
+type Wave struct {
+	Name  string
+	Repos []string
+}
+
+// AssignWaves puts every repo in the first wave that selects it, keeping repoList order within a wave.
+// Repos no wave selects go to a final "remaining" wave, and waves left without repos are dropped.
+func AssignWaves(waves []configs.Wave, repoList []string) []Wave {
+	// The last wave is the remaining one
+	assigned := make([]Wave, len(waves)+1)
+	for i, wave := range waves {
+		assigned[i].Name = wave.Name
+	}
+	assigned[len(waves)].Name = RemainingWaveName
+	for _, repo := range repoList {
+		i := 0
+		for i < len(waves) && !waves[i].Filter.Match(repo) {
+			i++
+		}
+		assigned[i].Repos = append(assigned[i].Repos, repo)
+	}
+
+	var nonEmpty []Wave
+	for _, wave := range assigned {
+		if len(wave.Repos) > 0 {
+			nonEmpty = append(nonEmpty, wave)
+		}
+	}
+	return nonEmpty
+}
+
+// ProductionWaveDispatch rolls the production workflows out wave by wave. Each wave's runs must all
+// succeed before the next wave is dispatched; after a failed wave the later waves are aborted.
+// It returns one result per workflow in rollout order, the Slack payload, and an error listing the failures if there were any.
+func ProductionWaveDispatch(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repoList []string) (results []WorkflowDispatchResult, slackpayload string, err error) {
+	return productionWaveDispatch(ctx, l, githubRepo, variables, repoList, nil)
+}
+
+// productionWaveDispatch is ProductionWaveDispatch dispatching the repos of releaseTags on their existing release tag
+func productionWaveDispatch(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repoList []string, releaseTags map[string]string) (results []WorkflowDispatchResult, slackpayload string, err error) {
+	waves := AssignWaves(variables.Waves, repoList)
+
+	var failures []string
+	var waveItems []map[string]interface{}
+	for i, wave := range waves {
+		status := WaveStatusSucceeded
+		var waveResults []WorkflowDispatchResult
+		if len(failures) > 0 {
+			status = WaveStatusAborted
+			for _, repo := range wave.Repos {
+				waveResults = append(waveResults, WorkflowDispatchResult{Repo: repo, Status: DispatchStatusAborted})
+			}
+		} else {
+			l.Info("Dispatching wave %d/%d %s: %v", i+1, len(waves), wave.Name, wave.Repos)
+			// Dry-run dispatches start no runs, so later waves are planned without waiting
+			waveResults = dispatchProductionWorkflows(ctx, l, githubRepo, variables, wave.Repos, releaseTags, !variables.DryRun)
+			if failures = dispatchFailures(waveResults); len(failures) > 0 {
+				status = WaveStatusFailed
+				l.Error("Wave %s failed, aborting the remaining %d wave(s)", wave.Name, len(waves)-i-1)
+			} else {
+				l.Info("Wave %s completed", wave.Name)
+			}
+		}
+
+		var dispatchItems []map[string]interface{}
+		for j := range waveResults {
+			waveResults[j].Wave = wave.Name
+			dispatchItems = append(dispatchItems, dispatchItem(waveResults[j]))
+		}
+		results = append(results, waveResults...)
+		waveItems = append(waveItems, map[string]interface{}{
+			"name":    wave.Name,
+			"status":  status,
+			"results": dispatchItems,
+		})
+	}
+
+	slackpayload, err = utils.ProductionWaveDispatchSlackPayloadBuilder(variables.RCVersion, waveItems, variables.Environment)
+	if err != nil {
+		l.Error("Error building slack payload: %v", err)
+		return results, "", fmt.Errorf("error building slack payload: %v", err)
+	}
+
+	if len(failures) > 0 {
+		return results, slackpayload, fmt.Errorf("production wave rollout failed for %d workflow(s): %s", len(failures), strings.Join(failures, "; "))
+	}
+	l.Info("Production workflows rolled out in %d wave(s)", len(waves))
+
+	return results, slackpayload, nil
+}
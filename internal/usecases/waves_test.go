@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"context"
+	"reflect"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"testing"
+)
+
+func TestAssignWaves(t *testing.T) {
+	waves, err := configs.ParseWaves("foundation: shared-*\nbackend: svc-*, shared-cache\nfrontend: web-*\nempty: nothing-*")
+	if err != nil {
+		t.Fatalf("ParseWaves() error = %v", err)
+	}
+
+	got := AssignWaves(waves, []string{"misc", "shared-cache", "svc-b", "shared-lib", "svc-a", "web-app"})
+	want := []Wave{
+		{Name: "foundation", Repos: []string{"shared-cache", "shared-lib"}},
+		{Name: "backend", Repos: []string{"svc-b", "svc-a"}},
+		{Name: "frontend", Repos: []string{"web-app"}},
+		{Name: RemainingWaveName, Repos: []string{"misc"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AssignWaves() = %+v, want %+v", got, want)
+	}
+}
+
+func TestProductionWaveDispatch(t *testing.T) {
+	tests := []struct {
+		name string
+		// failing is the repo whose run fails, if any
+		failing        string
+		wantStatuses   []string
+		wantDispatches int
+		wantErr        bool
+	}{
+		{
+			name:           "rolls out every wave",
+			wantStatuses:   []string{DispatchStatusDispatched, DispatchStatusDispatched, DispatchStatusDispatched, DispatchStatusDispatched},
+			wantDispatches: 4,
+		},
+		{
+			name:           "aborts the waves after a failed one",
+			failing:        "svc-a",
+			wantStatuses:   []string{DispatchStatusDispatched, DispatchStatusDispatched, DispatchStatusAborted, DispatchStatusAborted},
+			wantDispatches: 2,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubrepo.NewFakeGithubRepo()
+			repos := []string{"shared-lib", "svc-a", "web-app", "misc"}
+			for i, repo := range repos {
+				fake.AddRepo(repo).AddBranch("main", "sha-"+repo, true).AddWorkflow(int64(i+1), "Prod", prodWorkflowPath)
+			}
+			if tt.failing != "" {
+				fake.Repo(tt.failing).RunConclusions[2] = "failure"
+			}
+			cfg := testConfig()
+			var err error
+			if cfg.Waves, err = configs.ParseWaves("foundation: shared-*\nbackend: svc-*\nfrontend: web-*"); err != nil {
+				t.Fatalf("ParseWaves() error = %v", err)
+			}
+
+			results, _, err := ProductionWaveDispatch(context.Background(), testLogger(), fake, cfg, repos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProductionWaveDispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			wantWaves := []string{"foundation", "backend", "frontend", RemainingWaveName}
+			if len(results) != len(tt.wantStatuses) {
+				t.Fatalf("got %d results, want %d: %+v", len(results), len(tt.wantStatuses), results)
+			}
+			for i, result := range results {
+				if result.Wave != wantWaves[i] || result.Status != tt.wantStatuses[i] {
+					t.Errorf("results[%d] = %s %s, want %s %s", i, result.Wave, result.Status, wantWaves[i], tt.wantStatuses[i])
+				}
+			}
+			if len(fake.Dispatches) != tt.wantDispatches {
+				t.Errorf("got %d dispatches, want %d", len(fake.Dispatches), tt.wantDispatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WorkflowDispatchInput is an input declared under on.workflow_dispatch.inputs of a workflow file
+type WorkflowDispatchInput struct {
+	Required bool        `yaml:"required"`
+	Default  interface{} `yaml:"default"`
+	Type     string      `yaml:"type"`
+	Options  []string    `yaml:"options"`
+}
+
+// parseWorkflowDispatchInputs reads the workflow_dispatch inputs a workflow file declares.
+// It fails if the workflow can't be dispatched at all.
+func parseWorkflowDispatchInputs(content string) (map[string]WorkflowDispatchInput, error) {
+	var workflow struct {
+		On yaml.Node `yaml:"on"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &workflow); err != nil {
+		return nil, fmt.Errorf("error parsing workflow file: %v", err)
+	}
+
+	// on is a single event, a list of events or a map of events to their settings
+	switch workflow.On.Kind {
+	case yaml.ScalarNode:
+		if workflow.On.Value == "workflow_dispatch" {
+			return map[string]WorkflowDispatchInput{}, nil
+		}
+	case yaml.SequenceNode:
+		for _, event := range workflow.On.Content {
+			if event.Value == "workflow_dispatch" {
+				return map[string]WorkflowDispatchInput{}, nil
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(workflow.On.Content); i += 2 {
+			if workflow.On.Content[i].Value != "workflow_dispatch" {
+				continue
+			}
+			var dispatch struct {
+				Inputs map[string]WorkflowDispatchInput `yaml:"inputs"`
+			}
+			if err := workflow.On.Content[i+1].Decode(&dispatch); err != nil {
+				return nil, fmt.Errorf("error parsing workflow_dispatch inputs: %v", err)
+			}
+			if dispatch.Inputs == nil {
+				dispatch.Inputs = map[string]WorkflowDispatchInput{}
+			}
+			return dispatch.Inputs, nil
+		}
+	}
+	return nil, fmt.Errorf("workflow has no workflow_dispatch trigger")
+}
+
+// validateWorkflowInputs checks the inputs about to be dispatched against the declared ones,
+// catching what GitHub would reject or the workflow would run without
+func validateWorkflowInputs(declared map[string]WorkflowDispatchInput, inputs map[string]interface{}) error {
+	var problems []string
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for name, value := range inputs {
+		input, ok := declared[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("input %q is not declared (declared: %s)", name, strings.Join(names, ", ")))
+			continue
+		}
+		str := fmt.Sprintf("%v", value)
+		switch input.Type {
+		case "choice":
+			valid := false
+			for _, option := range input.Options {
+				valid = valid || option == str
+			}
+			if !valid {
+				problems = append(problems, fmt.Sprintf("input %q is %q, expected one of %s", name, str, strings.Join(input.Options, ", ")))
+			}
+		case "boolean":
+			if str != "true" && str != "false" {
+				problems = append(problems, fmt.Sprintf("input %q is %q, expected true or false", name, str))
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(str, 64); err != nil {
+				problems = append(problems, fmt.Sprintf("input %q is %q, expected a number", name, str))
+			}
+		}
+	}
+
+	for _, name := range names {
+		input := declared[name]
+		if _, ok := inputs[name]; !ok && input.Required && input.Default == nil {
+			problems = append(problems, fmt.Sprintf("required input %q is missing", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package usecases
+
+import (
+	"context"
+	"reflect"
+	"release-candidate/internal/usecases/githubrepo"
+	"strings"
+	"testing"
+)
+
+func TestParseWorkflowDispatchInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]WorkflowDispatchInput
+		wantErr string
+	}{
+		{
+			name:    "single event",
+			content: "on: workflow_dispatch\n",
+			want:    map[string]WorkflowDispatchInput{},
+		},
+		{
+			name:    "list of events",
+			content: "on: [push, workflow_dispatch]\n",
+			want:    map[string]WorkflowDispatchInput{},
+		},
+		{
+			name:    "no inputs",
+			content: "on:\n  push:\n  workflow_dispatch:\n",
+			want:    map[string]WorkflowDispatchInput{},
+		},
+		{
+			name: "declared inputs",
+			content: `on:
+  workflow_dispatch:
+    inputs:
+      environment:
+        type: choice
+        required: true
+        options: [staging, production]
+      dry_run:
+        type: boolean
+        default: false
+`,
+			want: map[string]WorkflowDispatchInput{
+				"environment": {Required: true, Type: "choice", Options: []string{"staging", "production"}},
+				"dry_run":     {Type: "boolean", Default: false},
+			},
+		},
+		{name: "no workflow_dispatch", content: "on:\n  push:\n    branches: [main]\n", wantErr: "no workflow_dispatch trigger"},
+		{name: "invalid YAML", content: "on: [push\n", wantErr: "error parsing workflow file"},
+		{name: "invalid inputs", content: "on:\n  workflow_dispatch:\n    inputs: [environment]\n", wantErr: "error parsing workflow_dispatch inputs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWorkflowDispatchInputs(tt.content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseWorkflowDispatchInputs() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseWorkflowDispatchInputs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWorkflowDispatchInputs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWorkflowInputs(t *testing.T) {
+	declared := map[string]WorkflowDispatchInput{
+		"environment":     {Required: true, Type: "choice", Options: []string{"staging", "production"}},
+		"release_version": {Required: true},
+		"dry_run":         {Type: "boolean"},
+		"replicas":        {Type: "number"},
+		"region":          {Required: true, Default: "eu"},
+	}
+	tests := []struct {
+		name     string
+		inputs   map[string]interface{}
+		wantErrs []string
+	}{
+		{
+			name:   "valid",
+			inputs: map[string]interface{}{"environment": "production", "release_version": "v1.1.0", "dry_run": "false", "replicas": "3"},
+		},
+		{
+			name:     "undeclared input",
+			inputs:   map[string]interface{}{"environment": "production", "release_version": "v1.1.0", "version": "v1.1.0"},
+			wantErrs: []string{`input "version" is not declared (declared: dry_run, environment, region, release_version, replicas)`},
+		},
+		{
+			name:     "missing required input",
+			inputs:   map[string]interface{}{"environment": "production"},
+			wantErrs: []string{`required input "release_version" is missing`},
+		},
+		{
+			name:   "invalid values",
+			inputs: map[string]interface{}{"environment": "prod", "release_version": "v1.1.0", "dry_run": "yes", "replicas": "three"},
+			wantErrs: []string{
+				`input "environment" is "prod", expected one of staging, production`,
+				`input "dry_run" is "yes", expected true or false`,
+				`input "replicas" is "three", expected a number`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkflowInputs(declared, tt.inputs)
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("validateWorkflowInputs() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("validateWorkflowInputs() error = nil")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("validateWorkflowInputs() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProductionWorkflowDispatchRejectsInvalidInputs(t *testing.T) {
+	fake := githubrepo.NewFakeGithubRepo()
+	fake.AddRepo("api").AddBranch("main", "a1", true).
+		AddFile(prodWorkflowPath, "on:\n  workflow_dispatch:\n    inputs:\n      version:\n        required: true\n").
+		AddWorkflow(1, "Prod", prodWorkflowPath)
+
+	results, _, err := ProductionWorkflowDispatch(context.Background(), testLogger(), fake, testConfig(), []string{"api"})
+	if err == nil {
+		t.Fatal("ProductionWorkflowDispatch() error = nil, want the invalid inputs")
+	}
+	if len(results) != 1 || results[0].Status != DispatchStatusFailed || !strings.Contains(results[0].Error, `required input "version" is missing`) {
+		t.Errorf("results = %+v, want a failed result naming the missing input", results)
+	}
+	if len(fake.Dispatches) != 0 {
+		t.Errorf("dispatched %d workflow(s) with invalid inputs", len(fake.Dispatches))
+	}
+}
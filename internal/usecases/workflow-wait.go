@@ -0,0 +1,144 @@
+package usecases
+
+import (
+	"context"
+	"release-candidate/internal/configs"
+	"release-candidate/internal/usecases/githubrepo"
+	"release-candidate/internal/utils"
+	"time"
+)
+
+// Conclusions of WorkflowDispatchResult for runs that did not complete while waiting
+const (
+	RunConclusionTimedOut = "timed-out"
+	RunConclusionNotFound = "run-not-found"
+)
+
+// runCreatedSkew allows for clock skew between the runner and GitHub when matching a dispatch to its run
+const runCreatedSkew = 10 * time.Second
+
+// WaitForWorkflowRuns follows every dispatched result to the workflow run it started and polls until
+// the runs complete or variables.WorkflowWaitTimeout passes. The dispatch API doesn't return the run,
+// so a run is matched by branch, workflow ID and being the earliest run created after the dispatch
+// that didn't exist before it.
+// Results are updated in place with the run and its conclusion.
+func WaitForWorkflowRuns(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, results []WorkflowDispatchResult) {
+	var pending []int
+	for i, result := range results {
+		if result.Status == DispatchStatusDispatched {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	l.Info("Waiting up to %s for %d dispatched workflow run(s) to complete", variables.WorkflowWaitTimeout, len(pending))
+	deadline := time.Now().Add(variables.WorkflowWaitTimeout)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+			utils.ForEachConcurrently(len(pending), variables.Concurrency, func(i int) {
+				pollWorkflowRun(ctx, l, githubRepo, variables, &results[pending[i]])
+			})
+		}
+
+		var running []int
+		for _, i := range pending {
+			if results[i].Conclusion == "" {
+				running = append(running, i)
+			}
+		}
+		pending = running
+		if len(pending) == 0 {
+			l.Info("All dispatched workflow runs completed")
+			return
+		}
+		if ctx.Err() != nil || !time.Now().Before(deadline) {
+			break
+		}
+		wait := min(variables.WorkflowPollInterval, time.Until(deadline))
+		l.Info("%d workflow run(s) still running, polling again in %s", len(pending), wait.Round(time.Second))
+		timer.Reset(wait)
+	}
+
+	for _, i := range pending {
+		if results[i].RunID == 0 {
+			results[i].Conclusion = RunConclusionNotFound
+			l.Error("No workflow run found for workflow %s of repo %s", results[i].WorkflowPath, results[i].Repo)
+		} else {
+			results[i].Conclusion = RunConclusionTimedOut
+			l.Error("Workflow run %s of repo %s did not complete within %s", results[i].RunURL, results[i].Repo, variables.WorkflowWaitTimeout)
+		}
+	}
+}
+
+// pollWorkflowRun finds the run of a dispatched result if it isn't known yet and records its conclusion once completed.
+// API errors are logged and retried on the next poll.
+func pollWorkflowRun(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, result *WorkflowDispatchResult) {
+	var run githubrepo.RespWorkflowRun
+	if result.RunID == 0 {
+		runs, err := githubRepo.ListWorkflowDispatchRuns(ctx, variables.Owner, result.Repo, result.WorkflowID, githubrepo.ShortRefName(result.Ref), result.DispatchedAt.Add(-runCreatedSkew))
+		if err != nil {
+			l.Warn("Error looking up the run of workflow %s in repo %s: %v", result.WorkflowPath, result.Repo, err)
+			return
+		}
+		var found bool
+		for _, candidate := range runs {
+			if !result.priorRunIDs[candidate.ID] {
+				run, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			l.Debug("Run of workflow %s in repo %s not created yet", result.WorkflowPath, result.Repo)
+			return
+		}
+		result.RunID = run.ID
+		result.RunURL = run.HTMLURL
+		// The run knows the commit it checked out, even if a branch moved since it was resolved
+		if run.HeadSHA != "" {
+			result.SHA = run.HeadSHA
+		}
+		l.Info("Workflow %s in repo %s started run %s", result.WorkflowPath, result.Repo, run.HTMLURL)
+	} else {
+		var err error
+		run, err = githubRepo.GetWorkflowRun(ctx, variables.Owner, result.Repo, result.RunID)
+		if err != nil {
+			l.Warn("Error polling workflow run %d in repo %s: %v", result.RunID, result.Repo, err)
+			return
+		}
+	}
+
+	if run.Status == "completed" {
+		result.Conclusion = run.Conclusion
+		if run.Conclusion == "success" {
+			l.Info("Workflow run %s of repo %s succeeded", run.HTMLURL, result.Repo)
+		} else {
+			l.Error("Workflow run %s of repo %s concluded %s", run.HTMLURL, result.Repo, run.Conclusion)
+		}
+	}
+}
+
+// priorWorkflowRuns lists the runs a dispatch at dispatchedAt could be confused with.
+// If they can't be listed the run is matched on creation time alone.
+func priorWorkflowRuns(ctx context.Context, l utils.LogInterface, githubRepo githubrepo.GitHubWebApis, variables *configs.Config, repo string, workflowID int64, ref string, dispatchedAt time.Time) map[int64]bool {
+	runs, err := githubRepo.ListWorkflowDispatchRuns(ctx, variables.Owner, repo, workflowID, githubrepo.ShortRefName(ref), dispatchedAt.Add(-runCreatedSkew))
+	if err != nil {
+		l.Warn("Error listing existing runs of workflow %d in repo %s: %v", workflowID, repo, err)
+		return nil
+	}
+	prior := make(map[int64]bool, len(runs))
+	for _, run := range runs {
+		prior[run.ID] = true
+	}
+	return prior
+}
+
+// runFailed reports whether a dispatched workflow was waited for and did not succeed
+func runFailed(result WorkflowDispatchResult) bool {
+	return result.Conclusion != "" && result.Conclusion != "success"
+}
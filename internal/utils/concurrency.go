@@ -0,0 +1,31 @@
+package utils
+
+import "sync"
+
+// ForEachConcurrently calls fn for every index in [0, n) with at most limit calls running at once.
+// Callers write into index i of a pre-sized slice so results keep the order of the input.
+func ForEachConcurrently(n int, limit int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > n {
+		limit = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
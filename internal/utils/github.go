@@ -2,19 +2,22 @@ package utils
 
 import (
 	"fmt"
-	"log"
 	"net/http"
-	"regexp"
+	"net/url"
 	"release-candidate/internal/configs"
 	"strconv"
+	"strings"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v66/github"
 )
 
-func CreateGitHubClient(variables *configs.Config) (*github.Client, error) {
+func CreateGitHubClient(l LogInterface, variables *configs.Config) (*github.Client, error) {
+	var client *github.Client
+	// Retries sit beneath authentication so the installation token exchange is retried too
+	transport := NewRetryTransport(http.DefaultTransport, l, variables.MaxRetries)
 	if variables.AppID != "" && variables.PrivateKey != "" && variables.InstallationID != "" {
-		log.Println("Using GitHub App authentication")
+		l.Info("Using GitHub App authentication")
 		appIDInt, err := strconv.ParseInt(variables.AppID, 10, 64)
 		if err != nil {
 			return nil, fmt.Errorf("error converting appID: %v", err)
@@ -23,28 +26,46 @@ func CreateGitHubClient(variables *configs.Config) (*github.Client, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error converting installationID: %v", err)
 		}
-		itr, err := ghinstallation.New(http.DefaultTransport, appIDInt, installationIDInt, []byte(variables.PrivateKey))
+		itr, err := ghinstallation.New(transport, appIDInt, installationIDInt, []byte(variables.PrivateKey))
 		if err != nil {
 			return nil, fmt.Errorf("error creating GitHub installation transport: %v", err)
 		}
-		return github.NewClient(&http.Client{Transport: itr}), nil
+		if variables.GitHubAPIURL != "" {
+			itr.BaseURL = strings.TrimRight(variables.GitHubAPIURL, "/")
+		}
+		client = github.NewClient(&http.Client{Transport: itr})
 	} else if variables.Token != "" {
-		log.Println("Using personal access token authentication")
-		return github.NewClient(nil).WithAuthToken(variables.Token), nil
+		l.Info("Using personal access token authentication")
+		client = github.NewClient(&http.Client{Transport: transport}).WithAuthToken(variables.Token)
 	} else {
 		return nil, fmt.Errorf("no authentication method provided")
 	}
+
+	if variables.GitHubAPIURL != "" {
+		baseURL, err := url.Parse(strings.TrimRight(variables.GitHubAPIURL, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("error parsing GitHub API URL: %v", err)
+		}
+		l.Info("Using GitHub API at %s", baseURL)
+		client.BaseURL = baseURL
+	}
+	return client, nil
 }
 
-// RcValidate validates the release candidate version format.
-func RcVersionValidate(l LogInterface, rcVersion string) error {
+// RcValidate validates the release candidate version format: a semantic version with the v prefix,
+// or a calendar version when allowCalVer is set.
+func RcVersionValidate(l LogInterface, rcVersion string, allowCalVer bool) error {
 	if rcVersion == "" {
 		l.Fatal("rcVersion is required")
 		return fmt.Errorf("rcVersion is required")
 	}
-	if match, _ := regexp.MatchString(`^v\d+\.\d+\.\d+$`, rcVersion); !match {
-		l.Fatal("rcVersion should be in the format v*.*.*")
-		return fmt.Errorf("rcVersion should be in the format v*.*.*")
+	version, err := ParseVersion(rcVersion, allowCalVer)
+	if err == nil && !version.CalVer && !strings.HasPrefix(rcVersion, "v") {
+		err = fmt.Errorf("%s should start with v", rcVersion)
+	}
+	if err != nil {
+		l.Fatal("rcVersion is invalid: %v", err)
+		return fmt.Errorf("rcVersion is invalid: %v", err)
 	}
 	l.Info("Validated rcVersion %s", rcVersion)
 	return nil
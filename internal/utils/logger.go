@@ -2,8 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog"
 )
@@ -16,11 +18,44 @@ type LogInterface interface {
 	Fatal(message interface{}, args ...interface{})
 }
 
+var (
+	fatalHooksMu sync.Mutex
+	fatalHooks   []func()
+)
+
+// OnFatal registers fn to run when Fatal ends the run, such as reporting what the run did so far or releasing what
+// it holds
+func OnFatal(fn func()) {
+	fatalHooksMu.Lock()
+	defer fatalHooksMu.Unlock()
+	fatalHooks = append(fatalHooks, fn)
+}
+
+// runFatalHooks runs the hooks once, newest first, so a hook that fails fatally doesn't run them again
+func runFatalHooks() {
+	fatalHooksMu.Lock()
+	hooks := fatalHooks
+	fatalHooks = nil
+	fatalHooksMu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
 type Logger struct {
 	logger *zerolog.Logger
 }
 
 func NewLogger(level string) LogInterface {
+	return newLogger(level, os.Stdout)
+}
+
+// NewConsoleLogger logs human readable lines to stderr, keeping stdout for the outputs of a CLI run
+func NewConsoleLogger(level string) LogInterface {
+	return newLogger(level, zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: "15:04:05", PartsExclude: []string{zerolog.CallerFieldName}})
+}
+
+func newLogger(level string, w io.Writer) LogInterface {
 	var l zerolog.Level
 
 	switch strings.ToLower(level) {
@@ -40,7 +75,7 @@ func NewLogger(level string) LogInterface {
 
 	zerolog.SetGlobalLevel(l)
 	skipFrameCount := 3
-	logger := zerolog.New(os.Stdout).With().Timestamp().CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + skipFrameCount).Logger()
+	logger := zerolog.New(w).With().Timestamp().CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + skipFrameCount).Logger()
 
 	return &Logger{logger: &logger}
 }
@@ -63,5 +98,8 @@ func (l *Logger) Error(message interface{}, args ...interface{}) {
 }
 
 func (l *Logger) Fatal(message interface{}, args ...interface{}) {
-	l.logger.Fatal().Msgf(fmt.Sprintf("%v", message), args...)
+	// WithLevel logs without exiting, which is left until the hooks have run
+	l.logger.WithLevel(zerolog.FatalLevel).Msgf(fmt.Sprintf("%v", message), args...)
+	runFatalHooks()
+	os.Exit(1)
 }
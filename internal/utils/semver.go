@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"release-candidate/internal/configs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is a semantic version such as v7.1.0, v7.1.0-rc.2 or v7.1.0+hotfix1 (https://semver.org),
+// or a calendar version YEAR.MONTH.MICRO such as 2026.10.1
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// Prerelease holds the dot-separated identifiers after "-", which order the version before its release
+	Prerelease string
+	// Build holds the metadata after "+", which doesn't take part in ordering
+	Build string
+	// CalVer versions are written without the v prefix, with Major as the year and Minor as the month
+	CalVer bool
+	// zeroPadMonth keeps the month of a calendar version written as 2026.01.0 in that form
+	zeroPadMonth bool
+}
+
+const (
+	versionIdentifier = `(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)`
+	versionSuffix     = `(?:-(` + versionIdentifier + `(?:\.` + versionIdentifier + `)*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`
+)
+
+var (
+	semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` + versionSuffix)
+	calverPattern = regexp.MustCompile(`^(\d{4})\.(0?[1-9]|1[0-2])\.(0|[1-9]\d*)` + versionSuffix)
+)
+
+// ParseVersion parses a semantic version, with or without the v prefix. With allowCalVer, calendar
+// versions are accepted too.
+func ParseVersion(s string, allowCalVer bool) (Version, error) {
+	// 2026.10.1 is a valid semantic version too, so calendar versions are matched first
+	if allowCalVer {
+		if match := calverPattern.FindStringSubmatch(s); match != nil {
+			version := newVersion(match, true)
+			version.zeroPadMonth = strings.HasPrefix(match[2], "0")
+			return version, nil
+		}
+	}
+	if match := semverPattern.FindStringSubmatch(s); match != nil {
+		return newVersion(match, false), nil
+	}
+	if allowCalVer {
+		return Version{}, fmt.Errorf("%s is neither a semantic version such as v1.4.0 nor a calendar version such as 2026.10.1", s)
+	}
+	return Version{}, fmt.Errorf("%s is not a semantic version such as v1.4.0, v1.4.0-rc.1 or v1.4.0+build.5", s)
+}
+
+func newVersion(match []string, calVer bool) Version {
+	// The patterns only match numbers that fit
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return Version{Major: major, Minor: minor, Patch: patch, Prerelease: match[4], Build: match[5], CalVer: calVer}
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.CalVer && v.zeroPadMonth {
+		s = fmt.Sprintf("%d.%02d.%d", v.Major, v.Minor, v.Patch)
+	} else if v.CalVer {
+		s = fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	}
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is released before, together with or after other. Following semver,
+// a pre-release comes before its release and build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	ids, otherIDs := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(ids) && i < len(otherIDs); i++ {
+		if cmp := compareIdentifiers(ids[i], otherIDs[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	return sign(len(ids) - len(otherIDs))
+}
+
+// compareIdentifiers orders numeric identifiers numerically and before alphanumeric ones, which are ordered as text
+func compareIdentifiers(a string, b string) int {
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return sign(aNum - bNum)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Less reports whether v is released before other
+func (v Version) Less(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// Bump returns the next major, minor or patch release. A calendar version moves to the month of now instead,
+// counting the releases of the month with the micro number.
+func (v Version) Bump(bump string, now time.Time) Version {
+	if v.CalVer {
+		next := Version{Major: now.Year(), Minor: int(now.Month()), CalVer: true, zeroPadMonth: v.zeroPadMonth}
+		if next.Major == v.Major && next.Minor == v.Minor {
+			next.Patch = v.Patch + 1
+		}
+		return next
+	}
+	switch bump {
+	case configs.VersionBumpMajor:
+		return Version{Major: v.Major + 1}
+	case configs.VersionBumpMinor:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	// A pre-release is followed by its release
+	if v.Prerelease != "" {
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"release-candidate/internal/configs"
+	"testing"
+	"time"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input       string
+		allowCalVer bool
+		want        string
+		wantCalVer  bool
+		wantErr     bool
+	}{
+		{input: "v1.4.0", want: "v1.4.0"},
+		{input: "1.4.0", want: "v1.4.0"},
+		{input: "v1.4.0-rc.1+build.5", want: "v1.4.0-rc.1+build.5"},
+		{input: "v1.4", wantErr: true},
+		{input: "v01.4.0", wantErr: true},
+		{input: "v1.4.0-rc.01", wantErr: true},
+		{input: "latest", wantErr: true},
+		{input: "2026.10.1", want: "v2026.10.1"},
+		{input: "2026.10.1", allowCalVer: true, want: "2026.10.1", wantCalVer: true},
+		{input: "2026.01.0", allowCalVer: true, want: "2026.01.0", wantCalVer: true},
+		{input: "2026.13.0", allowCalVer: true, want: "v2026.13.0"},
+		{input: "v1.4.0", allowCalVer: true, want: "v1.4.0"},
+		{input: "2026.1", allowCalVer: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			version, err := ParseVersion(tt.input, tt.allowCalVer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion(%q, %v) error = %v, wantErr %v", tt.input, tt.allowCalVer, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if version.String() != tt.want || version.CalVer != tt.wantCalVer {
+				t.Errorf("ParseVersion(%q, %v) = %s (calver %v), want %s (calver %v)", tt.input, tt.allowCalVer, version, version.CalVer, tt.want, tt.wantCalVer)
+			}
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "v1.2.3", b: "v1.2.3", want: 0},
+		{a: "v1.2.3", b: "v1.10.0", want: -1},
+		{a: "v2.0.0", b: "v1.99.99", want: 1},
+		{a: "v1.2.3-rc.1", b: "v1.2.3", want: -1},
+		{a: "v1.2.3-rc.2", b: "v1.2.3-rc.10", want: -1},
+		{a: "v1.2.3-rc.1", b: "v1.2.3-rc.1.1", want: -1},
+		{a: "v1.2.3-1", b: "v1.2.3-alpha", want: -1},
+		{a: "v1.2.3-beta", b: "v1.2.3-alpha", want: 1},
+		{a: "v1.2.3+build.1", b: "v1.2.3+build.2", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, errA := ParseVersion(tt.a, false)
+			b, errB := ParseVersion(tt.b, false)
+			if errA != nil || errB != nil {
+				t.Fatalf("ParseVersion() errors = %v, %v", errA, errB)
+			}
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare() = %d, want %d", got, tt.want)
+			}
+			if got := b.Compare(a); got != -tt.want {
+				t.Errorf("reverse Compare() = %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionBump(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		version string
+		bump    string
+		want    string
+	}{
+		{version: "v1.2.3", bump: configs.VersionBumpPatch, want: "v1.2.4"},
+		{version: "v1.2.3", bump: configs.VersionBumpMinor, want: "v1.3.0"},
+		{version: "v1.2.3", bump: configs.VersionBumpMajor, want: "v2.0.0"},
+		{version: "v1.3.0-rc.2", bump: configs.VersionBumpPatch, want: "v1.3.0"},
+		{version: "v1.2.3+build.5", bump: configs.VersionBumpMinor, want: "v1.3.0"},
+		{version: "2026.10.1", bump: configs.VersionBumpPatch, want: "2026.10.2"},
+		{version: "2026.09.4", bump: configs.VersionBumpMajor, want: "2026.10.0"},
+		{version: "2025.12.0", bump: configs.VersionBumpMinor, want: "2026.10.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.bump, func(t *testing.T) {
+			version, err := ParseVersion(tt.version, true)
+			if err != nil {
+				t.Fatalf("ParseVersion() error = %v", err)
+			}
+			if got := version.Bump(tt.bump, now).String(); got != tt.want {
+				t.Errorf("Bump(%s) = %s, want %s", tt.bump, got, tt.want)
+			}
+		})
+	}
+
+	if got := (Version{CalVer: true}).Bump(configs.VersionBumpMinor, now).String(); got != "2026.10.0" {
+		t.Errorf("first calendar version = %s, want 2026.10.0", got)
+	}
+}
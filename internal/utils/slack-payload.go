@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -54,6 +55,49 @@ func buildSlackPayload(headerText, sectionText string, detailsTextSectionList []
 	return string(payloadJSON), nil
 }
 
+// WithSlackChannel adds the channel to post to to a payload, for senders such as chat.postMessage that read it from the payload
+func WithSlackChannel(payload string, channel string) (string, error) {
+	var blocks map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &blocks); err != nil {
+		return "", err
+	}
+	blocks["channel"] = channel
+
+	payloadJSON, err := json.MarshalIndent(blocks, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(payloadJSON), nil
+}
+
+// WithSlackNotice adds a section with text right below the header of a payload
+func WithSlackNotice(payload string, text string) (string, error) {
+	var message map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		return "", err
+	}
+	blocks, _ := message["blocks"].([]interface{})
+	notice := map[string]interface{}{
+		"type": "section",
+		"text": map[string]string{
+			"type": "mrkdwn",
+			"text": text,
+		},
+	}
+	if len(blocks) > 0 {
+		blocks = append(blocks[:1], append([]interface{}{notice}, blocks[1:]...)...)
+	} else {
+		blocks = []interface{}{notice}
+	}
+	message["blocks"] = blocks
+
+	payloadJSON, err := json.MarshalIndent(message, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(payloadJSON), nil
+}
+
 func buildDetailsTextSectionList(items []string) []interface{} {
 	var detailsTextSectionList []interface{}
 	for _, section := range items {
@@ -83,24 +127,120 @@ func buildSections(items []map[string]interface{}, formatFunc func(map[string]in
 	return sections
 }
 
-func ProductionWorkflowDispatchSlackPayloadBuilder(rcVersion string, repoList []string, environment string) (string, error) {
-	formatFunc := func(repo map[string]interface{}) string {
-		return fmt.Sprintf(
-			"• *`%s`* :rocket: Successfully dispatched! :heavy_check_mark:\n",
-			repo["repo"],
-		)
+// formatWorkflowDispatchResult renders one dispatch result, with the run outcome when it was waited for
+func formatWorkflowDispatchResult(result map[string]interface{}) string {
+	workflow := ""
+	if name, ok := result["workflow"].(string); ok && name != "" {
+		workflow = fmt.Sprintf(" (%s)", name)
+	}
+	// The commit shipped, abbreviated like git does
+	if sha, ok := result["sha"].(string); ok && sha != "" {
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		workflow += fmt.Sprintf(" `%s@%s`", result["ref"], sha)
+	}
+	run := ""
+	if runURL, ok := result["run_url"].(string); ok && runURL != "" {
+		run = fmt.Sprintf(" <%s|Run-Link>", runURL)
+	}
+	switch result["status"] {
+	case "failed":
+		return fmt.Sprintf("• *`%s`*%s :x: Failed - %s\n", result["repo"], workflow, result["error"])
+	case "skipped-no-workflow":
+		return fmt.Sprintf("• *`%s`* :zzz: Skipped - no production workflow found\n", result["repo"])
+	case "aborted":
+		return fmt.Sprintf("• *`%s`* :double_vertical_bar: Not dispatched - an earlier wave failed\n", result["repo"])
 	}
+	switch result["conclusion"] {
+	case nil, "":
+		return fmt.Sprintf("• *`%s`*%s :rocket: Successfully dispatched! :heavy_check_mark:\n", result["repo"], workflow)
+	case "success":
+		return fmt.Sprintf("• *`%s`*%s :white_check_mark: Succeeded%s\n", result["repo"], workflow, run)
+	case "cancelled":
+		return fmt.Sprintf("• *`%s`*%s :no_entry_sign: Cancelled%s\n", result["repo"], workflow, run)
+	case "timed-out":
+		return fmt.Sprintf("• *`%s`*%s :hourglass: Still running when the wait timed out%s\n", result["repo"], workflow, run)
+	case "run-not-found":
+		return fmt.Sprintf("• *`%s`*%s :grey_question: Dispatched but no workflow run was found\n", result["repo"], workflow)
+	default:
+		return fmt.Sprintf("• *`%s`*%s :x: Run concluded %s%s\n", result["repo"], workflow, result["conclusion"], run)
+	}
+}
+
+// workflowDispatchFailed reports whether a dispatch, or the run it started, failed
+func workflowDispatchFailed(result map[string]interface{}) bool {
+	conclusion, _ := result["conclusion"].(string)
+	return result["status"] == "failed" || (conclusion != "" && conclusion != "success")
+}
 
-	var repoItems []map[string]interface{}
-	for _, repo := range repoList {
-		repoItems = append(repoItems, map[string]interface{}{"repo": repo})
+func ProductionWorkflowDispatchSlackPayloadBuilder(rcVersion string, dispatchResults []map[string]interface{}, environment string) (string, error) {
+	// Failures get their own sections first so they can't be missed among the successes
+	var failed, others []map[string]interface{}
+	for _, result := range dispatchResults {
+		if workflowDispatchFailed(result) {
+			failed = append(failed, result)
+		} else {
+			others = append(others, result)
+		}
 	}
 
-	sections := buildSections(repoItems, formatFunc)
-	detailsTextSectionList := buildDetailsTextSectionList(sections)
+	var detailsTextSectionList []interface{}
+	if len(failed) > 0 {
+		failedSections := buildSections(failed, formatWorkflowDispatchResult)
+		failedSections[0] = fmt.Sprintf("*:rotating_light: %d dispatch(es) or run(s) failed*\n", len(failed)) + failedSections[0]
+		detailsTextSectionList = append(detailsTextSectionList, buildDetailsTextSectionList(failedSections)...)
+		detailsTextSectionList = append(detailsTextSectionList, map[string]interface{}{"type": "divider"})
+	}
+	detailsTextSectionList = append(detailsTextSectionList, buildDetailsTextSectionList(buildSections(others, formatWorkflowDispatchResult))...)
 
 	headerText := fmt.Sprintf("🚀 Production Pipeline Dispatch - %s to %s :vertical_traffic_light:", rcVersion, environment)
 	sectionText := "The production pipeline has been dispatched for the following repositories: 🚀"
+	if len(failed) > 0 {
+		sectionText = fmt.Sprintf("The production pipeline dispatch finished with %d failure(s). :warning:", len(failed))
+	}
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
+
+// ProductionWaveDispatchSlackPayloadBuilder renders one section per wave in rollout order.
+// Each wave has a name, a status (succeeded, failed, aborted) and the dispatch results of its repos.
+func ProductionWaveDispatchSlackPayloadBuilder(rcVersion string, waves []map[string]interface{}, environment string) (string, error) {
+	var detailsTextSectionList []interface{}
+	var failedWave interface{}
+	for i, wave := range waves {
+		var status string
+		switch wave["status"] {
+		case "succeeded":
+			status = ":white_check_mark: Succeeded"
+		case "failed":
+			status = ":x: Failed"
+			failedWave = wave["name"]
+		case "aborted":
+			status = ":double_vertical_bar: Aborted"
+		default:
+			status = fmt.Sprintf(":grey_question: %v", wave["status"])
+		}
+
+		results, _ := wave["results"].([]map[string]interface{})
+		sections := buildSections(results, formatWorkflowDispatchResult)
+		heading := fmt.Sprintf("*Wave %d: %s* %s\n", i+1, wave["name"], status)
+		if len(sections) == 0 {
+			sections = []string{heading}
+		} else {
+			sections[0] = heading + sections[0]
+		}
+		if i > 0 {
+			detailsTextSectionList = append(detailsTextSectionList, map[string]interface{}{"type": "divider"})
+		}
+		detailsTextSectionList = append(detailsTextSectionList, buildDetailsTextSectionList(sections)...)
+	}
+
+	headerText := fmt.Sprintf("🌊 Production Wave Rollout - %s to %s :vertical_traffic_light:", rcVersion, environment)
+	sectionText := fmt.Sprintf("The production pipeline has been rolled out in %d wave(s): 🚀", len(waves))
+	if failedWave != nil {
+		sectionText = fmt.Sprintf("The production rollout stopped at wave *%v*; later waves were not dispatched. :warning:", failedWave)
+	}
 
 	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
 }
@@ -108,7 +248,14 @@ func ProductionWorkflowDispatchSlackPayloadBuilder(rcVersion string, repoList []
 func MainToEpicSyncSlackPayloadBuilder(rcVersion string, prResultsByEpic map[string][]map[string]interface{}) (string, error) {
 	var detailsTextSectionList []interface{}
 
-	for epic, prs := range prResultsByEpic {
+	epics := make([]string, 0, len(prResultsByEpic))
+	for epic := range prResultsByEpic {
+		epics = append(epics, epic)
+	}
+	sort.Strings(epics)
+
+	for _, epic := range epics {
+		prs := prResultsByEpic[epic]
 		var details strings.Builder
 		details.WriteString(fmt.Sprintf("*Epic: %s*\n", epic))
 		for _, pr := range prs {
@@ -141,3 +288,205 @@ func MainToEpicSyncSlackPayloadBuilder(rcVersion string, prResultsByEpic map[str
 
 	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
 }
+
+func ReleaseCandidateSlackPayloadBuilder(rcVersion string, productionBranch string, prResults []map[string]interface{}) (string, error) {
+	formatFunc := func(pr map[string]interface{}) string {
+		repo := pr["repo"].(string)
+		if prUrl, ok := pr["url"].(string); ok && prUrl != "" {
+			if hasConflicts, _ := pr["hasConflicts"].(bool); hasConflicts {
+				return fmt.Sprintf("• *`%s`:* <%s|:warning: PR-Link (Conflicts)>\n", repo, prUrl)
+			}
+			return fmt.Sprintf("• *`%s`:* <%s|:white_check_mark: PR-Link>\n", repo, prUrl)
+		}
+		if errStr, ok := pr["error"].(string); ok && errStr != "" {
+			if strings.Contains(errStr, "No commits between") {
+				return fmt.Sprintf("• *`%s`:* :zzz: No changes\n", repo)
+			}
+			return fmt.Sprintf("• *`%s`:* :x: Failed - %s\n", repo, errStr)
+		}
+		return fmt.Sprintf("• *`%s`:* :grey_question: No PR\n", repo)
+	}
+
+	sections := buildSections(prResults, formatFunc)
+	detailsTextSectionList := buildDetailsTextSectionList(sections)
+
+	headerText := fmt.Sprintf("🌊 Release Candidate - %s", rcVersion)
+	sectionText := fmt.Sprintf("Release candidate PRs into `%s` have been raised for the following repositories: 📋", productionBranch)
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
+
+func GitHubReleaseSlackPayloadBuilder(rcVersion string, releaseResults []map[string]interface{}, draft bool) (string, error) {
+	formatFunc := func(release map[string]interface{}) string {
+		repo := release["repo"].(string)
+		switch release["status"] {
+		case "failed":
+			return fmt.Sprintf("• *`%s`:* :x: Failed - %s\n", repo, release["error"])
+		case "exists":
+			return fmt.Sprintf("• *`%s`:* <%s|:label: Release-Link> (already released)\n", repo, release["url"])
+		}
+		if url, ok := release["url"].(string); ok && url != "" {
+			return fmt.Sprintf("• *`%s`:* <%s|:label: Release-Link>\n", repo, url)
+		}
+		return fmt.Sprintf("• *`%s`:* :label: Released\n", repo)
+	}
+
+	sections := buildSections(releaseResults, formatFunc)
+	detailsTextSectionList := buildDetailsTextSectionList(sections)
+
+	headerText := fmt.Sprintf("🏷️ GitHub Releases - %s", rcVersion)
+	sectionText := "GitHub releases with generated release notes have been published for the following repositories: 📋"
+	if draft {
+		sectionText = "Draft GitHub releases with generated release notes are ready to review for the following repositories: 📋"
+	}
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
+
+func ChangelogSlackPayloadBuilder(rcVersion string, changelogs []map[string]interface{}) (string, error) {
+	formatFunc := func(changelog map[string]interface{}) string {
+		repo := changelog["repo"].(string)
+		if err, ok := changelog["error"].(string); ok && err != "" {
+			return fmt.Sprintf("• *`%s`:* :x: Failed - %s\n", repo, err)
+		}
+		if skipped, ok := changelog["skipped"].(string); ok && skipped != "" {
+			return fmt.Sprintf("• *`%s`:* :grey_question: Skipped - %s\n", repo, skipped)
+		}
+		groups, _ := changelog["groups"].([]map[string]interface{})
+		if len(groups) == 0 {
+			return fmt.Sprintf("• *`%s`:* :zzz: No changes\n", repo)
+		}
+		var counts []string
+		for _, group := range groups {
+			counts = append(counts, fmt.Sprintf("%v %s", group["count"], group["name"]))
+		}
+		return fmt.Sprintf("• *`%s`:* %s <%s|:mag: Compare>\n", repo, strings.Join(counts, ", "), changelog["compare_url"])
+	}
+
+	sections := buildSections(changelogs, formatFunc)
+	detailsTextSectionList := buildDetailsTextSectionList(sections)
+
+	headerText := fmt.Sprintf("📰 Changelog - %s", rcVersion)
+	sectionText := "The following changes are part of this release: 📋"
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
+
+// HotfixSlackPayloadBuilder reports a hotfix stage. Release items are dispatch results, next to the repos the hotfix didn't affect.
+func HotfixSlackPayloadBuilder(rcVersion string, stage string, hotfixResults []map[string]interface{}) (string, error) {
+	formatFunc := func(hotfix map[string]interface{}) string {
+		repo := hotfix["repo"].(string)
+		switch hotfix["status"] {
+		case "branch-created":
+			return fmt.Sprintf("• *`%s`:* :seedling: `%s` is ready for the fix\n", repo, hotfix["branch"])
+		case "no-changes":
+			return fmt.Sprintf("• *`%s`:* :zzz: No changes on `%s`\n", repo, hotfix["branch"])
+		case "not-affected":
+			return fmt.Sprintf("• *`%s`:* :zzz: Not affected\n", repo)
+		case "pull-requests-opened":
+			line := fmt.Sprintf("• *`%s`:* <%s|Production-PR> | <%s|Development-PR>", repo, hotfix["production_pr_url"], hotfix["development_pr_url"])
+			if hasConflicts, ok := hotfix["hasConflicts"].(bool); ok && hasConflicts {
+				line += " :warning: Has conflicts"
+			}
+			return line + "\n"
+		}
+		return formatWorkflowDispatchResult(hotfix)
+	}
+
+	sections := buildSections(hotfixResults, formatFunc)
+	detailsTextSectionList := buildDetailsTextSectionList(sections)
+
+	headerText := fmt.Sprintf("🩹 Hotfix - %s", rcVersion)
+	sectionText := "Hotfix branches have been cut from the production branch. Push the fix to them, then open the pull requests: 🛠️"
+	switch stage {
+	case "pull-requests":
+		sectionText = "Hotfix pull requests into the production and development branches are ready for review: 👀"
+	case "release":
+		sectionText = "The repositories fixed by the hotfix have been dispatched to production: 🚀"
+	}
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
+
+// RollbackSlackPayloadBuilder reports a rollback from rcVersion. Items are dispatch results with the to_version of their
+// repo, next to the repos that had no release to roll back to.
+func RollbackSlackPayloadBuilder(rcVersion string, rollbackResults []map[string]interface{}, environment string) (string, error) {
+	failed := 0
+	for _, result := range rollbackResults {
+		if workflowDispatchFailed(result) {
+			failed++
+		}
+	}
+	formatFunc := func(result map[string]interface{}) string {
+		line := formatWorkflowDispatchResult(result)
+		if toVersion, ok := result["to_version"].(string); ok && toVersion != "" {
+			line = fmt.Sprintf("• *`%s`* :rewind: %s%s", result["repo"], toVersion, strings.TrimPrefix(line, fmt.Sprintf("• *`%s`*", result["repo"])))
+		}
+		return line
+	}
+
+	sections := buildSections(rollbackResults, formatFunc)
+	detailsTextSectionList := buildDetailsTextSectionList(sections)
+
+	headerText := fmt.Sprintf("⏪ Rollback - %s on %s :rotating_light:", rcVersion, environment)
+	sectionText := fmt.Sprintf("%s is being rolled back, the previous releases have been redeployed: ⏪", rcVersion)
+	if failed > 0 {
+		sectionText = fmt.Sprintf("The rollback of %s finished with %d failure(s). :warning:", rcVersion, failed)
+	}
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
+
+// ReadinessSlackPayloadBuilder reports the repos that failed the readiness gate, which stopped the release
+func ReadinessSlackPayloadBuilder(rcVersion string, readinessResults []map[string]interface{}) (string, error) {
+	notReady := 0
+	for _, result := range readinessResults {
+		if ready, _ := result["ready"].(bool); !ready {
+			notReady++
+		}
+	}
+	formatFunc := func(result map[string]interface{}) string {
+		if ready, _ := result["ready"].(bool); ready {
+			return fmt.Sprintf("• *`%s`* :white_check_mark: Ready\n", result["repo"])
+		}
+		return fmt.Sprintf("• *`%s`* :x: Not ready - %s\n", result["repo"], result["problems"])
+	}
+
+	sections := buildSections(readinessResults, formatFunc)
+	detailsTextSectionList := buildDetailsTextSectionList(sections)
+
+	headerText := fmt.Sprintf("🚦 Release Readiness - %s :no_entry:", rcVersion)
+	sectionText := fmt.Sprintf("%d of %d repositories are not ready for production, nothing has been dispatched: :warning:", notReady, len(readinessResults))
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
+
+// FreezeSlackPayloadBuilder reports a release stopped by a freeze window
+func FreezeSlackPayloadBuilder(rcVersion string, window string, until string) (string, error) {
+	headerText := fmt.Sprintf("🧊 Release Freeze - %s :no_entry:", rcVersion)
+	sectionText := fmt.Sprintf("Production releases are frozen by *%s* until %s, nothing has been dispatched. "+
+		"Rerun with `freeze_override` and a `freeze_override_reason` to release anyway. :lock:", window, until)
+
+	return buildSlackPayload(headerText, sectionText, nil)
+}
+
+func DryRunPlanSlackPayloadBuilder(useCase string, rcVersion string, actions []map[string]interface{}) (string, error) {
+	formatFunc := func(action map[string]interface{}) string {
+		line := fmt.Sprintf("• *`%s`:* would `%s` `%s`", action["repo"], action["action"], action["target"])
+		if details, ok := action["details"].(string); ok && details != "" {
+			line += fmt.Sprintf(" - %s", details)
+		}
+		return line + "\n"
+	}
+
+	sections := buildSections(actions, formatFunc)
+	if len(sections) == 0 {
+		sections = []string{"No changes would be made. :zzz:"}
+	}
+	detailsTextSectionList := buildDetailsTextSectionList(sections)
+
+	headerText := fmt.Sprintf("📝 Dry Run Plan - %s %s", useCase, rcVersion)
+	sectionText := fmt.Sprintf("Nothing has been changed yet. Approve the following %d action(s) before running for real: :eyes:", len(actions))
+
+	return buildSlackPayload(headerText, sectionText, detailsTextSectionList)
+}
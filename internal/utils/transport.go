@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryTransport sits beneath the GitHub client and retries requests that failed transiently.
+// Rate limited requests (primary, secondary and abuse limits) were rejected by GitHub, so they are
+// retried for every method. 502/503/504s and network errors are only retried for reads and for writes
+// marked WithReplaySafe, since other writes such as a lock file commit may already have taken effect.
+type RetryTransport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	// BaseDelay is the first backoff step; each retry doubles it, with full jitter, up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxWait is the longest Retry-After or rate limit reset the transport waits for before giving up
+	MaxWait time.Duration
+	// SecondaryLimitWait is how long to wait on a secondary rate limit that doesn't say when to retry
+	SecondaryLimitWait time.Duration
+	l                  LogInterface
+}
+
+func NewRetryTransport(base http.RoundTripper, l LogInterface, maxRetries int) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{
+		Base:       base,
+		MaxRetries: maxRetries,
+		BaseDelay:  time.Second,
+		MaxDelay:   30 * time.Second,
+		MaxWait:    10 * time.Minute,
+		// GitHub asks to wait at least a minute
+		SecondaryLimitWait: time.Minute,
+		l:                  l,
+	}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				// The body can't be replayed, so a retry would send an empty request
+				return t.Base.RoundTrip(req)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("error rewinding request body: %v", err)
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+		if err != nil {
+			if attempt >= t.MaxRetries || !canReplay(req) || req.Context().Err() != nil {
+				return nil, err
+			}
+			wait := t.backoff(attempt)
+			t.l.Warn("GitHub request %s %s failed: %v; retrying in %s (attempt %d/%d)", req.Method, req.URL.Path, err, wait, attempt+1, t.MaxRetries)
+			if err := sleepContext(req, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		t.logQuota(resp)
+
+		wait, retry := t.retryAfter(req, resp, attempt)
+		if !retry || attempt >= t.MaxRetries {
+			if err := t.waitForQuotaReset(req, resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+		if wait > t.MaxWait {
+			t.l.Error("GitHub asked to wait %s before retrying %s %s, which exceeds %s; giving up", wait, req.Method, req.URL.Path, t.MaxWait)
+			return resp, nil
+		}
+
+		t.l.Warn("GitHub request %s %s returned %d; retrying in %s (attempt %d/%d)", req.Method, req.URL.Path, resp.StatusCode, wait.Round(time.Millisecond), attempt+1, t.MaxRetries)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := sleepContext(req, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter decides whether resp should be retried and how long to wait first
+func (t *RetryTransport) retryAfter(req *http.Request, resp *http.Response, attempt int) (time.Duration, bool) {
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if !isRateLimited(resp) {
+			return 0, false
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+				// Add a second of slack for clock skew between us and GitHub
+				return time.Until(time.Unix(reset, 0)) + time.Second, true
+			}
+		}
+		// Secondary limits without headers
+		return t.SecondaryLimitWait + t.backoff(attempt), true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return t.backoff(attempt), canReplay(req)
+	}
+	return 0, false
+}
+
+// isRateLimited tells rate limit 403/429s apart from permission errors.
+// The body is restored so go-github can still parse it.
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return true
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection")
+}
+
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	delay := t.BaseDelay << attempt
+	if delay <= 0 || delay > t.MaxDelay {
+		delay = t.MaxDelay
+	}
+	// Full jitter keeps concurrent workers from retrying in lockstep
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// waitForQuotaReset holds back a response that used up the last request of the quota until it resets.
+// Otherwise go-github would fail the next call with a RateLimitError without sending it.
+func (t *RetryTransport) waitForQuotaReset(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode >= 300 || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	wait := time.Until(time.Unix(reset, 0)) + time.Second
+	if wait <= 0 || wait > t.MaxWait {
+		return nil
+	}
+	t.l.Warn("GitHub %s rate limit exhausted; waiting %s for it to reset", resp.Header.Get("X-RateLimit-Resource"), wait.Round(time.Second))
+	return sleepContext(req, wait)
+}
+
+func (t *RetryTransport) logQuota(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if limit > 0 && remaining < limit/10 {
+		t.l.Warn("GitHub %s rate limit low: %d/%d requests remaining", resource, remaining, limit)
+	} else {
+		t.l.Debug("GitHub %s rate limit: %d/%d requests remaining", resource, remaining, limit)
+	}
+}
+
+type replaySafeKey struct{}
+
+// WithReplaySafe marks the writes made with ctx as safe to send again after a 502/503/504 or network error.
+// Callers use it for writes whose repeat is harmless or detected, e.g. a workflow dispatch or a ref
+// creation that treats "already exists" at the expected sha as success.
+func WithReplaySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replaySafeKey{}, true)
+}
+
+func canReplay(req *http.Request) bool {
+	replaySafe, _ := req.Context().Value(replaySafeKey{}).(bool)
+	return replaySafe || isIdempotent(req.Method)
+}
+
+// isIdempotent reports whether a request can be sent again without changing its outcome. PUT and DELETE are left
+// out: the contents API uses them as compare-and-swap writes, which fail on retry when the first attempt took effect.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func sleepContext(req *http.Request, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
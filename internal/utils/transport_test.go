@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingServer answers the first failures requests with respond and the rest with 200 OK
+func failingServer(t *testing.T, failures int, respond func(w http.ResponseWriter)) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost && string(body) != `{"ref":"main"}` {
+			t.Errorf("attempt %d sent body %q, want the original body", atomic.LoadInt32(&requests)+1, body)
+		}
+		if int(atomic.AddInt32(&requests, 1)) <= failures {
+			respond(w)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func testTransport(maxRetries int) *RetryTransport {
+	transport := NewRetryTransport(http.DefaultTransport, NewLogger("error"), maxRetries)
+	transport.BaseDelay = time.Millisecond
+	transport.MaxDelay = time.Millisecond
+	transport.SecondaryLimitWait = time.Millisecond
+	return transport
+}
+
+func TestRetryTransport(t *testing.T) {
+	unavailable := func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) }
+	tests := []struct {
+		name       string
+		method     string
+		replaySafe bool
+		failures   int
+		respond    func(w http.ResponseWriter)
+		maxWait    time.Duration
+		wantStatus int
+		// wantRequests counts the attempts that reached the server
+		wantRequests int32
+	}{
+		{
+			name:     "Retry-After",
+			method:   http.MethodGet,
+			failures: 2,
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantStatus:   http.StatusOK,
+			wantRequests: 3,
+		},
+		{
+			name:     "Retry-After beyond the longest wait",
+			method:   http.MethodGet,
+			failures: 1,
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			maxWait:      time.Minute,
+			wantStatus:   http.StatusTooManyRequests,
+			wantRequests: 1,
+		},
+		{
+			name:     "secondary rate limit",
+			method:   http.MethodPost,
+			failures: 1,
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				io.WriteString(w, `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`)
+			},
+			wantStatus:   http.StatusOK,
+			wantRequests: 2,
+		},
+		{
+			name:     "permission error",
+			method:   http.MethodGet,
+			failures: 1,
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				io.WriteString(w, `{"message":"Resource not accessible by integration"}`)
+			},
+			wantStatus:   http.StatusForbidden,
+			wantRequests: 1,
+		},
+		{
+			name:         "retry limit",
+			method:       http.MethodGet,
+			failures:     10,
+			respond:      unavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantRequests: 3,
+		},
+		{
+			name:         "unavailable write",
+			method:       http.MethodPost,
+			failures:     1,
+			respond:      unavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantRequests: 1,
+		},
+		{
+			name:         "unavailable replay-safe write",
+			method:       http.MethodPost,
+			replaySafe:   true,
+			failures:     1,
+			respond:      unavailable,
+			wantStatus:   http.StatusOK,
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := failingServer(t, tt.failures, tt.respond)
+			transport := testTransport(2)
+			if tt.maxWait > 0 {
+				transport.MaxWait = tt.maxWait
+			}
+			ctx := context.Background()
+			if tt.replaySafe {
+				ctx = WithReplaySafe(ctx)
+			}
+			var body io.Reader
+			if tt.method == http.MethodPost {
+				body = strings.NewReader(`{"ref":"main"}`)
+			}
+			req, err := http.NewRequestWithContext(ctx, tt.method, server.URL+"/repos/o/api", body)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(requests); got != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRetryTransportKeepsRateLimitBody(t *testing.T) {
+	server, _ := failingServer(t, 1, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"message":"Must have admin rights to Repository."}`)
+	})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	resp, err := testTransport(2).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); !strings.Contains(string(body), "admin rights") {
+		t.Errorf("body = %q, want the error GitHub sent", body)
+	}
+}
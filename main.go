@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 
+	"release-candidate/internal/cli"
 	"release-candidate/internal/configs"
 	"release-candidate/internal/usecases"
 	"release-candidate/internal/usecases/githubrepo"
@@ -12,35 +15,115 @@ import (
 func main() {
 
 	// ctx := context.Background()
-	config, _ := configs.Variables()
-
-	l := utils.NewLogger(config.LogLevel)
+	var config *configs.Config
+	var l utils.LogInterface
+	// A command selects a CLI run. Other arguments are a mistyped command outside GitHub Actions, and are ignored
+	// inside it, where the action's container reads its inputs from the environment.
+	cliRun := len(os.Args) > 1 && (cli.IsCommand(os.Args[1]) || os.Getenv("GITHUB_ACTIONS") != "true")
+	if cliRun {
+		var err error
+		config, err = cli.Config(os.Args[1:], os.Getenv, os.Stderr)
+		if cli.IsHelp(err) {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		l = utils.NewConsoleLogger(config.LogLevel)
+	} else {
+		config, _ = configs.Variables()
+		l = utils.NewLogger(config.LogLevel)
+		if len(os.Args) > 1 {
+			l.Warn("Ignoring the arguments %v, the action reads its inputs from the environment", os.Args[1:])
+		}
+	}
 	l.Info("Starting release candidate process")
 	l.Info("RCVersion: %s", config.RCVersion)
 
-	if err := utils.RcVersionValidate(l, config.RCVersion); err != nil {
-		l.Fatal("Error validating RC version: %v", err)
+	// An automatic version is computed once the GitHub client exists
+	autoVersion := config.RCVersion == configs.RCVersionAuto
+	if !autoVersion {
+		if err := utils.RcVersionValidate(l, config.RCVersion, config.AllowCalVer); err != nil {
+			l.Fatal("Error validating RC version: %v", err)
+		}
+	}
+	if config.RollbackVersion != "" {
+		if err := utils.RcVersionValidate(l, config.RollbackVersion, config.AllowCalVer); err != nil {
+			l.Fatal("Error validating rollback version: %v", err)
+		}
 	}
 
-	config.RCBranch = "rc/" + config.RCVersion
-
-	githubClient, err := utils.CreateGitHubClient(config)
+	githubClient, err := utils.CreateGitHubClient(l, config)
 	if err != nil {
 		l.Fatal("Error creating GitHub client: %v", err)
 	}
 
+	githubRepo := githubrepo.NewGithubRepo(githubClient, l)
+	var plan *githubrepo.DryRunPlan
+	if config.DryRun {
+		l.Info("Dry run enabled: mutating GitHub calls will only be recorded")
+		plan = githubrepo.NewDryRunPlan()
+		githubRepo = githubRepo.WithDryRun(plan)
+		// A use case failing fatally never returns, and its plan so far shows how far the run would get
+		utils.OnFatal(func() {
+			l.Warn("Dry run stopped early, the plan only has the actions recorded before the failure")
+			usecases.ReportDryRunPlan(l, config, plan)
+		})
+	}
+
+	if autoVersion {
+		if err := usecases.ResolveNextVersion(context.Background(), l, githubRepo, config); err != nil {
+			l.Fatal("Error computing the next version: %v", err)
+		}
+	}
+	config.RCBranch = "rc/" + config.RCVersion
+	config.HotfixBranch = "hotfix/" + config.RCVersion
+
+	// Changelog only reads, every other use case changes the repositories and holds the lock of the organization
+	if config.LockRepository != "" && config.UseCase != "Changelog" {
+		if config.DryRun {
+			l.Info("Dry run: the release lock is not taken")
+		} else {
+			releaseLock, err := usecases.AcquireReleaseLock(context.Background(), l, githubRepo, config)
+			if err != nil {
+				l.Fatal("Error acquiring the release lock: %v", err)
+			}
+			utils.OnFatal(releaseLock)
+			defer releaseLock()
+		}
+	}
+
 	switch config.UseCase {
+	case "Release-Candidate":
+		l.Info("Release-Candidate use case")
+		usecases.ReleaseCandidateUseCase(context.Background(), l, githubRepo, config)
 	case "Production-Release":
 		l.Info("Production-Release use case")
-		usecases.ProductionReleaseUseCase(context.Background(), l, githubClient, config)
+		usecases.ProductionReleaseUseCase(context.Background(), l, githubRepo, config)
 	case "Main-To-Epic-Sync":
 		l.Info("Main-To-Epic-Sync use case")
-		githubRepo := githubrepo.NewGithubRepo(githubClient, l)
 		// repoList is nil because it will be fetched later from the github repo
 		usecases.MainToEpicSyncUseCase(context.Background(), l, githubRepo, config, nil)
+	case "GitHub-Release":
+		l.Info("GitHub-Release use case")
+		usecases.GitHubReleaseUseCase(context.Background(), l, githubRepo, config)
+	case "Hotfix":
+		l.Info("Hotfix use case")
+		usecases.HotfixUseCase(context.Background(), l, githubRepo, config)
+	case "Rollback":
+		l.Info("Rollback use case")
+		usecases.RollbackUseCase(context.Background(), l, githubRepo, config)
+	case "Changelog":
+		l.Info("Changelog use case")
+		usecases.ChangelogUseCase(context.Background(), l, githubRepo, config)
 	default:
 		l.Fatal("Invalid use case")
 
 	}
 
+	if plan != nil {
+		usecases.ReportDryRunPlan(l, config, plan)
+	}
+
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"release-candidate/internal/ghemulator"
+	"strings"
+	"testing"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so the entry point can be run as a process
+const runMainEnv = "RELEASE_CANDIDATE_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the binary with args and the environment variables in env, on top of a clean GitHub Actions environment
+func runMain(t *testing.T, args []string, env map[string]string) ([]byte, error) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1", "GITHUB_OUTPUT=", "GITHUB_ACTIONS=")
+	for name, value := range env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	return cmd.CombinedOutput()
+}
+
+func TestMainAgainstEmulator(t *testing.T) {
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{
+		{
+			Name:      "api",
+			Branches:  []*ghemulator.Branch{{Name: "main", SHA: "a1"}},
+			Workflows: []*ghemulator.Workflow{{ID: 1, Name: "Prod", Path: ".github/workflows/prod-release.yml"}},
+		},
+		{
+			Name:      "web",
+			Branches:  []*ghemulator.Branch{{Name: "main", SHA: "w1"}},
+			Workflows: []*ghemulator.Workflow{{ID: 2, Name: "Lint", Path: ".github/workflows/lint.yml"}},
+		},
+	}}
+	emulator := ghemulator.New(state)
+	server := emulator.Start()
+	defer server.Close()
+	inputs := map[string]string{
+		"INPUT_GITHUB_API_URL":    server.URL,
+		"INPUT_OWNER":             "o",
+		"INPUT_GITHUB_TOKEN":      "test-token",
+		"INPUT_USE_CASE":          "Production-Release",
+		"INPUT_ENVIRONMENT":       "production",
+		"INPUT_RC_VERSION":        "v1.1.0",
+		"INPUT_PRODUCTION_BRANCH": "main",
+		"INPUT_LOG_LEVEL":         "error",
+	}
+
+	if out, err := runMain(t, nil, inputs); err != nil {
+		t.Fatalf("main() error = %v, output:\n%s", err, out)
+	}
+	repos := emulator.State().Repos
+	if dispatches := repos[0].Dispatches; len(dispatches) != 1 || dispatches[0].Ref != "refs/heads/main" || dispatches[0].Inputs["release_version"] != "v1.1.0" {
+		t.Errorf("api has %d dispatch(es), want one dispatch of v1.1.0 on main", len(dispatches))
+	}
+	if dispatches := repos[1].Dispatches; len(dispatches) != 0 {
+		t.Errorf("web has %d dispatch(es), want none without a production workflow", len(dispatches))
+	}
+
+	inputs["INPUT_RC_VERSION"] = "1.2"
+	if out, err := runMain(t, nil, inputs); err == nil {
+		t.Errorf("main() with an invalid rc_version succeeded, output:\n%s", out)
+	}
+}
+
+func TestMainCommandLine(t *testing.T) {
+	state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{
+		Name:      "api",
+		Branches:  []*ghemulator.Branch{{Name: "main", SHA: "a1"}},
+		Workflows: []*ghemulator.Workflow{{ID: 1, Name: "Prod", Path: ".github/workflows/prod-release.yml"}},
+	}}}
+	emulator := ghemulator.New(state)
+	server := emulator.Start()
+	defer server.Close()
+	env := map[string]string{"RELEASE_WAVE_GITHUB_API_URL": server.URL, "GITHUB_TOKEN": "test-token"}
+	args := []string{"production-release", "--owner", "o", "--rc-version", "v1.1.0", "--production-branch", "main", "--environment", "production", "--dry-run"}
+
+	out, err := runMain(t, args, env)
+	if err != nil {
+		t.Fatalf("main() error = %v, output:\n%s", err, out)
+	}
+	if dispatches := emulator.State().Repos[0].Dispatches; len(dispatches) != 0 {
+		t.Errorf("a dry run dispatched %d workflow(s)", len(dispatches))
+	}
+	if !strings.Contains(string(out), "dispatch-workflow") {
+		t.Errorf("output does not show the planned dispatch:\n%s", out)
+	}
+
+	if out, err := runMain(t, []string{"deploy"}, env); err == nil {
+		t.Errorf("main() with an unknown command succeeded, output:\n%s", out)
+	}
+}
+
+func TestMainFreeze(t *testing.T) {
+	tests := []struct {
+		useCase string
+		inputs  map[string]string
+	}{
+		{useCase: "Production-Release"},
+		{useCase: "Hotfix", inputs: map[string]string{"INPUT_HOTFIX_STAGE": "release"}},
+		{useCase: "Rollback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.useCase, func(t *testing.T) {
+			state := &ghemulator.State{Owner: "o", Repos: []*ghemulator.Repo{{
+				Name:      "api",
+				Branches:  []*ghemulator.Branch{{Name: "main", SHA: "a2"}},
+				Tags:      []*ghemulator.Tag{{Name: "v1.0.0", SHA: "a1"}},
+				Releases:  []*ghemulator.Release{{ID: 1, TagName: "v1.0.0"}},
+				Commits:   []*ghemulator.Commit{{SHA: "a1"}, {SHA: "a2"}},
+				Workflows: []*ghemulator.Workflow{{ID: 1, Name: "Prod", Path: ".github/workflows/prod-release.yml"}},
+				Pulls:     []*ghemulator.Pull{{Number: 1, Head: "hotfix/v1.1.0", Base: "main", State: "closed", Merged: true}},
+			}}}
+			emulator := ghemulator.New(state)
+			server := emulator.Start()
+			defer server.Close()
+			inputs := map[string]string{
+				"INPUT_GITHUB_API_URL":    server.URL,
+				"INPUT_OWNER":             "o",
+				"INPUT_GITHUB_TOKEN":      "test-token",
+				"INPUT_USE_CASE":          tt.useCase,
+				"INPUT_ENVIRONMENT":       "production",
+				"INPUT_RC_VERSION":        "v1.1.0",
+				"INPUT_PRODUCTION_BRANCH": "main",
+				"INPUT_LOG_LEVEL":         "error",
+				"INPUT_FREEZE_CALENDAR":   "windows:\n  - name: always\n    days: [mon, tue, wed, thu, fri, sat, sun]\n",
+			}
+			for name, value := range tt.inputs {
+				inputs[name] = value
+			}
+
+			if out, err := runMain(t, nil, inputs); err == nil {
+				t.Fatalf("main() during a freeze succeeded, output:\n%s", out)
+			}
+			var dispatches int
+			emulator.Inspect(func(state *ghemulator.State) { dispatches = len(state.Repos[0].Dispatches) })
+			if dispatches != 0 {
+				t.Fatalf("got %d dispatch(es) during a freeze, want none", dispatches)
+			}
+
+			inputs["INPUT_FREEZE_OVERRIDE"] = "true"
+			inputs["INPUT_FREEZE_OVERRIDE_REASON"] = "outage fix"
+			if out, err := runMain(t, nil, inputs); err != nil {
+				t.Fatalf("main() with a freeze override error = %v, output:\n%s", err, out)
+			}
+			emulator.Inspect(func(state *ghemulator.State) { dispatches = len(state.Repos[0].Dispatches) })
+			if dispatches != 1 {
+				t.Errorf("got %d dispatch(es) with a freeze override, want 1", dispatches)
+			}
+		})
+	}
+}